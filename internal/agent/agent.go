@@ -12,6 +12,7 @@ import (
 	"context"
 	_ "embed"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -31,6 +32,7 @@ import (
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/charmbracelet/crush/internal/agent/hyper"
 	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/checkpoint"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/message"
@@ -39,12 +41,6 @@ import (
 	"github.com/charmbracelet/crush/internal/stringext"
 )
 
-//go:embed templates/title.md
-var titlePrompt []byte
-
-//go:embed templates/summary.md
-var summaryPrompt []byte
-
 type SessionAgentCall struct {
 	SessionID        string
 	Prompt           string
@@ -56,20 +52,29 @@ type SessionAgentCall struct {
 	TopK             *int64
 	FrequencyPenalty *float64
 	PresencePenalty  *float64
+	// Model replaces the large model of the agent for this call only, like
+	// a fallback model does.
+	Model *Model
 }
 
 type SessionAgent interface {
 	Run(context.Context, SessionAgentCall) (*fantasy.AgentResult, error)
 	SetModels(large Model, small Model)
 	SetTools(tools []fantasy.AgentTool)
+	SetSystemPrompts(systemPrompt, summaryPrompt, titlePrompt string)
 	Cancel(sessionID string)
 	CancelAll()
 	IsSessionBusy(sessionID string) bool
 	IsBusy() bool
 	QueuedPrompts(sessionID string) int
-	QueuedPromptsList(sessionID string) []string
+	QueuedPromptsList(sessionID string) []session.QueuedPrompt
 	ClearQueue(sessionID string)
+	EditQueuedPrompt(ctx context.Context, sessionID, id, prompt string) error
+	MoveQueuedPrompt(ctx context.Context, sessionID, id string, offset int) error
+	RemoveQueuedPrompt(ctx context.Context, sessionID, id string) error
+	SteerQueuedPrompt(ctx context.Context, sessionID, id string) error
 	Summarize(context.Context, string, fantasy.ProviderOptions) error
+	Output(context.Context, string, *OutputSchema, fantasy.ProviderOptions) (json.RawMessage, error)
 	Model() Model
 }
 
@@ -84,28 +89,56 @@ type sessionAgent struct {
 	smallModel           Model
 	systemPromptPrefix   string
 	systemPrompt         string
+	summaryPrompt        string
+	titlePrompt          string
 	isSubAgent           bool
 	tools                []fantasy.AgentTool
 	sessions             session.Service
 	messages             message.Service
+	checkpoints          checkpoint.Service
 	disableAutoSummarize bool
 	isYolo               bool
-
-	messageQueue   *csync.Map[string, []SessionAgentCall]
+	budgets              config.Budgets
+	pruning              config.Pruning
+	titles               config.Titles
+	summaries            config.Summaries
+	// titleModel and summaryModel replace the small and large models for
+	// titles and summaries when set.
+	titleModel     *Model
+	summaryModel   *Model
+	summaryOptions fantasy.ProviderOptions
+
+	messageQueue   *csync.Map[string, []session.QueuedPrompt]
+	queueLocks     *csync.Map[string, *sync.Mutex]
 	activeRequests *csync.Map[string, context.CancelFunc]
 }
 
 type SessionAgentOptions struct {
-	LargeModel           Model
-	SmallModel           Model
-	SystemPromptPrefix   string
-	SystemPrompt         string
+	LargeModel         Model
+	SmallModel         Model
+	SystemPromptPrefix string
+	SystemPrompt       string
+	// SummaryPrompt and TitlePrompt default to the embedded templates.
+	SummaryPrompt        string
+	TitlePrompt          string
 	IsSubAgent           bool
 	DisableAutoSummarize bool
 	IsYolo               bool
 	Sessions             session.Service
 	Messages             message.Service
-	Tools                []fantasy.AgentTool
+	// Checkpoints records a checkpoint at each user turn when set.
+	Checkpoints checkpoint.Service
+	Budgets     config.Budgets
+	Pruning     config.Pruning
+	Titles      config.Titles
+	Summaries   config.Summaries
+	Tools       []fantasy.AgentTool
+	// TitleModel and SummaryModel are built from the models set in Titles
+	// and Summaries. The small and large models are used when they are nil.
+	TitleModel   *Model
+	SummaryModel *Model
+	// SummaryOptions are the provider options of the summary model.
+	SummaryOptions fantasy.ProviderOptions
 }
 
 func NewSessionAgent(
@@ -116,13 +149,24 @@ func NewSessionAgent(
 		smallModel:           opts.SmallModel,
 		systemPromptPrefix:   opts.SystemPromptPrefix,
 		systemPrompt:         opts.SystemPrompt,
+		summaryPrompt:        cmp.Or(opts.SummaryPrompt, string(summaryPromptTmpl)),
+		titlePrompt:          cmp.Or(opts.TitlePrompt, string(titlePromptTmpl)),
 		isSubAgent:           opts.IsSubAgent,
 		sessions:             opts.Sessions,
 		messages:             opts.Messages,
+		checkpoints:          opts.Checkpoints,
 		disableAutoSummarize: opts.DisableAutoSummarize,
 		tools:                opts.Tools,
 		isYolo:               opts.IsYolo,
-		messageQueue:         csync.NewMap[string, []SessionAgentCall](),
+		budgets:              opts.Budgets,
+		pruning:              opts.Pruning,
+		titles:               opts.Titles,
+		summaries:            opts.Summaries,
+		titleModel:           opts.TitleModel,
+		summaryModel:         opts.SummaryModel,
+		summaryOptions:       opts.SummaryOptions,
+		messageQueue:         csync.NewMap[string, []session.QueuedPrompt](),
+		queueLocks:           csync.NewMap[string, *sync.Mutex](),
 		activeRequests:       csync.NewMap[string, context.CancelFunc](),
 	}
 }
@@ -137,30 +181,42 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 
 	// Queue the message if busy
 	if a.IsSessionBusy(call.SessionID) {
-		existing, ok := a.messageQueue.Get(call.SessionID)
-		if !ok {
-			existing = []SessionAgentCall{}
-		}
-		existing = append(existing, call)
-		a.messageQueue.Set(call.SessionID, existing)
+		a.enqueue(ctx, call)
 		return nil, nil
 	}
+	largeModel := a.largeModel
+	if call.Model != nil {
+		largeModel = *call.Model
+	}
+
+	sessionLock := sync.Mutex{}
+	currentSession, err := a.sessions.Get(ctx, call.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if maxCost := a.budgets.MaxSessionCost; maxCost > 0 && currentSession.Cost >= maxCost {
+		return nil, fmt.Errorf("%w: the session cost $%.2f, the limit is $%.2f", ErrBudgetExceeded, currentSession.Cost, maxCost)
+	}
 
-	if len(a.tools) > 0 {
-		// Add Anthropic caching to the last tool.
-		a.tools[len(a.tools)-1].SetProviderOptions(a.getCacheControlOptions())
+	agentTools := sessionTools(a.tools, currentSession)
+	if len(agentTools) > 0 {
+		// Add Anthropic caching to the last tool. The last tool changes with
+		// the plan mode and the tools are shared by every session, so it is
+		// only set on this run's copy.
+		last := len(agentTools) - 1
+		agentTools[last] = &cachedTool{AgentTool: agentTools[last], providerOptions: a.getCacheControlOptions()}
 	}
 
 	agent := fantasy.NewAgent(
-		a.largeModel.Model,
+		largeModel.Model,
 		fantasy.WithSystemPrompt(a.systemPrompt),
-		fantasy.WithTools(a.tools...),
+		fantasy.WithTools(agentTools...),
 	)
 
-	sessionLock := sync.Mutex{}
-	currentSession, err := a.sessions.Get(ctx, call.SessionID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get session: %w", err)
+	// A turn cut short by crush stopping would leave the history broken for
+	// the provider, whether the session was opened in the TUI or not.
+	if _, err := a.messages.Recover(ctx, call.SessionID); err != nil {
+		return nil, fmt.Errorf("failed to recover interrupted turn: %w", err)
 	}
 
 	msgs, err := a.getSessionMessages(ctx, currentSession)
@@ -170,7 +226,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 
 	var wg sync.WaitGroup
 	// Generate title if first message.
-	if len(msgs) == 0 {
+	if len(msgs) == 0 && !a.titles.Disabled {
 		titleCtx := ctx // Copy to avoid race with ctx reassignment below.
 		wg.Go(func() {
 			a.generateTitle(titleCtx, call.SessionID, call.Prompt)
@@ -178,10 +234,15 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	}
 
 	// Add the user message to the session.
-	_, err = a.createUserMessage(ctx, call)
+	userMsg, err := a.createUserMessage(ctx, call)
 	if err != nil {
 		return nil, err
 	}
+	if a.checkpoints != nil {
+		if _, err := a.checkpoints.Create(ctx, call.SessionID, userMsg.ID, userMsg.CreatedAt); err != nil {
+			slog.Error("Failed to create checkpoint", "error", err)
+		}
+	}
 
 	// Add the session to the context.
 	ctx = context.WithValue(ctx, tools.SessionIDContextKey, call.SessionID)
@@ -193,12 +254,18 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	defer a.activeRequests.Del(call.SessionID)
 
 	history, files := a.preparePrompt(msgs, call.Attachments...)
+	if reminder := planReminder(currentSession); reminder != "" {
+		history = append([]fantasy.Message{fantasy.NewUserMessage(reminder)}, history...)
+	}
 
 	startTime := time.Now()
 	a.eventPromptSent(call.SessionID)
 
 	var currentAssistant *message.Message
 	var shouldSummarize bool
+	// Set when a budget stops the run.
+	var budgetExceeded string
+	sessionCost := currentSession.Cost
 	result, err := agent.Stream(genCtx, fantasy.AgentStreamCall{
 		Prompt:           message.PromptWithTextAttachments(call.Prompt, call.Attachments),
 		Files:            files,
@@ -216,17 +283,19 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				prepared.Messages[i].ProviderOptions = nil
 			}
 
-			queuedCalls, _ := a.messageQueue.Get(call.SessionID)
-			a.messageQueue.Del(call.SessionID)
-			for _, queued := range queuedCalls {
-				userMessage, createErr := a.createUserMessage(callContext, queued)
+			for _, queued := range a.takeSteeringPrompts(callContext, call.SessionID) {
+				userMessage, createErr := a.createUserMessage(callContext, SessionAgentCall{
+					SessionID:   call.SessionID,
+					Prompt:      queued.Prompt,
+					Attachments: queued.Attachments,
+				})
 				if createErr != nil {
 					return callContext, prepared, createErr
 				}
 				prepared.Messages = append(prepared.Messages, userMessage.ToAIMessage()...)
 			}
 
-			prepared.Messages = a.workaroundProviderMediaLimitations(prepared.Messages)
+			prepared.Messages = a.workaroundProviderMediaLimitations(largeModel, prepared.Messages)
 
 			lastSystemRoleInx := 0
 			systemMessageUpdated := false
@@ -244,7 +313,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				}
 			}
 
-			if promptPrefix := a.promptPrefix(); promptPrefix != "" {
+			if promptPrefix := a.promptPrefix(largeModel); promptPrefix != "" {
 				prepared.Messages = append([]fantasy.Message{fantasy.NewSystemMessage(promptPrefix)}, prepared.Messages...)
 			}
 
@@ -252,15 +321,15 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			assistantMsg, err = a.messages.Create(callContext, call.SessionID, message.CreateMessageParams{
 				Role:     message.Assistant,
 				Parts:    []message.ContentPart{},
-				Model:    a.largeModel.ModelCfg.Model,
-				Provider: a.largeModel.ModelCfg.Provider,
+				Model:    largeModel.ModelCfg.Model,
+				Provider: largeModel.ModelCfg.Provider,
 			})
 			if err != nil {
 				return callContext, prepared, err
 			}
 			callContext = context.WithValue(callContext, tools.MessageIDContextKey, assistantMsg.ID)
-			callContext = context.WithValue(callContext, tools.SupportsImagesContextKey, a.largeModel.CatwalkCfg.SupportsImages)
-			callContext = context.WithValue(callContext, tools.ModelNameContextKey, a.largeModel.CatwalkCfg.Name)
+			callContext = context.WithValue(callContext, tools.SupportsImagesContextKey, largeModel.CatwalkCfg.SupportsImages)
+			callContext = context.WithValue(callContext, tools.ModelNameContextKey, largeModel.CatwalkCfg.Name)
 			currentAssistant = &assistantMsg
 			return callContext, prepared, err
 		},
@@ -354,7 +423,8 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				sessionLock.Unlock()
 				return getSessionErr
 			}
-			a.updateSessionUsage(a.largeModel, &updatedSession, stepResult.Usage, a.openrouterCost(stepResult.ProviderMetadata))
+			a.updateSessionUsage(largeModel, &updatedSession, stepResult.Usage, a.openrouterCost(stepResult.ProviderMetadata))
+			sessionCost = updatedSession.Cost
 			_, sessionErr := a.sessions.Save(genCtx, updatedSession)
 			sessionLock.Unlock()
 			if sessionErr != nil {
@@ -363,17 +433,24 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
 		StopWhen: []fantasy.StopCondition{
-			func(_ []fantasy.StepResult) bool {
-				cw := int64(a.largeModel.CatwalkCfg.ContextWindow)
+			func(steps []fantasy.StepResult) bool {
+				// Runs that are done anyway did not go over any budget.
+				if steps[len(steps)-1].FinishReason != fantasy.FinishReasonToolCalls {
+					return false
+				}
+				budgetExceeded = a.checkBudgets(steps, sessionCost)
+				return budgetExceeded != ""
+			},
+			func(steps []fantasy.StepResult) bool {
+				cw := int64(largeModel.CatwalkCfg.ContextWindow)
 				tokens := currentSession.CompletionTokens + currentSession.PromptTokens
-				remaining := cw - tokens
-				var threshold int64
-				if cw > 200_000 {
-					threshold = 20_000
-				} else {
-					threshold = int64(float64(cw) * 0.2)
+				if a.pruning.Enabled() {
+					// Measure the context as it was last sent, after pruning,
+					// so that it is only summarized when pruning is not enough.
+					usage := steps[len(steps)-1].Usage
+					tokens = usage.InputTokens + usage.CacheCreationTokens + usage.OutputTokens + usage.CacheReadTokens
 				}
-				if (remaining <= threshold) && !a.disableAutoSummarize {
+				if shouldAutoSummarize(cw, tokens, a.summaries.Threshold) && !a.disableAutoSummarize {
 					shouldSummarize = true
 					return true
 				}
@@ -466,7 +543,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				currentAssistant.AddFinish(
 					message.FinishReasonError,
 					"Copilot model not enabled",
-					fmt.Sprintf("%q is not enabled in Copilot. Go to the following page to enable it. Then, wait a minute before trying again. %s", a.largeModel.CatwalkCfg.Name, link),
+					fmt.Sprintf("%q is not enabled in Copilot. Go to the following page to enable it. Then, wait a minute before trying again. %s", largeModel.CatwalkCfg.Name, link),
 				)
 			} else {
 				currentAssistant.AddFinish(message.FinishReasonError, cmp.Or(stringext.Capitalize(providerErr.Title), defaultTitle), providerErr.Message)
@@ -486,20 +563,27 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	}
 	wg.Wait()
 
+	if planHandedOff(currentSession) {
+		a.clearPlan(ctx, call.SessionID)
+	}
+
+	if budgetExceeded != "" {
+		currentAssistant.AddFinish(message.FinishReasonBudgetExceeded, "Budget exceeded", budgetExceeded)
+		if err := a.messages.Update(ctx, *currentAssistant); err != nil {
+			return nil, err
+		}
+	}
+
 	if shouldSummarize {
 		a.activeRequests.Del(call.SessionID)
 		if summarizeErr := a.Summarize(genCtx, call.SessionID, call.ProviderOptions); summarizeErr != nil {
 			return nil, summarizeErr
 		}
-		// If the agent wasn't done...
-		if len(currentAssistant.ToolCalls()) > 0 {
-			existing, ok := a.messageQueue.Get(call.SessionID)
-			if !ok {
-				existing = []SessionAgentCall{}
-			}
+		// If the agent wasn't done, it goes on unless the summary is to be
+		// reviewed first.
+		if len(currentAssistant.ToolCalls()) > 0 && !a.summaries.Review {
 			call.Prompt = fmt.Sprintf("The previous session was interrupted because it got too long, the initial user request was: `%s`", call.Prompt)
-			existing = append(existing, call)
-			a.messageQueue.Set(call.SessionID, existing)
+			a.enqueue(ctx, call)
 		}
 	}
 
@@ -507,14 +591,12 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	a.activeRequests.Del(call.SessionID)
 	cancel()
 
-	queuedMessages, ok := a.messageQueue.Get(call.SessionID)
-	if !ok || len(queuedMessages) == 0 {
+	nextCall, ok := a.dequeue(ctx, call)
+	if !ok {
 		return result, err
 	}
 	// There are queued messages restart the loop.
-	firstQueuedMessage := queuedMessages[0]
-	a.messageQueue.Set(call.SessionID, queuedMessages[1:])
-	return a.Run(ctx, firstQueuedMessage)
+	return a.Run(ctx, nextCall)
 }
 
 func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fantasy.ProviderOptions) error {
@@ -526,6 +608,9 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	if err != nil {
 		return fmt.Errorf("failed to get session: %w", err)
 	}
+	if _, err := a.messages.Recover(ctx, sessionID); err != nil {
+		return fmt.Errorf("failed to recover interrupted turn: %w", err)
+	}
 	msgs, err := a.getSessionMessages(ctx, currentSession)
 	if err != nil {
 		return err
@@ -542,13 +627,18 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	defer a.activeRequests.Del(sessionID)
 	defer cancel()
 
-	agent := fantasy.NewAgent(a.largeModel.Model,
-		fantasy.WithSystemPrompt(string(summaryPrompt)),
+	model := a.largeModel
+	if a.summaryModel != nil {
+		model = *a.summaryModel
+		opts = a.summaryOptions
+	}
+	agent := fantasy.NewAgent(model.Model,
+		fantasy.WithSystemPrompt(a.summaryPrompt),
 	)
 	summaryMessage, err := a.messages.Create(ctx, sessionID, message.CreateMessageParams{
 		Role:             message.Assistant,
-		Model:            a.largeModel.Model.Model(),
-		Provider:         a.largeModel.Model.Provider(),
+		Model:            model.Model.Model(),
+		Provider:         model.Model.Provider(),
 		IsSummaryMessage: true,
 	})
 	if err != nil {
@@ -564,6 +654,9 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		summaryPromptText += "\nInclude these tasks and their statuses in your summary. "
 		summaryPromptText += "Instruct the resuming assistant to use the `todos` tool to continue tracking progress on these tasks."
 	}
+	if a.summaries.Instructions != "" {
+		summaryPromptText += "\n\n" + a.summaries.Instructions
+	}
 
 	resp, err := agent.Stream(genCtx, fantasy.AgentStreamCall{
 		Prompt:          summaryPromptText,
@@ -623,11 +716,15 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		}
 	}
 
-	a.updateSessionUsage(a.largeModel, &currentSession, resp.TotalUsage, openrouterCost)
+	a.updateSessionUsage(model, &currentSession, resp.TotalUsage, openrouterCost)
 
 	// Just in case, get just the last usage info.
 	usage := resp.Response.Usage
 	currentSession.SummaryMessageID = summaryMessage.ID
+	// The context files loaded so far are not part of the conversation
+	// anymore, they are loaded again when the agent gets back to their
+	// directories.
+	currentSession.ContextFiles = nil
 	currentSession.CompletionTokens = usage.OutputTokens
 	currentSession.PromptTokens = 0
 	_, err = a.sessions.Save(genCtx, currentSession)
@@ -648,6 +745,21 @@ func (a *sessionAgent) getCacheControlOptions() fantasy.ProviderOptions {
 	}
 }
 
+// cachedTool is a tool with its own provider options, so that they can be
+// set for a single run.
+type cachedTool struct {
+	fantasy.AgentTool
+	providerOptions fantasy.ProviderOptions
+}
+
+func (t *cachedTool) ProviderOptions() fantasy.ProviderOptions {
+	return t.providerOptions
+}
+
+func (t *cachedTool) SetProviderOptions(opts fantasy.ProviderOptions) {
+	t.providerOptions = opts
+}
+
 func (a *sessionAgent) createUserMessage(ctx context.Context, call SessionAgentCall) (message.Message, error) {
 	parts := []message.ContentPart{message.TextContent{Text: call.Prompt}}
 	var attachmentParts []message.ContentPart
@@ -676,7 +788,7 @@ If not, please feel free to ignore. Again do not mention this message to the use
 			),
 		))
 	}
-	for _, m := range msgs {
+	for _, m := range pruneToolResults(msgs, a.pruning) {
 		if len(m.Parts) == 0 {
 			continue
 		}
@@ -730,13 +842,18 @@ func (a *sessionAgent) generateTitle(ctx context.Context, sessionID string, prom
 		return
 	}
 
+	model := a.smallModel
+	if a.titleModel != nil {
+		model = *a.titleModel
+	}
+
 	var maxOutput int64 = 40
-	if a.smallModel.CatwalkCfg.CanReason {
-		maxOutput = a.smallModel.CatwalkCfg.DefaultMaxTokens
+	if model.CatwalkCfg.CanReason {
+		maxOutput = model.CatwalkCfg.DefaultMaxTokens
 	}
 
-	agent := fantasy.NewAgent(a.smallModel.Model,
-		fantasy.WithSystemPrompt(string(titlePrompt)+"\n /no_think"),
+	agent := fantasy.NewAgent(model.Model,
+		fantasy.WithSystemPrompt(a.titlePrompt+"\n /no_think"),
 		fantasy.WithMaxOutputTokens(maxOutput),
 	)
 
@@ -783,13 +900,13 @@ func (a *sessionAgent) generateTitle(ctx context.Context, sessionID string, prom
 		}
 	}
 
-	modelConfig := a.smallModel.CatwalkCfg
+	modelConfig := model.CatwalkCfg
 	cost := modelConfig.CostPer1MInCached/1e6*float64(resp.TotalUsage.CacheCreationTokens) +
 		modelConfig.CostPer1MOutCached/1e6*float64(resp.TotalUsage.CacheReadTokens) +
 		modelConfig.CostPer1MIn/1e6*float64(resp.TotalUsage.InputTokens) +
 		modelConfig.CostPer1MOut/1e6*float64(resp.TotalUsage.OutputTokens)
 
-	if a.isClaudeCode() {
+	if a.isClaudeCode(model) {
 		cost = 0
 	}
 
@@ -830,7 +947,7 @@ func (a *sessionAgent) updateSessionUsage(model Model, session *session.Session,
 		modelConfig.CostPer1MIn/1e6*float64(usage.InputTokens) +
 		modelConfig.CostPer1MOut/1e6*float64(usage.OutputTokens)
 
-	if a.isClaudeCode() {
+	if a.isClaudeCode(model) {
 		cost = 0
 	}
 
@@ -846,6 +963,41 @@ func (a *sessionAgent) updateSessionUsage(model Model, session *session.Session,
 	session.PromptTokens = usage.InputTokens + usage.CacheCreationTokens
 }
 
+// checkBudgets describes the budget the run went over, if any.
+func (a *sessionAgent) checkBudgets(steps []fantasy.StepResult, sessionCost float64) string {
+	if maxSteps := a.budgets.MaxRunSteps; maxSteps > 0 && len(steps) >= maxSteps {
+		return fmt.Sprintf("The agent took %d steps, the limit is %d.", len(steps), maxSteps)
+	}
+	if maxTokens := a.budgets.MaxRunTokens; maxTokens > 0 {
+		var tokens int64
+		for _, step := range steps {
+			tokens += step.Usage.InputTokens + step.Usage.OutputTokens + step.Usage.CacheCreationTokens + step.Usage.CacheReadTokens
+		}
+		if tokens >= maxTokens {
+			return fmt.Sprintf("The agent used %d tokens, the limit is %d.", tokens, maxTokens)
+		}
+	}
+	if maxCost := a.budgets.MaxSessionCost; maxCost > 0 && sessionCost >= maxCost {
+		return fmt.Sprintf("The session cost $%.2f, the limit is $%.2f.", sessionCost, maxCost)
+	}
+	return ""
+}
+
+// shouldAutoSummarize reports whether a conversation using tokens of a
+// context window of size contextWindow is to be summarized. With a threshold,
+// that is once that fraction of the context window is in use. Otherwise it is
+// once less than 20% of it is left, or 20k tokens for large windows.
+func shouldAutoSummarize(contextWindow, tokens int64, threshold float64) bool {
+	if threshold > 0 {
+		return float64(tokens) >= float64(contextWindow)*threshold
+	}
+	remaining := contextWindow - tokens
+	if contextWindow > 200_000 {
+		return remaining <= 20_000
+	}
+	return remaining <= int64(float64(contextWindow)*0.2)
+}
+
 func (a *sessionAgent) Cancel(sessionID string) {
 	// Cancel regular requests.
 	if cancel, ok := a.activeRequests.Take(sessionID); ok && cancel != nil {
@@ -859,17 +1011,7 @@ func (a *sessionAgent) Cancel(sessionID string) {
 		cancel()
 	}
 
-	if a.QueuedPrompts(sessionID) > 0 {
-		slog.Info("Clearing queued prompts", "session_id", sessionID)
-		a.messageQueue.Del(sessionID)
-	}
-}
-
-func (a *sessionAgent) ClearQueue(sessionID string) {
-	if a.QueuedPrompts(sessionID) > 0 {
-		slog.Info("Clearing queued prompts", "session_id", sessionID)
-		a.messageQueue.Del(sessionID)
-	}
+	a.ClearQueue(sessionID)
 }
 
 func (a *sessionAgent) CancelAll() {
@@ -907,26 +1049,6 @@ func (a *sessionAgent) IsSessionBusy(sessionID string) bool {
 	return busy
 }
 
-func (a *sessionAgent) QueuedPrompts(sessionID string) int {
-	l, ok := a.messageQueue.Get(sessionID)
-	if !ok {
-		return 0
-	}
-	return len(l)
-}
-
-func (a *sessionAgent) QueuedPromptsList(sessionID string) []string {
-	l, ok := a.messageQueue.Get(sessionID)
-	if !ok {
-		return nil
-	}
-	prompts := make([]string, len(l))
-	for i, call := range l {
-		prompts[i] = call.Prompt
-	}
-	return prompts
-}
-
 func (a *sessionAgent) SetModels(large Model, small Model) {
 	a.largeModel = large
 	a.smallModel = small
@@ -936,20 +1058,26 @@ func (a *sessionAgent) SetTools(tools []fantasy.AgentTool) {
 	a.tools = tools
 }
 
+func (a *sessionAgent) SetSystemPrompts(systemPrompt, summaryPrompt, titlePrompt string) {
+	a.systemPrompt = systemPrompt
+	a.summaryPrompt = summaryPrompt
+	a.titlePrompt = titlePrompt
+}
+
 func (a *sessionAgent) Model() Model {
 	return a.largeModel
 }
 
-func (a *sessionAgent) promptPrefix() string {
-	if a.isClaudeCode() {
+func (a *sessionAgent) promptPrefix(model Model) string {
+	if a.isClaudeCode(model) {
 		return "You are Claude Code, Anthropic's official CLI for Claude."
 	}
 	return a.systemPromptPrefix
 }
 
-func (a *sessionAgent) isClaudeCode() bool {
+func (a *sessionAgent) isClaudeCode(model Model) bool {
 	cfg := config.Get()
-	pc, ok := cfg.Providers.Get(a.largeModel.ModelCfg.Provider)
+	pc, ok := cfg.Providers.Get(model.ModelCfg.Provider)
 	return ok && pc.ID == string(catwalk.InferenceProviderAnthropic) && pc.OAuthToken != nil
 }
 
@@ -1007,9 +1135,9 @@ func (a *sessionAgent) convertToToolResult(result fantasy.ToolResultContent) mes
 //
 //	BEFORE: [tool result: image data]
 //	AFTER:  [tool result: "Image loaded - see attached"], [user: image attachment]
-func (a *sessionAgent) workaroundProviderMediaLimitations(messages []fantasy.Message) []fantasy.Message {
-	providerSupportsMedia := a.largeModel.ModelCfg.Provider == string(catwalk.InferenceProviderAnthropic) ||
-		a.largeModel.ModelCfg.Provider == string(catwalk.InferenceProviderBedrock)
+func (a *sessionAgent) workaroundProviderMediaLimitations(model Model, messages []fantasy.Message) []fantasy.Message {
+	providerSupportsMedia := model.ModelCfg.Provider == string(catwalk.InferenceProviderAnthropic) ||
+		model.ModelCfg.Provider == string(catwalk.InferenceProviderBedrock)
 
 	if providerSupportsMedia {
 		return messages
@@ -8,7 +8,6 @@ import (
 
 	"charm.land/fantasy"
 
-	"github.com/charmbracelet/crush/internal/agent/prompt"
 	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/config"
 )
@@ -16,6 +15,9 @@ import (
 //go:embed templates/agent_tool.md
 var agentToolDescription []byte
 
+//go:embed templates/agent_tool_worktree.md
+var agentToolWorktreeDescription []byte
+
 type AgentParams struct {
 	Prompt string `json:"prompt" description:"The task for the agent to perform"`
 }
@@ -29,7 +31,7 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 	if !ok {
 		return nil, errors.New("task agent not configured")
 	}
-	prompt, err := taskPrompt(prompt.WithWorkingDir(c.cfg.WorkingDir()))
+	prompt, err := agentPrompt(agentCfg, c.cfg.WorkingDir())
 	if err != nil {
 		return nil, err
 	}
@@ -38,9 +40,13 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 	if err != nil {
 		return nil, err
 	}
+	description := agentToolDescription
+	if c.cfg.Options.SubAgentWorktrees {
+		description = agentToolWorktreeDescription
+	}
 	return fantasy.NewParallelAgentTool(
 		AgentToolName,
-		string(agentToolDescription),
+		string(description),
 		func(ctx context.Context, params AgentParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 			if params.Prompt == "" {
 				return fantasy.NewTextErrorResponse("prompt is required"), nil
@@ -56,12 +62,31 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 				return fantasy.ToolResponse{}, errors.New("agent message id missing from context")
 			}
 
+			// Each sub-agent gets its own worktree to change files in.
+			runAgent := agent
+			var worktreePath string
+			if c.cfg.Options.SubAgentWorktrees {
+				var err error
+				worktreePath, runAgent, err = c.worktreeAgent(ctx, agentCfg, sessionID, call.ID)
+				if err != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("error creating worktree: %s", err)), nil
+				}
+			}
+			// The worktree is only kept when its changes are handed back to
+			// the parent agent.
+			keepWorktree := false
+			defer func() {
+				if worktreePath != "" && !keepWorktree {
+					c.removeWorktree(worktreePath)
+				}
+			}()
+
 			agentToolSessionID := c.sessions.CreateAgentToolSessionID(agentMessageID, call.ID)
 			session, err := c.sessions.CreateTaskSession(ctx, agentToolSessionID, sessionID, "New Agent Session")
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error creating session: %s", err)
 			}
-			model := agent.Model()
+			model := runAgent.Model()
 			maxTokens := model.CatwalkCfg.DefaultMaxTokens
 			if model.ModelCfg.MaxTokens != 0 {
 				maxTokens = model.ModelCfg.MaxTokens
@@ -71,7 +96,7 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 			if !ok {
 				return fantasy.ToolResponse{}, errors.New("model provider not configured")
 			}
-			result, err := agent.Run(ctx, SessionAgentCall{
+			result, err := runAgent.Run(ctx, SessionAgentCall{
 				SessionID:        session.ID,
 				Prompt:           params.Prompt,
 				MaxOutputTokens:  maxTokens,
@@ -100,6 +125,14 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error saving parent session: %s", err)
 			}
-			return fantasy.NewTextResponse(result.Response.Content.Text()), nil
+			response := result.Response.Content.Text()
+			if worktreePath != "" {
+				response, err = c.worktreeResult(ctx, worktreePath, call.ID, response)
+				if err != nil {
+					return fantasy.ToolResponse{}, fmt.Errorf("error getting worktree changes: %s", err)
+				}
+				keepWorktree = true
+			}
+			return fantasy.NewTextResponse(response), nil
 		}), nil
 }
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckBudgets(t *testing.T) {
+	t.Parallel()
+
+	step := fantasy.StepResult{
+		Response: fantasy.Response{
+			Usage: fantasy.Usage{InputTokens: 1000, OutputTokens: 200},
+		},
+	}
+	steps := []fantasy.StepResult{step, step, step}
+
+	tests := []struct {
+		name     string
+		budgets  config.Budgets
+		cost     float64
+		exceeded string
+	}{
+		{
+			name: "no budgets",
+			cost: 100,
+		},
+		{
+			name:     "steps",
+			budgets:  config.Budgets{MaxRunSteps: 3},
+			exceeded: "The agent took 3 steps, the limit is 3.",
+		},
+		{
+			name:    "steps under the limit",
+			budgets: config.Budgets{MaxRunSteps: 4},
+		},
+		{
+			name:     "tokens",
+			budgets:  config.Budgets{MaxRunTokens: 3000},
+			exceeded: "The agent used 3600 tokens, the limit is 3000.",
+		},
+		{
+			name:     "session cost",
+			budgets:  config.Budgets{MaxSessionCost: 1},
+			cost:     1.5,
+			exceeded: "The session cost $1.50, the limit is $1.00.",
+		},
+		{
+			name:    "session cost under the limit",
+			budgets: config.Budgets{MaxSessionCost: 2},
+			cost:    1.5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := &sessionAgent{budgets: tt.budgets}
+			require.Equal(t, tt.exceeded, a.checkBudgets(steps, tt.cost))
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+// contextFileToolPaths maps the tools that load the context files of the
+// directories they touch to the parameter holding the path they touch.
+var contextFileToolPaths = map[string]string{
+	tools.EditToolName:      "file_path",
+	tools.GlobToolName:      "path",
+	tools.GrepToolName:      "path",
+	tools.MultiEditToolName: "file_path",
+	tools.ViewToolName:      "file_path",
+	tools.WriteToolName:     "file_path",
+}
+
+// contextFileNames returns the names of the context files looked for in the
+// subdirectories of the working directory, that is the context paths that
+// are plain file names.
+func contextFileNames(contextPaths []string) []string {
+	var names []string
+	for _, path := range contextPaths {
+		if path == "" || strings.ContainsAny(path, `/\~$`) || slices.Contains(names, path) {
+			continue
+		}
+		names = append(names, path)
+	}
+	return names
+}
+
+// contextFiles gives the agent the context files of the subdirectories of
+// the working directory the first time one of its tools touches a file
+// under them in a session. Context files of the working directory itself
+// are in the system prompt already.
+type contextFiles struct {
+	names      []string
+	workingDir string
+	sessions   session.Service
+	// mu keeps parallel tool calls from loading the same files twice.
+	mu sync.Mutex
+}
+
+// contextFileTool appends the context files that apply to the path the tool
+// touched to its result.
+type contextFileTool struct {
+	fantasy.AgentTool
+	files *contextFiles
+	param string
+}
+
+func withContextFiles(agentTools []fantasy.AgentTool, names []string, workingDir string, sessions session.Service) []fantasy.AgentTool {
+	if len(names) == 0 {
+		return agentTools
+	}
+	files := &contextFiles{
+		names:      names,
+		workingDir: workingDir,
+		sessions:   sessions,
+	}
+	wrapped := make([]fantasy.AgentTool, len(agentTools))
+	for i, tool := range agentTools {
+		wrapped[i] = tool
+		if param, ok := contextFileToolPaths[tool.Info().Name]; ok {
+			wrapped[i] = &contextFileTool{
+				AgentTool: tool,
+				files:     files,
+				param:     param,
+			}
+		}
+	}
+	return wrapped
+}
+
+func (t *contextFileTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	response, err := t.AgentTool.Run(ctx, call)
+	if err != nil || response.IsError {
+		return response, err
+	}
+
+	var params map[string]any
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return response, nil
+	}
+	path, _ := params[t.param].(string)
+	if path == "" {
+		return response, nil
+	}
+	// Glob and grep take the directory they search in, the other tools a
+	// file.
+	dir := path
+	if t.param == "file_path" {
+		dir = filepath.Dir(path)
+	}
+
+	reminder, err := t.files.load(ctx, tools.GetSessionFromContext(ctx), dir)
+	if err != nil {
+		slog.Warn("Failed to load context files", "dir", dir, "error", err)
+		return response, nil
+	}
+	if reminder != "" {
+		response.Content += "\n\n" + reminder
+	}
+	return response, nil
+}
+
+// load returns a reminder with the context files that apply to the given
+// directory and were not loaded in the session yet, and records them in the
+// session.
+func (f *contextFiles) load(ctx context.Context, sessionID, dir string) (string, error) {
+	if sessionID == "" {
+		return "", nil
+	}
+	found := f.find(dir)
+	if len(found) == 0 {
+		return "", nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	currentSession, err := f.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get session: %w", err)
+	}
+	var sb strings.Builder
+	for _, path := range found {
+		if slices.Contains(currentSession.ContextFiles, path) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(f.workingDir, filepath.FromSlash(path)))
+		if err != nil {
+			continue
+		}
+		currentSession.ContextFiles = append(currentSession.ContextFiles, path)
+		fmt.Fprintf(&sb, "<file path=\"%s\">\n%s\n</file>\n", path, strings.TrimSpace(string(content)))
+	}
+	if sb.Len() == 0 {
+		return "", nil
+	}
+	if _, err := f.sessions.Save(ctx, currentSession); err != nil {
+		return "", fmt.Errorf("failed to save session: %w", err)
+	}
+	return contextFilesReminder + sb.String() + "</system_reminder>", nil
+}
+
+// contextFilesReminder starts the reminder appended to the tool results
+// with the context files they loaded.
+const contextFilesReminder = "<system_reminder>The following context files apply to the files under their directory. Follow their instructions when working there, they take precedence over the ones of parent directories.\n\n"
+
+// loadedContextFiles returns the context files reminder at the end of a tool
+// result, if any.
+func loadedContextFiles(content string) string {
+	if i := strings.LastIndex(content, contextFilesReminder); i != -1 {
+		return content[i:]
+	}
+	return ""
+}
+
+// find returns the context files of the directory and of its parents up to,
+// but not including, the working directory, outermost first. Paths are
+// relative to the working directory.
+func (f *contextFiles) find(dir string) []string {
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(f.workingDir, dir)
+	}
+	rel, err := filepath.Rel(f.workingDir, dir)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+
+	var found []string
+	for ; rel != "."; rel = filepath.Dir(rel) {
+		entries, err := os.ReadDir(filepath.Join(f.workingDir, rel))
+		if err != nil {
+			continue
+		}
+		// Names are matched exactly so the same file is not loaded twice
+		// under a different case on case-insensitive file systems.
+		var dirFiles []string
+		for _, entry := range entries {
+			if !entry.IsDir() && slices.Contains(f.names, entry.Name()) {
+				dirFiles = append(dirFiles, filepath.ToSlash(filepath.Join(rel, entry.Name())))
+			}
+		}
+		found = append(dirFiles, found...)
+	}
+	return found
+}
@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextFiles(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	s, err := env.sessions.Create(t.Context(), "Context files")
+	require.NoError(t, err)
+
+	for path, content := range map[string]string{
+		"AGENTS.md":            "root rules",
+		"pkg/AGENTS.md":        "pkg rules",
+		"pkg/api/CLAUDE.md":    "api rules",
+		"pkg/api/handler.go":   "package api",
+		"pkg/store/store.go":   "package store",
+		"pkg/store/agents.txt": "not a context file",
+	} {
+		path = filepath.Join(env.workingDir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	require.Equal(t, []string{"AGENTS.md", "CLAUDE.md"}, contextFileNames([]string{".github/copilot-instructions.md", ".cursor/rules/", "AGENTS.md", "CLAUDE.md", "AGENTS.md", "~/CRUSH.md"}))
+
+	view := fantasy.NewAgentTool(tools.ViewToolName, "view", func(ctx context.Context, params tools.ViewParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+		return fantasy.NewTextResponse("viewed"), nil
+	})
+	bash := fantasy.NewAgentTool(tools.BashToolName, "bash", func(ctx context.Context, params tools.BashParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+		return fantasy.NewTextResponse("ran"), nil
+	})
+	wrapped := withContextFiles([]fantasy.AgentTool{view, bash}, []string{"AGENTS.md", "CLAUDE.md"}, env.workingDir, env.sessions)
+	require.Same(t, bash, wrapped[1])
+
+	ctx := context.WithValue(t.Context(), tools.SessionIDContextKey, s.ID)
+	run := func(path string) string {
+		response, err := wrapped[0].Run(ctx, fantasy.ToolCall{ID: "call", Name: tools.ViewToolName, Input: `{"file_path":"` + path + `"}`})
+		require.NoError(t, err)
+		return response.Content
+	}
+
+	require.Equal(t, "viewed", run(filepath.Join(env.workingDir, "main.go")))
+
+	content := run(filepath.Join(env.workingDir, "pkg", "api", "handler.go"))
+	require.Contains(t, content, "<file path=\"pkg/AGENTS.md\">\npkg rules\n</file>\n<file path=\"pkg/api/CLAUDE.md\">\napi rules\n</file>")
+	require.NotContains(t, content, "root rules")
+
+	// Relative paths are resolved against the working directory, and files
+	// are only loaded once per session.
+	require.Equal(t, "viewed", run("pkg/api/handler.go"))
+	require.Equal(t, "viewed", run(filepath.Join(env.workingDir, "pkg", "store", "store.go")))
+	require.Equal(t, "viewed", run(filepath.Join(filepath.Dir(env.workingDir), "other", "AGENTS.md")))
+
+	s, err = env.sessions.Get(t.Context(), s.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"pkg/AGENTS.md", "pkg/api/CLAUDE.md"}, s.ContextFiles)
+
+	other, err := env.sessions.Create(t.Context(), "Other")
+	require.NoError(t, err)
+	ctx = context.WithValue(t.Context(), tools.SessionIDContextKey, other.ID)
+	require.Contains(t, run("pkg/store/store.go"), "pkg rules")
+}
@@ -12,22 +12,29 @@ import (
 	"maps"
 	"net/http"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/charmbracelet/crush/internal/agent/hyper"
 	"github.com/charmbracelet/crush/internal/agent/prompt"
 	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/checkpoint"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/hooks"
 	"github.com/charmbracelet/crush/internal/log"
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/symbols"
 	"golang.org/x/sync/errgroup"
 
 	"charm.land/fantasy/providers/anthropic"
@@ -42,31 +49,83 @@ import (
 )
 
 type Coordinator interface {
-	// INFO: (kujtim) this is not used yet we will use this when we have multiple agents
-	// SetMainAgent(string)
+	// SetMainAgent switches the agent that handles new prompts.
+	SetMainAgent(ctx context.Context, agentID string) error
+	// MainAgent returns the configuration of the agent that handles new
+	// prompts.
+	MainAgent() config.Agent
 	Run(ctx context.Context, sessionID, prompt string, attachments ...message.Attachment) (*fantasy.AgentResult, error)
 	Cancel(sessionID string)
 	CancelAll()
 	IsSessionBusy(sessionID string) bool
 	IsBusy() bool
 	QueuedPrompts(sessionID string) int
-	QueuedPromptsList(sessionID string) []string
+	QueuedPromptsList(sessionID string) []session.QueuedPrompt
 	ClearQueue(sessionID string)
+	// EditQueuedPrompt changes the text of a queued prompt.
+	EditQueuedPrompt(ctx context.Context, sessionID, id, prompt string) error
+	// MoveQueuedPrompt moves a queued prompt by offset positions in the
+	// queue.
+	MoveQueuedPrompt(ctx context.Context, sessionID, id string, offset int) error
+	RemoveQueuedPrompt(ctx context.Context, sessionID, id string) error
+	// SteerQueuedPrompt sends a queued prompt to the agent before its next
+	// step instead of waiting for the current run to finish.
+	SteerQueuedPrompt(ctx context.Context, sessionID, id string) error
+	// Recover finishes the last turn of a session that is not busy if crush
+	// stopped in the middle of it, as the history would be broken for the
+	// providers otherwise. It reports whether the turn was interrupted.
+	Recover(ctx context.Context, sessionID string) (bool, error)
+	// ResumeQueue runs the prompts left in the queue of a session that is
+	// not busy, like the ones queued before crush stopped.
+	ResumeQueue(ctx context.Context, sessionID string) (*fantasy.AgentResult, error)
 	Summarize(context.Context, string) error
+	// EditSummary replaces the text of a summary message. Only the latest
+	// summary of the session, which the agent continues the conversation
+	// from, can be edited.
+	EditSummary(ctx context.Context, sessionID, messageID, summary string) error
+	// Output asks the agent for the final answer of the session as a JSON
+	// document matching the schema.
+	Output(ctx context.Context, sessionID string, schema *OutputSchema) (json.RawMessage, error)
+	// SetPlanMode switches the session between plan mode, where the agent
+	// only has read-only tools and writes a plan, and execution mode.
+	SetPlanMode(ctx context.Context, sessionID string, planMode bool) error
+	// Resend rewinds the session to an earlier user message and runs the
+	// agent again from there with the edited prompt.
+	Resend(ctx context.Context, sessionID, messageID, prompt string, opts ResendOptions) (*fantasy.AgentResult, error)
+	// Continue runs the agent again on a session whose last turn was
+	// interrupted by crush stopping, picking up from where it stopped.
+	Continue(ctx context.Context, sessionID string) (*fantasy.AgentResult, error)
 	Model() Model
 	UpdateModels(ctx context.Context) error
 }
 
+// ResendOptions controls what happens to the conversation that follows a
+// resent prompt.
+type ResendOptions struct {
+	// KeepBranch keeps the discarded messages as a hidden branch instead of
+	// deleting them.
+	KeepBranch bool
+	// RestoreFiles puts the files changed since the prompt back to their
+	// earlier content.
+	RestoreFiles bool
+}
+
 type coordinator struct {
 	cfg         *config.Config
 	sessions    session.Service
 	messages    message.Service
 	permissions permission.Service
 	history     history.Service
+	checkpoints checkpoint.Service
 	lspClients  *csync.Map[string, *lsp.Client]
+	symbols     *symbols.Index
+	hooks       *hooks.Runner
 
-	currentAgent SessionAgent
-	agents       map[string]SessionAgent
+	// mainAgent is read by the runs while the TUI switches it.
+	mainAgent atomic.Pointer[mainAgent]
+	// switchMu makes agent switches happen one at a time.
+	switchMu sync.Mutex
+	agents   *csync.Map[string, SessionAgent]
 
 	readyWg errgroup.Group
 }
@@ -78,7 +137,9 @@ func NewCoordinator(
 	messages message.Service,
 	permissions permission.Service,
 	history history.Service,
+	checkpoints checkpoint.Service,
 	lspClients *csync.Map[string, *lsp.Client],
+	symbolIndex *symbols.Index,
 ) (Coordinator, error) {
 	c := &coordinator{
 		cfg:         cfg,
@@ -86,28 +147,111 @@ func NewCoordinator(
 		messages:    messages,
 		permissions: permissions,
 		history:     history,
+		checkpoints: checkpoints,
 		lspClients:  lspClients,
-		agents:      make(map[string]SessionAgent),
+		symbols:     symbolIndex,
+		hooks:       hooks.NewRunner(cfg.Hooks, cfg.WorkingDir()),
+		agents:      csync.NewMap[string, SessionAgent](),
+	}
+
+	if err := c.SetMainAgent(ctx, config.AgentCoder); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// mainAgent is the agent the prompts of the coordinator run on.
+type mainAgent struct {
+	id    string
+	agent SessionAgent
+}
+
+// currentAgent returns the agent the prompts run on.
+func (c *coordinator) currentAgent() SessionAgent {
+	if main := c.mainAgent.Load(); main != nil {
+		return main.agent
+	}
+	return nil
+}
+
+// currentAgentID returns the ID of the agent the prompts run on.
+func (c *coordinator) currentAgentID() string {
+	if main := c.mainAgent.Load(); main != nil {
+		return main.id
+	}
+	return ""
+}
+
+// SetMainAgent implements Coordinator.
+func (c *coordinator) SetMainAgent(ctx context.Context, agentID string) error {
+	c.switchMu.Lock()
+	defer c.switchMu.Unlock()
+
+	if agentID == c.currentAgentID() {
+		return nil
+	}
+	if current := c.currentAgent(); current != nil && current.IsBusy() {
+		return errors.New("cannot switch agents while the agent is busy")
 	}
 
-	agentCfg, ok := cfg.Agents[config.AgentCoder]
+	agentCfg, ok := c.cfg.Agents[agentID]
 	if !ok {
-		return nil, errors.New("coder agent not configured")
+		return fmt.Errorf("%s agent not configured", agentID)
+	}
+	if agentCfg.Disabled {
+		return fmt.Errorf("%s agent is disabled", agentID)
+	}
+	if config.IsSubAgent(agentID) {
+		return fmt.Errorf("%s agent can only be used as a sub-agent", agentID)
+	}
+
+	if agent, ok := c.agents.Get(agentID); ok {
+		c.mainAgent.Store(&mainAgent{id: agentID, agent: agent})
+		// Make sure a previously built agent picks up config changes.
+		return c.UpdateModels(ctx)
 	}
 
-	// TODO: make this dynamic when we support multiple agents
-	prompt, err := coderPrompt(prompt.WithWorkingDir(c.cfg.WorkingDir()))
+	prompt, err := agentPrompt(agentCfg, c.cfg.WorkingDir())
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	agent, err := c.buildAgent(ctx, prompt, agentCfg, false)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	c.agents.Set(agentID, agent)
+	c.mainAgent.Store(&mainAgent{id: agentID, agent: agent})
+	return nil
+}
+
+// MainAgent implements Coordinator.
+func (c *coordinator) MainAgent() config.Agent {
+	return c.cfg.Agents[c.currentAgentID()]
+}
+
+// agentPrompt returns the system prompt of the given agent working in
+// workingDir, falling back to the embedded templates when the agent does not
+// define its own.
+func agentPrompt(agent config.Agent, workingDir string) (*prompt.Prompt, error) {
+	opts := []prompt.Option{
+		prompt.WithWorkingDir(workingDir),
+		prompt.WithContextPaths(agent.ContextPaths...),
+	}
+	switch {
+	case agent.Prompt != "":
+		return prompt.NewPrompt(agent.ID, agent.Prompt, opts...)
+	case agent.PromptFile != "":
+		tmpl, err := os.ReadFile(agent.PromptFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading prompt file of %s agent: %w", agent.ID, err)
+		}
+		return prompt.NewPrompt(agent.ID, string(tmpl), opts...)
+	case agent.ID == config.AgentTask:
+		return taskPrompt(opts...)
+	default:
+		return coderPrompt(opts...)
 	}
-	c.currentAgent = agent
-	c.agents[config.AgentCoder] = agent
-	return c, nil
 }
 
 // Run implements Coordinator.
@@ -116,7 +260,85 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 		return nil, err
 	}
 
-	model := c.currentAgent.Model()
+	if err := c.runPromptHooks(ctx, sessionID, prompt); err != nil {
+		return nil, err
+	}
+	return c.runPrompt(ctx, sessionID, prompt, attachments...)
+}
+
+// runPrompt runs a prompt that already went through the prompt hooks.
+func (c *coordinator) runPrompt(ctx context.Context, sessionID string, prompt string, attachments ...message.Attachment) (*fantasy.AgentResult, error) {
+	result, err := c.run(ctx, c.currentAgent().Model(), sessionID, prompt, attachments...)
+	if isProviderUnavailable(err) {
+		result, err = c.runFallbacks(ctx, sessionID, err)
+	}
+	c.runStopHooks(ctx, sessionID, result, err)
+	return result, err
+}
+
+// runFallbacks runs the failed prompt of the session again on each fallback
+// of the agent model in turn, for as long as their providers are
+// unavailable too. Only the failed run uses the fallbacks, the agent keeps
+// its configured model. Turns that already ran tools are not run again, as
+// that would repeat their side effects.
+func (c *coordinator) runFallbacks(ctx context.Context, sessionID string, err error) (*fantasy.AgentResult, error) {
+	agentCfg := c.cfg.Agents[c.currentAgentID()]
+	modelCfg := c.cfg.AgentModel(agentCfg)
+	if len(modelCfg.Fallbacks) == 0 {
+		return nil, err
+	}
+
+	from := modelCfg
+	for _, fallback := range modelCfg.Fallbacks {
+		model, buildErr := c.buildModel(ctx, fallback)
+		if buildErr != nil {
+			slog.Warn("Skipping fallback model", "provider", fallback.Provider, "model", fallback.Model, "error", buildErr)
+			continue
+		}
+
+		msgs, listErr := c.messages.List(ctx, sessionID)
+		if listErr != nil {
+			return nil, listErr
+		}
+		idx := -1
+		for i, m := range msgs {
+			if m.Role == message.User {
+				idx = i
+			}
+		}
+		if idx == -1 {
+			return nil, err
+		}
+		if toolsRan(msgs[idx+1:]) {
+			slog.Warn("Not switching to fallback model, the failed turn already ran tools", "from", from.Model, "to", fallback.Model, "error", err)
+			return nil, err
+		}
+
+		// Drop the failed turn and its checkpoint, it is about to be run
+		// again.
+		prompt := msgs[idx]
+		if _, rewindErr := c.rewind(ctx, sessionID, prompt.ID, false); rewindErr != nil {
+			return nil, fmt.Errorf("failed to rewind session: %w", rewindErr)
+		}
+
+		slog.Warn("Provider unavailable, switching to fallback model", "from", from.Model, "to", fallback.Model, "error", err)
+		fallbackBroker.Publish(pubsub.UpdatedEvent, FallbackEvent{
+			SessionID: sessionID,
+			From:      from,
+			To:        fallback,
+			Err:       err,
+		})
+		var result *fantasy.AgentResult
+		result, err = c.run(ctx, model, sessionID, prompt.Content().Text, messageAttachments(prompt)...)
+		if !isProviderUnavailable(err) {
+			return result, err
+		}
+		from = fallback
+	}
+	return nil, err
+}
+
+func (c *coordinator) run(ctx context.Context, model Model, sessionID string, prompt string, attachments ...message.Attachment) (*fantasy.AgentResult, error) {
 	maxTokens := model.CatwalkCfg.DefaultMaxTokens
 	if model.ModelCfg.MaxTokens != 0 {
 		maxTokens = model.ModelCfg.MaxTokens
@@ -148,7 +370,7 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 	}
 
 	run := func() (*fantasy.AgentResult, error) {
-		return c.currentAgent.Run(ctx, SessionAgentCall{
+		return c.currentAgent().Run(ctx, SessionAgentCall{
 			SessionID:        sessionID,
 			Prompt:           prompt,
 			Attachments:      attachments,
@@ -159,6 +381,7 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 			TopK:             topK,
 			FrequencyPenalty: freqPenalty,
 			PresencePenalty:  presPenalty,
+			Model:            &model,
 		})
 	}
 	result, originalErr := run()
@@ -316,31 +539,52 @@ func mergeCallOptions(model Model, cfg config.ProviderConfig) (fantasy.ProviderO
 }
 
 func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, agent config.Agent, isSubAgent bool) (SessionAgent, error) {
-	large, small, err := c.buildAgentModels(ctx)
+	large, small, err := c.buildAgentModels(ctx, agent)
 	if err != nil {
 		return nil, err
 	}
 
-	systemPrompt, err := prompt.Build(ctx, large.Model.Provider(), large.Model.Model(), *c.cfg)
+	side, err := c.buildSideModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	systemPrompt, summaryPrompt, titlePrompt, err := buildPrompts(ctx, c.cfg, prompt, large.ModelCfg, small.ModelCfg)
 	if err != nil {
 		return nil, err
 	}
 
+	// Sub-agents work in their own sessions, the user turn that started them
+	// already has a checkpoint.
+	checkpoints := c.checkpoints
+	if isSubAgent {
+		checkpoints = nil
+	}
+
 	largeProviderCfg, _ := c.cfg.Providers.Get(large.ModelCfg.Provider)
 	result := NewSessionAgent(SessionAgentOptions{
 		large,
 		small,
 		largeProviderCfg.SystemPromptPrefix,
 		systemPrompt,
+		summaryPrompt,
+		titlePrompt,
 		isSubAgent,
 		c.cfg.Options.DisableAutoSummarize,
 		c.permissions.SkipRequests(),
 		c.sessions,
 		c.messages,
+		checkpoints,
+		*c.cfg.Options.Budgets,
+		*c.cfg.Options.Pruning,
+		*c.cfg.Options.Titles,
+		*c.cfg.Options.Summaries,
 		nil,
+		side.title,
+		side.summary,
+		side.summaryOptions,
 	})
 	c.readyWg.Go(func() error {
-		tools, err := c.buildTools(ctx, agent)
+		tools, err := c.buildTools(ctx, agent, c.cfg.WorkingDir(), c.lspClients)
 		if err != nil {
 			return err
 		}
@@ -351,7 +595,56 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 	return result, nil
 }
 
-func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fantasy.AgentTool, error) {
+// buildPrompts builds the system prompt of an agent for its large model,
+// along with the summary and title prompts for the models that write them,
+// so overrides for specific models apply to each.
+func buildPrompts(ctx context.Context, cfg *config.Config, tmpl *prompt.Prompt, large, small config.SelectedModel) (string, string, string, error) {
+	systemPrompt, err := tmpl.Build(ctx, large.Provider, large.Model, *cfg)
+	if err != nil {
+		return "", "", "", err
+	}
+	if model := cfg.Options.Summaries.Model; model != nil {
+		large = *model
+	}
+	if model := cfg.Options.Titles.Model; model != nil {
+		small = *model
+	}
+	summary, err := summaryPrompt()
+	if err != nil {
+		return "", "", "", err
+	}
+	summaryText, err := summary.Build(ctx, large.Provider, large.Model, *cfg)
+	if err != nil {
+		return "", "", "", err
+	}
+	title, err := titlePrompt()
+	if err != nil {
+		return "", "", "", err
+	}
+	titleText, err := title.Build(ctx, small.Provider, small.Model, *cfg)
+	if err != nil {
+		return "", "", "", err
+	}
+	return systemPrompt, summaryText, titleText, nil
+}
+
+// RenderPrompts returns the system, summary and title prompts the agent with
+// the given ID runs with on the models selected in the config, with the
+// prompt template overrides applied.
+func RenderPrompts(ctx context.Context, cfg *config.Config, agentID string) (string, string, string, error) {
+	agentCfg, ok := cfg.Agents[agentID]
+	if !ok {
+		return "", "", "", fmt.Errorf("%s agent not configured", agentID)
+	}
+	tmpl, err := agentPrompt(agentCfg, cfg.WorkingDir())
+	if err != nil {
+		return "", "", "", err
+	}
+	return buildPrompts(ctx, cfg, tmpl, cfg.AgentModel(agentCfg), cfg.Models[config.SelectedModelTypeSmall])
+}
+
+// buildTools returns the tools of the given agent, working in workingDir.
+func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, workingDir string, lspClients *csync.Map[string, *lsp.Client]) ([]fantasy.AgentTool, error) {
 	var allTools []fantasy.AgentTool
 	if slices.Contains(agent.AllowedTools, AgentToolName) {
 		agentTool, err := c.agentTool(ctx)
@@ -371,32 +664,36 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 
 	// Get the model name for the agent
 	modelName := ""
-	if modelCfg, ok := c.cfg.Models[agent.Model]; ok {
-		if model := c.cfg.GetModel(modelCfg.Provider, modelCfg.Model); model != nil {
-			modelName = model.Name
-		}
+	modelCfg := c.cfg.AgentModel(agent)
+	if model := c.cfg.GetModel(modelCfg.Provider, modelCfg.Model); model != nil {
+		modelName = model.Name
 	}
 
 	allTools = append(allTools,
-		tools.NewBashTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Options.Attribution, modelName),
+		tools.NewBashTool(c.permissions, workingDir, c.cfg.Options.Attribution, modelName),
 		tools.NewJobOutputTool(),
 		tools.NewJobKillTool(),
-		tools.NewDownloadTool(c.permissions, c.cfg.WorkingDir(), nil),
-		tools.NewEditTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
-		tools.NewMultiEditTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
-		tools.NewFetchTool(c.permissions, c.cfg.WorkingDir(), nil),
-		tools.NewGlobTool(c.cfg.WorkingDir()),
-		tools.NewGrepTool(c.cfg.WorkingDir()),
-		tools.NewLsTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Tools.Ls),
+		tools.NewDownloadTool(c.permissions, workingDir, nil),
+		tools.NewEditTool(lspClients, c.permissions, c.history, workingDir, *c.cfg.Options.Formatting),
+		tools.NewMultiEditTool(lspClients, c.permissions, c.history, workingDir, *c.cfg.Options.Formatting),
+		tools.NewFetchTool(c.permissions, workingDir, nil),
+		tools.NewGlobTool(workingDir),
+		tools.NewGrepTool(workingDir),
+		tools.NewLsTool(c.permissions, workingDir, c.cfg.Tools.Ls),
+		tools.NewMemoryTool(c.cfg.Options.DataDirectory),
+		tools.NewPlanTool(c.sessions),
 		tools.NewSourcegraphTool(nil),
+		tools.NewSymbolsTool(c.symbols, workingDir),
 		tools.NewTodosTool(c.sessions),
-		tools.NewViewTool(c.lspClients, c.permissions, c.cfg.WorkingDir(), c.cfg.Options.SkillsPaths...),
-		tools.NewWriteTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
+		tools.NewViewTool(lspClients, c.permissions, workingDir, c.cfg.Options.SkillsPaths...),
+		tools.NewWriteTool(lspClients, c.permissions, c.history, workingDir, *c.cfg.Options.Formatting),
+		tools.NewWorktreeTool(c.permissions, c.history, workingDir, c.cfg.Options.DataDirectory),
 	)
 
 	if len(c.cfg.LSP) > 0 {
-		allTools = append(allTools, tools.NewDiagnosticsTool(c.lspClients), tools.NewReferencesTool(c.lspClients))
+		allTools = append(allTools, tools.NewDiagnosticsTool(lspClients), tools.NewReferencesTool(lspClients), tools.NewDefinitionTool(lspClients), tools.NewHoverTool(lspClients), tools.NewRenameTool(lspClients, c.permissions, c.history, workingDir), tools.NewCodeActionsTool(lspClients, c.permissions, c.history, workingDir))
 	}
+	allTools = append(allTools, tools.NewCustomTools(c.cfg.Tools.Custom, c.permissions, workingDir)...)
 
 	var filteredTools []fantasy.AgentTool
 	for _, tool := range allTools {
@@ -405,7 +702,7 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 		}
 	}
 
-	for _, tool := range tools.GetMCPTools(c.permissions, c.cfg.WorkingDir()) {
+	for _, tool := range tools.GetMCPTools(c.permissions, workingDir) {
 		// Check MCP-specific disabled tools.
 		if mcpCfg, ok := c.cfg.MCP[tool.MCP()]; ok {
 			if slices.Contains(mcpCfg.DisabledTools, tool.MCPToolName()) {
@@ -436,91 +733,110 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 	slices.SortFunc(filteredTools, func(a, b fantasy.AgentTool) int {
 		return strings.Compare(a.Info().Name, b.Info().Name)
 	})
+	contextPaths := c.cfg.Options.ContextPaths
+	if agent.ContextPaths != nil {
+		contextPaths = agent.ContextPaths
+	}
+	filteredTools = withContextFiles(filteredTools, contextFileNames(contextPaths), workingDir, c.sessions)
+	if c.cfg.Hooks.HasToolHooks() {
+		filteredTools = withHooks(filteredTools, c.hooks, c.permissions)
+	}
 	return filteredTools, nil
 }
 
-// TODO: when we support multiple agents we need to change this so that we pass in the agent specific model config
-func (c *coordinator) buildAgentModels(ctx context.Context) (Model, Model, error) {
-	largeModelCfg, ok := c.cfg.Models[config.SelectedModelTypeLarge]
-	if !ok {
-		return Model{}, Model{}, errors.New("large model not selected")
-	}
+// buildAgentModels returns the models used by the given agent. The first
+// model drives the agent, the second one is used for titles.
+func (c *coordinator) buildAgentModels(ctx context.Context, agent config.Agent) (Model, Model, error) {
 	smallModelCfg, ok := c.cfg.Models[config.SelectedModelTypeSmall]
 	if !ok {
 		return Model{}, Model{}, errors.New("small model not selected")
 	}
-
-	largeProviderCfg, ok := c.cfg.Providers.Get(largeModelCfg.Provider)
-	if !ok {
-		return Model{}, Model{}, errors.New("large model provider not configured")
+	agentModelCfg := c.cfg.AgentModel(agent)
+	if agentModelCfg.Model == "" {
+		return Model{}, Model{}, fmt.Errorf("%s model not selected", agent.Model)
 	}
 
-	largeProvider, err := c.buildProvider(largeProviderCfg, largeModelCfg)
+	agentModel, err := c.buildModel(ctx, agentModelCfg)
 	if err != nil {
-		return Model{}, Model{}, err
-	}
-
-	smallProviderCfg, ok := c.cfg.Providers.Get(smallModelCfg.Provider)
-	if !ok {
-		return Model{}, Model{}, errors.New("large model provider not configured")
+		return Model{}, Model{}, fmt.Errorf("%s agent model: %w", agent.ID, err)
 	}
-
-	smallProvider, err := c.buildProvider(smallProviderCfg, largeModelCfg)
+	small, err := c.buildModel(ctx, smallModelCfg)
 	if err != nil {
-		return Model{}, Model{}, err
+		return Model{}, Model{}, fmt.Errorf("small model: %w", err)
 	}
+	return agentModel, small, nil
+}
 
-	var largeCatwalkModel *catwalk.Model
-	var smallCatwalkModel *catwalk.Model
+// sideModels are the models configured to write session titles and
+// summaries. They are nil when the small and large models are used instead.
+type sideModels struct {
+	title   *Model
+	summary *Model
+	// summaryOptions are the provider options of the summary model.
+	summaryOptions fantasy.ProviderOptions
+}
 
-	for _, m := range largeProviderCfg.Models {
-		if m.ID == largeModelCfg.Model {
-			largeCatwalkModel = &m
+func (c *coordinator) buildSideModels(ctx context.Context) (sideModels, error) {
+	var side sideModels
+	if modelCfg := c.cfg.Options.Titles.Model; modelCfg != nil && !c.cfg.Options.Titles.Disabled {
+		model, err := c.buildModel(ctx, *modelCfg)
+		if err != nil {
+			return sideModels{}, fmt.Errorf("title model: %w", err)
 		}
+		side.title = &model
 	}
-	for _, m := range smallProviderCfg.Models {
-		if m.ID == smallModelCfg.Model {
-			smallCatwalkModel = &m
+	if modelCfg := c.cfg.Options.Summaries.Model; modelCfg != nil {
+		model, err := c.buildModel(ctx, *modelCfg)
+		if err != nil {
+			return sideModels{}, fmt.Errorf("summary model: %w", err)
+		}
+		providerCfg, ok := c.cfg.Providers.Get(modelCfg.Provider)
+		if !ok {
+			return sideModels{}, fmt.Errorf("summary model: provider %q not configured", modelCfg.Provider)
 		}
+		side.summary = &model
+		side.summaryOptions = getProviderOptions(model, providerCfg)
 	}
+	return side, nil
+}
 
-	if largeCatwalkModel == nil {
-		return Model{}, Model{}, errors.New("large model not found in provider config")
+// buildModel builds the language model for the given model config.
+func (c *coordinator) buildModel(ctx context.Context, modelCfg config.SelectedModel) (Model, error) {
+	providerCfg, ok := c.cfg.Providers.Get(modelCfg.Provider)
+	if !ok {
+		return Model{}, fmt.Errorf("provider %q not configured", modelCfg.Provider)
 	}
 
-	if smallCatwalkModel == nil {
-		return Model{}, Model{}, errors.New("snall model not found in provider config")
+	provider, err := c.buildProvider(providerCfg, modelCfg)
+	if err != nil {
+		return Model{}, err
 	}
 
-	largeModelID := largeModelCfg.Model
-	smallModelID := smallModelCfg.Model
-
-	if largeModelCfg.Provider == openrouter.Name && isExactoSupported(largeModelID) {
-		largeModelID += ":exacto"
+	var catwalkModel *catwalk.Model
+	for _, m := range providerCfg.Models {
+		if m.ID == modelCfg.Model {
+			catwalkModel = &m
+		}
 	}
-
-	if smallModelCfg.Provider == openrouter.Name && isExactoSupported(smallModelID) {
-		smallModelID += ":exacto"
+	if catwalkModel == nil {
+		return Model{}, fmt.Errorf("model %q not found in provider config", modelCfg.Model)
 	}
 
-	largeModel, err := largeProvider.LanguageModel(ctx, largeModelID)
-	if err != nil {
-		return Model{}, Model{}, err
+	modelID := modelCfg.Model
+	if modelCfg.Provider == openrouter.Name && isExactoSupported(modelID) {
+		modelID += ":exacto"
 	}
-	smallModel, err := smallProvider.LanguageModel(ctx, smallModelID)
+
+	model, err := provider.LanguageModel(ctx, modelID)
 	if err != nil {
-		return Model{}, Model{}, err
+		return Model{}, err
 	}
 
 	return Model{
-			Model:      largeModel,
-			CatwalkCfg: *largeCatwalkModel,
-			ModelCfg:   largeModelCfg,
-		}, Model{
-			Model:      smallModel,
-			CatwalkCfg: *smallCatwalkModel,
-			ModelCfg:   smallModelCfg,
-		}, nil
+		Model:      model,
+		CatwalkCfg: *catwalkModel,
+		ModelCfg:   modelCfg,
+	}, nil
 }
 
 func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map[string]string, isOauth bool) (fantasy.Provider, error) {
@@ -765,64 +1081,302 @@ func isExactoSupported(modelID string) bool {
 }
 
 func (c *coordinator) Cancel(sessionID string) {
-	c.currentAgent.Cancel(sessionID)
+	c.currentAgent().Cancel(sessionID)
 }
 
 func (c *coordinator) CancelAll() {
-	c.currentAgent.CancelAll()
+	c.currentAgent().CancelAll()
 }
 
 func (c *coordinator) ClearQueue(sessionID string) {
-	c.currentAgent.ClearQueue(sessionID)
+	c.currentAgent().ClearQueue(sessionID)
 }
 
 func (c *coordinator) IsBusy() bool {
-	return c.currentAgent.IsBusy()
+	return c.currentAgent().IsBusy()
 }
 
 func (c *coordinator) IsSessionBusy(sessionID string) bool {
-	return c.currentAgent.IsSessionBusy(sessionID)
+	return c.currentAgent().IsSessionBusy(sessionID)
 }
 
 func (c *coordinator) Model() Model {
-	return c.currentAgent.Model()
+	return c.currentAgent().Model()
 }
 
 func (c *coordinator) UpdateModels(ctx context.Context) error {
+	// The agent is read once, in case it is switched meanwhile.
+	current := c.mainAgent.Load()
+	agentCfg, ok := c.cfg.Agents[current.id]
+	if !ok {
+		return fmt.Errorf("%s agent not configured", current.id)
+	}
+
 	// build the models again so we make sure we get the latest config
-	large, small, err := c.buildAgentModels(ctx)
+	large, small, err := c.buildAgentModels(ctx, agentCfg)
 	if err != nil {
 		return err
 	}
-	c.currentAgent.SetModels(large, small)
+	current.agent.SetModels(large, small)
 
-	agentCfg, ok := c.cfg.Agents[config.AgentCoder]
-	if !ok {
-		return errors.New("coder agent not configured")
+	// Prompt templates may be overridden for specific models.
+	tmpl, err := agentPrompt(agentCfg, c.cfg.WorkingDir())
+	if err != nil {
+		return err
+	}
+	systemPrompt, summaryPrompt, titlePrompt, err := buildPrompts(ctx, c.cfg, tmpl, large.ModelCfg, small.ModelCfg)
+	if err != nil {
+		return err
 	}
+	current.agent.SetSystemPrompts(systemPrompt, summaryPrompt, titlePrompt)
 
-	tools, err := c.buildTools(ctx, agentCfg)
+	tools, err := c.buildTools(ctx, agentCfg, c.cfg.WorkingDir(), c.lspClients)
 	if err != nil {
 		return err
 	}
-	c.currentAgent.SetTools(tools)
+	current.agent.SetTools(tools)
 	return nil
 }
 
 func (c *coordinator) QueuedPrompts(sessionID string) int {
-	return c.currentAgent.QueuedPrompts(sessionID)
+	return c.currentAgent().QueuedPrompts(sessionID)
 }
 
-func (c *coordinator) QueuedPromptsList(sessionID string) []string {
-	return c.currentAgent.QueuedPromptsList(sessionID)
+func (c *coordinator) QueuedPromptsList(sessionID string) []session.QueuedPrompt {
+	return c.currentAgent().QueuedPromptsList(sessionID)
+}
+
+func (c *coordinator) EditQueuedPrompt(ctx context.Context, sessionID, id, prompt string) error {
+	return c.currentAgent().EditQueuedPrompt(ctx, sessionID, id, prompt)
+}
+
+func (c *coordinator) MoveQueuedPrompt(ctx context.Context, sessionID, id string, offset int) error {
+	return c.currentAgent().MoveQueuedPrompt(ctx, sessionID, id, offset)
+}
+
+func (c *coordinator) RemoveQueuedPrompt(ctx context.Context, sessionID, id string) error {
+	return c.currentAgent().RemoveQueuedPrompt(ctx, sessionID, id)
+}
+
+func (c *coordinator) SteerQueuedPrompt(ctx context.Context, sessionID, id string) error {
+	return c.currentAgent().SteerQueuedPrompt(ctx, sessionID, id)
+}
+
+func (c *coordinator) Recover(ctx context.Context, sessionID string) (bool, error) {
+	if c.currentAgent().IsSessionBusy(sessionID) {
+		// The turn in progress is not interrupted.
+		return false, nil
+	}
+	return c.messages.Recover(ctx, sessionID)
+}
+
+func (c *coordinator) ResumeQueue(ctx context.Context, sessionID string) (*fantasy.AgentResult, error) {
+	if err := c.readyWg.Wait(); err != nil {
+		return nil, err
+	}
+	if c.currentAgent().IsSessionBusy(sessionID) {
+		// The running turn takes the queued prompts when it finishes.
+		return nil, nil
+	}
+	queued := c.currentAgent().QueuedPromptsList(sessionID)
+	if len(queued) == 0 {
+		return nil, nil
+	}
+	next := queued[0]
+	if err := c.currentAgent().RemoveQueuedPrompt(ctx, sessionID, next.ID); err != nil {
+		return nil, err
+	}
+	// The prompt hooks ran when the prompt was queued, and the run takes the
+	// rest of the queue when it finishes.
+	return c.runPrompt(ctx, sessionID, next.Prompt, next.Attachments...)
 }
 
 func (c *coordinator) Summarize(ctx context.Context, sessionID string) error {
-	providerCfg, ok := c.cfg.Providers.Get(c.currentAgent.Model().ModelCfg.Provider)
+	providerCfg, ok := c.cfg.Providers.Get(c.currentAgent().Model().ModelCfg.Provider)
 	if !ok {
 		return errors.New("model provider not configured")
 	}
-	return c.currentAgent.Summarize(ctx, sessionID, getProviderOptions(c.currentAgent.Model(), providerCfg))
+	return c.currentAgent().Summarize(ctx, sessionID, getProviderOptions(c.currentAgent().Model(), providerCfg))
+}
+
+func (c *coordinator) EditSummary(ctx context.Context, sessionID, messageID, summary string) error {
+	if c.currentAgent().IsSessionBusy(sessionID) {
+		return ErrSessionBusy
+	}
+	currentSession, err := c.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	if currentSession.SummaryMessageID != messageID {
+		return errors.New("only the latest summary can be edited")
+	}
+	msg, err := c.messages.Get(ctx, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to get summary: %w", err)
+	}
+	msg.SetContent(summary)
+	return c.messages.Update(ctx, msg)
+}
+
+func (c *coordinator) Output(ctx context.Context, sessionID string, schema *OutputSchema) (json.RawMessage, error) {
+	providerCfg, ok := c.cfg.Providers.Get(c.currentAgent().Model().ModelCfg.Provider)
+	if !ok {
+		return nil, errors.New("model provider not configured")
+	}
+	return c.currentAgent().Output(ctx, sessionID, schema, getProviderOptions(c.currentAgent().Model(), providerCfg))
+}
+
+func (c *coordinator) SetPlanMode(ctx context.Context, sessionID string, planMode bool) error {
+	if c.currentAgent().IsSessionBusy(sessionID) {
+		return ErrSessionBusy
+	}
+	currentSession, err := c.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	if currentSession.PlanMode == planMode {
+		return nil
+	}
+	currentSession.PlanMode = planMode
+	_, err = c.sessions.Save(ctx, currentSession)
+	return err
+}
+
+func (c *coordinator) Resend(ctx context.Context, sessionID, messageID, prompt string, opts ResendOptions) (*fantasy.AgentResult, error) {
+	if c.currentAgent().IsSessionBusy(sessionID) {
+		return nil, ErrSessionBusy
+	}
+	msg, err := c.messages.Get(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if msg.SessionID != sessionID || msg.Role != message.User {
+		return nil, fmt.Errorf("message %s is not a prompt of session %s", messageID, sessionID)
+	}
+
+	// The checkpoint of the prompt has the versions to restore the files to.
+	var fileVersions history.Versions
+	if opts.RestoreFiles {
+		if c.checkpoints == nil {
+			return nil, errors.New("cannot restore files without checkpoints")
+		}
+		checkpoint, err := c.checkpoints.GetByMessage(ctx, sessionID, messageID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore files: %w", err)
+		}
+		fileVersions = checkpoint.FileVersions
+	}
+
+	attachments := messageAttachments(msg)
+
+	removed, err := c.rewind(ctx, sessionID, messageID, opts.KeepBranch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewind session: %w", err)
+	}
+
+	currentSession, err := c.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	summaryRemoved := slices.ContainsFunc(removed, func(m message.Message) bool {
+		return m.ID == currentSession.SummaryMessageID
+	})
+	if summaryRemoved {
+		currentSession.SummaryMessageID = ""
+	}
+	// Context files loaded by the removed tool results are loaded again
+	// when the agent gets back to their directories.
+	contextFilesRemoved := slices.ContainsFunc(removed, func(m message.Message) bool {
+		return slices.ContainsFunc(m.ToolResults(), func(r message.ToolResult) bool {
+			return loadedContextFiles(r.Content) != ""
+		})
+	})
+	if contextFilesRemoved {
+		currentSession.ContextFiles = nil
+	}
+	if summaryRemoved || contextFilesRemoved {
+		if _, err := c.sessions.Save(ctx, currentSession); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.RestoreFiles {
+		conflicts, err := c.history.Restore(ctx, sessionID, fileVersions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore files: %w", err)
+		}
+		for _, conflict := range conflicts {
+			slog.Warn("Not restoring file changed outside of the session", "path", conflict.Path)
+		}
+	}
+
+	return c.Run(ctx, sessionID, prompt, attachments...)
+}
+
+// rewind drops the messages of the session from messageID on, along with
+// the checkpoints of the turns they started.
+func (c *coordinator) rewind(ctx context.Context, sessionID, messageID string, keepBranch bool) ([]message.Message, error) {
+	removed, err := c.messages.Rewind(ctx, sessionID, messageID, keepBranch)
+	if err != nil {
+		return nil, err
+	}
+	if c.checkpoints == nil {
+		return removed, nil
+	}
+	var prompts []string
+	for _, msg := range removed {
+		if msg.Role == message.User {
+			prompts = append(prompts, msg.ID)
+		}
+	}
+	if err := c.checkpoints.Discard(ctx, sessionID, prompts...); err != nil {
+		return nil, fmt.Errorf("failed to discard checkpoints: %w", err)
+	}
+	return removed, nil
+}
+
+const continuePrompt = "Your previous turn was interrupted before you were done. Continue from where you left off."
+
+func (c *coordinator) Continue(ctx context.Context, sessionID string) (*fantasy.AgentResult, error) {
+	if c.currentAgent().IsSessionBusy(sessionID) {
+		return nil, ErrSessionBusy
+	}
+	msgs, err := c.messages.List(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	if !Interrupted(msgs) {
+		return nil, ErrNotInterrupted
+	}
+	return c.Run(ctx, sessionID, continuePrompt)
+}
+
+// Interrupted reports whether the last turn of the conversation was cut
+// short by crush stopping.
+func Interrupted(msgs []message.Message) bool {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		switch msgs[i].Role {
+		case message.Assistant:
+			return msgs[i].FinishReason() == message.FinishReasonInterrupted
+		case message.User:
+			return false
+		}
+	}
+	return false
+}
+
+// messageAttachments returns the files attached to a user message.
+func messageAttachments(msg message.Message) []message.Attachment {
+	var attachments []message.Attachment
+	for _, bc := range msg.BinaryContent() {
+		attachments = append(attachments, message.Attachment{
+			FilePath: bc.Path,
+			FileName: filepath.Base(bc.Path),
+			MimeType: bc.MIMEType,
+			Content:  bc.Data,
+		})
+	}
+	return attachments
 }
 
 func (c *coordinator) isUnauthorized(err error) bool {
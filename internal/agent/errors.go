@@ -3,8 +3,13 @@ package agent
 import "errors"
 
 var (
-	ErrRequestCancelled = errors.New("request canceled by user")
-	ErrSessionBusy      = errors.New("session is currently processing another request")
-	ErrEmptyPrompt      = errors.New("prompt is empty")
-	ErrSessionMissing   = errors.New("session id is missing")
+	ErrRequestCancelled     = errors.New("request canceled by user")
+	ErrSessionBusy          = errors.New("session is currently processing another request")
+	ErrEmptyPrompt          = errors.New("prompt is empty")
+	ErrSessionMissing       = errors.New("session id is missing")
+	ErrBudgetExceeded       = errors.New("budget exceeded")
+	ErrPromptDenied         = errors.New("prompt denied by a hook")
+	ErrInvalidOutput        = errors.New("output does not match the schema")
+	ErrQueuedPromptNotFound = errors.New("queued prompt not found")
+	ErrNotInterrupted       = errors.New("the last turn of the session was not interrupted")
 )
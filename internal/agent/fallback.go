@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+// FallbackEvent is published when a run switches to a fallback model
+// because the provider of the previous one is unavailable.
+type FallbackEvent struct {
+	SessionID string
+	From      config.SelectedModel
+	To        config.SelectedModel
+	Err       error
+}
+
+var fallbackBroker = pubsub.NewBroker[FallbackEvent]()
+
+// SubscribeFallbackEvents returns a channel for fallback events.
+func SubscribeFallbackEvents(ctx context.Context) <-chan pubsub.Event[FallbackEvent] {
+	return fallbackBroker.Subscribe(ctx)
+}
+
+// isProviderUnavailable reports whether the provider failed in a way another
+// model may not, that is with rate limit, overload or server errors.
+func isProviderUnavailable(err error) bool {
+	var providerErr *fantasy.ProviderError
+	if !errors.As(err, &providerErr) {
+		return false
+	}
+	return providerErr.StatusCode == http.StatusTooManyRequests || providerErr.StatusCode >= http.StatusInternalServerError
+}
+
+// toolsRan reports whether any tool ran in the given messages of a turn.
+// Tools run between the steps of a turn, so they did once a step finished
+// with tool calls.
+func toolsRan(turn []message.Message) bool {
+	for _, msg := range turn {
+		if msg.Role == message.Assistant && msg.FinishReason() == message.FinishReasonToolUse {
+			return true
+		}
+	}
+	return false
+}
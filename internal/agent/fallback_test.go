@@ -0,0 +1,172 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/symbols"
+	"github.com/stretchr/testify/require"
+)
+
+// overloadedServer stands in for a provider that is always overloaded.
+func overloadedServer(t *testing.T, requests *atomic.Int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		// Keep the SDK from retrying on its own.
+		w.Header().Set("X-Should-Retry", "false")
+		w.WriteHeader(529)
+		_, _ = w.Write([]byte(`{"error":{"message":"Overloaded","type":"overloaded_error"}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// replyServer stands in for a provider that streams back the given text.
+func replyServer(t *testing.T, text string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunk := func(delta map[string]any, finishReason any) {
+			data, _ := json.Marshal(map[string]any{
+				"id":      "chatcmpl-1",
+				"object":  "chat.completion.chunk",
+				"created": time.Now().Unix(),
+				"model":   "backup-model",
+				"choices": []map[string]any{{"index": 0, "delta": delta, "finish_reason": finishReason}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+		chunk(map[string]any{"role": "assistant", "content": text}, nil)
+		chunk(map[string]any{}, "stop")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// testProvider declares an OpenAI compatible provider serving a single
+// model at url.
+func testProvider(url, model string) map[string]any {
+	return map[string]any{
+		"type":     "openai-compat",
+		"base_url": url,
+		"api_key":  "test",
+		"models": []map[string]any{{
+			"id":                 model,
+			"name":               model,
+			"context_window":     100_000,
+			"default_max_tokens": 1_000,
+		}},
+	}
+}
+
+// testCoordinator returns a coordinator of env configured with the given
+// providers and models. The global configuration must be isolated by the
+// test.
+func testCoordinator(t *testing.T, env fakeEnv, providers, models map[string]any) Coordinator {
+	data, err := json.Marshal(map[string]any{
+		"options": map[string]any{
+			"disable_provider_auto_update": true,
+			"data_directory":               t.TempDir(),
+		},
+		"providers": providers,
+		"models":    models,
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(env.workingDir, "crush.json"), data, 0o644))
+
+	cfg, err := config.Init(env.workingDir, "", false)
+	require.NoError(t, err)
+
+	coordinator, err := NewCoordinator(t.Context(), cfg, env.sessions, env.messages, env.permissions, env.history, env.checkpoints, env.lspClients, symbols.NewIndex(env.lspClients, cfg.WorkingDir(), cfg.LSP))
+	require.NoError(t, err)
+	return coordinator
+}
+
+func TestCoordinatorFallback(t *testing.T) {
+	var primaryRequests atomic.Int32
+	primary := overloadedServer(t, &primaryRequests)
+	backup := replyServer(t, "Hello from the fallback")
+
+	t.Setenv("CRUSH_GLOBAL_CONFIG", t.TempDir())
+	t.Setenv("CRUSH_GLOBAL_DATA", t.TempDir())
+
+	env := testEnv(t)
+	coordinator := testCoordinator(t, env, map[string]any{
+		"primary": testProvider(primary.URL, "primary-model"),
+		"backup":  testProvider(backup.URL, "backup-model"),
+	}, map[string]any{
+		"large": map[string]any{
+			"provider": "primary",
+			"model":    "primary-model",
+			"fallbacks": []map[string]any{
+				{"provider": "backup", "model": "backup-model"},
+			},
+		},
+		"small": map[string]any{"provider": "backup", "model": "backup-model"},
+	})
+
+	events := SubscribeFallbackEvents(t.Context())
+
+	sess, err := env.sessions.Create(t.Context(), "Fallback")
+	require.NoError(t, err)
+	_, err = coordinator.Run(t.Context(), sess.ID, "Hello")
+	require.NoError(t, err)
+	require.Positive(t, primaryRequests.Load())
+
+	select {
+	case event := <-events:
+		require.Equal(t, sess.ID, event.Payload.SessionID)
+		require.Equal(t, "primary-model", event.Payload.From.Model)
+		require.Equal(t, "backup-model", event.Payload.To.Model)
+	case <-time.After(time.Second):
+		t.Fatal("no fallback event")
+	}
+
+	msgs, err := env.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Equal(t, message.User, msgs[0].Role)
+	require.Equal(t, "Hello", msgs[0].Content().Text)
+	require.Equal(t, message.Assistant, msgs[1].Role)
+	require.Equal(t, "Hello from the fallback", msgs[1].Content().Text)
+	require.Equal(t, "backup", msgs[1].Provider)
+	require.Equal(t, "backup-model", msgs[1].Model)
+
+	// Only the prompt run on the fallback has a checkpoint.
+	checkpoints, err := env.checkpoints.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 1)
+	require.Equal(t, msgs[0].ID, checkpoints[0].MessageID)
+
+	// The next prompt tries the configured model first again.
+	require.Equal(t, "primary-model", coordinator.Model().ModelCfg.Model)
+}
+
+func TestToolsRan(t *testing.T) {
+	t.Parallel()
+
+	assistant := func(reason message.FinishReason) message.Message {
+		msg := message.Message{Role: message.Assistant}
+		msg.AddFinish(reason, "", "")
+		return msg
+	}
+
+	// The provider failed while streaming the first step.
+	require.False(t, toolsRan([]message.Message{assistant(message.FinishReasonError)}))
+	// A step finished with tool calls, which ran before the next one failed.
+	require.True(t, toolsRan([]message.Message{
+		assistant(message.FinishReasonToolUse),
+		{Role: message.Tool},
+		assistant(message.FinishReasonError),
+	}))
+}
@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/hooks"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+// hookedTool runs the tool hooks around the calls of a tool.
+type hookedTool struct {
+	fantasy.AgentTool
+	hooks       *hooks.Runner
+	permissions permission.Service
+}
+
+func withHooks(agentTools []fantasy.AgentTool, runner *hooks.Runner, permissions permission.Service) []fantasy.AgentTool {
+	hooked := make([]fantasy.AgentTool, len(agentTools))
+	for i, tool := range agentTools {
+		hooked[i] = &hookedTool{
+			AgentTool:   tool,
+			hooks:       runner,
+			permissions: permissions,
+		}
+	}
+	return hooked
+}
+
+// unwrapTool returns the tool wrapped by the hooks, if any.
+func unwrapTool(tool fantasy.AgentTool) fantasy.AgentTool {
+	if hooked, ok := tool.(*hookedTool); ok {
+		return hooked.AgentTool
+	}
+	return tool
+}
+
+func (t *hookedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	input := hooks.Input{
+		SessionID:  tools.GetSessionFromContext(ctx),
+		ToolName:   t.Info().Name,
+		ToolCallID: call.ID,
+		ToolInput:  json.RawMessage(call.Input),
+	}
+
+	if t.hooks.Has(hooks.EventPreToolUse) {
+		input.Event = hooks.EventPreToolUse
+		output := t.hooks.Run(ctx, input)
+		switch output.Decision {
+		case hooks.DecisionDeny:
+			reason := "The tool call was denied by a hook."
+			if output.Reason != "" {
+				reason = fmt.Sprintf("The tool call was denied by a hook: %s", output.Reason)
+			}
+			return fantasy.NewTextErrorResponse(reason), nil
+		case hooks.DecisionApprove:
+			t.permissions.ApproveToolCall(call.ID)
+			defer t.permissions.ForgetToolCall(call.ID)
+		}
+		if len(output.ToolInput) > 0 {
+			call.Input = string(output.ToolInput)
+			input.ToolInput = output.ToolInput
+		}
+	}
+
+	response, err := t.AgentTool.Run(ctx, call)
+	if err != nil || !t.hooks.Has(hooks.EventPostToolUse) {
+		return response, err
+	}
+
+	input.Event = hooks.EventPostToolUse
+	input.ToolResult = &hooks.ToolResult{
+		Content: response.Content,
+		IsError: response.IsError,
+	}
+	if output := t.hooks.Run(ctx, input); output.Feedback != "" {
+		response.Content += "\n\n" + output.Feedback
+	}
+	return response, nil
+}
+
+// runPromptHooks runs the hooks for a submitted prompt, and the session start
+// hooks if it is the first prompt of the session. It returns an error if a
+// hook denied the prompt.
+func (c *coordinator) runPromptHooks(ctx context.Context, sessionID, prompt string) error {
+	if c.hooks.Has(hooks.EventSessionStart) {
+		currentSession, err := c.sessions.Get(ctx, sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to get session: %w", err)
+		}
+		if currentSession.MessageCount == 0 {
+			c.hooks.Run(ctx, hooks.Input{
+				Event:     hooks.EventSessionStart,
+				SessionID: sessionID,
+				Prompt:    prompt,
+			})
+		}
+	}
+
+	if !c.hooks.Has(hooks.EventUserPromptSubmit) {
+		return nil
+	}
+	output := c.hooks.Run(ctx, hooks.Input{
+		Event:     hooks.EventUserPromptSubmit,
+		SessionID: sessionID,
+		Prompt:    prompt,
+	})
+	if output.Decision != hooks.DecisionDeny {
+		return nil
+	}
+	if output.Reason == "" {
+		return ErrPromptDenied
+	}
+	return fmt.Errorf("%w: %s", ErrPromptDenied, output.Reason)
+}
+
+// runStopHooks runs the hooks for when the agent finished responding.
+func (c *coordinator) runStopHooks(ctx context.Context, sessionID string, result *fantasy.AgentResult, err error) {
+	if !c.hooks.Has(hooks.EventStop) {
+		return
+	}
+	if result == nil && err == nil {
+		// Queued prompts return right away, the run that handles them runs
+		// the hooks.
+		return
+	}
+	input := hooks.Input{
+		Event:     hooks.EventStop,
+		SessionID: sessionID,
+	}
+	if result != nil {
+		input.Response = result.Response.Content.Text()
+	}
+	if err != nil {
+		input.Error = err.Error()
+	}
+	// The run may have been canceled, the hooks should still run.
+	c.hooks.Run(context.WithoutCancel(ctx), input)
+}
@@ -0,0 +1,176 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/kaptinlin/jsonschema"
+)
+
+// maxOutputAttempts is how many times the model is asked for a document that
+// matches the schema, after the native structured output failed.
+const maxOutputAttempts = 3
+
+const outputPrompt = `Give your final answer to the task above as a single JSON document that matches the following JSON schema. Reply with the JSON document only, without any other text or code fences.
+
+%s`
+
+const outputRetryPrompt = `The document does not match the schema: %s
+
+Reply with a corrected JSON document only.`
+
+// OutputSchema is a compiled JSON schema for the output of a run.
+type OutputSchema struct {
+	raw       json.RawMessage
+	validator *jsonschema.Schema
+}
+
+// NewOutputSchema compiles the given JSON schema.
+func NewOutputSchema(raw []byte) (*OutputSchema, error) {
+	validator, err := jsonschema.NewCompiler().Compile(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	return &OutputSchema{
+		raw:       raw,
+		validator: validator,
+	}, nil
+}
+
+// Validate returns an error describing why the document does not match the
+// schema, if it does not.
+func (s *OutputSchema) Validate(doc []byte) error {
+	if !json.Valid(doc) {
+		return errors.New("not a valid JSON document")
+	}
+	result := s.validator.ValidateJSON(doc)
+	if result.IsValid() {
+		return nil
+	}
+	var problems []string
+	for path, problem := range result.GetDetailedErrors() {
+		problems = append(problems, fmt.Sprintf("%s: %s", cmp.Or(path, "/"), problem))
+	}
+	slices.Sort(problems)
+	return errors.New(strings.Join(problems, "; "))
+}
+
+// Output asks the model for the final answer of the session as a JSON
+// document matching the schema. It uses the structured output of the
+// provider first, and falls back to asking for the document as text, telling
+// the model what is wrong with it, for a few attempts.
+func (a *sessionAgent) Output(ctx context.Context, sessionID string, schema *OutputSchema, opts fantasy.ProviderOptions) (json.RawMessage, error) {
+	if a.IsSessionBusy(sessionID) {
+		return nil, ErrSessionBusy
+	}
+
+	currentSession, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	msgs, err := a.getSessionMessages(ctx, currentSession)
+	if err != nil {
+		return nil, err
+	}
+	history, _ := a.preparePrompt(msgs)
+
+	var prompt fantasy.Prompt
+	if a.systemPromptPrefix != "" {
+		prompt = append(prompt, fantasy.NewSystemMessage(a.systemPromptPrefix))
+	}
+	prompt = append(prompt, fantasy.NewSystemMessage(a.systemPrompt))
+	prompt = append(prompt, history...)
+	prompt = append(prompt, fantasy.NewUserMessage(fmt.Sprintf(outputPrompt, schema.raw)))
+
+	model := a.largeModel
+	var usage fantasy.Usage
+	defer func() {
+		a.updateSessionUsage(model, &currentSession, usage, nil)
+		if _, err := a.sessions.Save(context.WithoutCancel(ctx), currentSession); err != nil {
+			slog.Error("Failed to save session usage", "error", err)
+		}
+	}()
+
+	var objectSchema fantasy.Schema
+	if err := json.Unmarshal(schema.raw, &objectSchema); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	resp, err := model.Model.GenerateObject(ctx, fantasy.ObjectCall{
+		Prompt:          prompt,
+		Schema:          objectSchema,
+		SchemaName:      "output",
+		ProviderOptions: opts,
+	})
+	if err == nil {
+		usage = addUsage(usage, resp.Usage)
+		var doc json.RawMessage
+		if doc, err = json.Marshal(resp.Object); err == nil {
+			if err = schema.Validate(doc); err == nil {
+				return doc, nil
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	slog.Warn("Structured output failed, asking for the document as text", "error", err)
+
+	for range maxOutputAttempts {
+		var resp *fantasy.Response
+		resp, err = model.Model.Generate(ctx, fantasy.Call{
+			Prompt:          prompt,
+			ProviderOptions: opts,
+		})
+		if err != nil {
+			return nil, err
+		}
+		usage = addUsage(usage, resp.Usage)
+
+		text := resp.Content.Text()
+		doc := json.RawMessage(extractJSON(text))
+		if err = schema.Validate(doc); err == nil {
+			return doc, nil
+		}
+		slog.Warn("Output does not match the schema", "error", err)
+		prompt = append(prompt,
+			fantasy.Message{
+				Role:    fantasy.MessageRoleAssistant,
+				Content: []fantasy.MessagePart{fantasy.TextPart{Text: text}},
+			},
+			fantasy.NewUserMessage(fmt.Sprintf(outputRetryPrompt, err)),
+		)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrInvalidOutput, err)
+}
+
+// extractJSON returns the JSON document of a response, dropping the code
+// fences models tend to wrap it in.
+func extractJSON(text string) string {
+	text = strings.TrimSpace(text)
+	if rest, ok := strings.CutPrefix(text, "```"); ok {
+		// Drop the language of the fence, if any.
+		if i := strings.IndexByte(rest, '\n'); i >= 0 {
+			rest = rest[i+1:]
+		}
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "```"))
+	}
+	return text
+}
+
+func addUsage(a, b fantasy.Usage) fantasy.Usage {
+	return fantasy.Usage{
+		InputTokens:         a.InputTokens + b.InputTokens,
+		OutputTokens:        a.OutputTokens + b.OutputTokens,
+		TotalTokens:         a.TotalTokens + b.TotalTokens,
+		ReasoningTokens:     a.ReasoningTokens + b.ReasoningTokens,
+		CacheCreationTokens: a.CacheCreationTokens + b.CacheCreationTokens,
+		CacheReadTokens:     a.CacheReadTokens + b.CacheReadTokens,
+	}
+}
@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputSchema(t *testing.T) {
+	t.Parallel()
+
+	schema, err := NewOutputSchema([]byte(`{
+		"type": "object",
+		"properties": {
+			"todos": {"type": "array", "items": {"type": "string"}},
+			"count": {"type": "integer", "minimum": 0}
+		},
+		"required": ["todos", "count"],
+		"additionalProperties": false
+	}`))
+	require.NoError(t, err)
+
+	require.NoError(t, schema.Validate([]byte(`{"todos": ["a", "b"], "count": 2}`)))
+	require.ErrorContains(t, schema.Validate([]byte(`{"todos": ["a"]}`)), "count")
+	require.Error(t, schema.Validate([]byte(`{"todos": [], "count": -1}`)))
+	require.Error(t, schema.Validate([]byte(`{"todos": [], "count": 0, "extra": true}`)))
+	require.ErrorContains(t, schema.Validate([]byte(`Here you go: {}`)), "not a valid JSON document")
+
+	_, err = NewOutputSchema([]byte(`{"type": `))
+	require.Error(t, err)
+}
+
+func TestExtractJSON(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, `{"a": 1}`, extractJSON(`  {"a": 1}`+"\n"))
+	require.Equal(t, `{"a": 1}`, extractJSON("```json\n{\"a\": 1}\n```"))
+	require.Equal(t, `[1, 2]`, extractJSON("```\n[1, 2]\n```\n"))
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+//go:embed templates/plan_mode.md
+var planModePrompt string
+
+// planModeToolNames are the built-in tools available while a session is in
+// plan mode. None of them modify the working directory.
+var planModeToolNames = []string{
+	tools.DefinitionToolName,
+	tools.DiagnosticsToolName,
+	tools.FetchToolName,
+	tools.GlobToolName,
+	tools.GrepToolName,
+	tools.HoverToolName,
+	tools.LSToolName,
+	tools.PlanToolName,
+	tools.ReferencesToolName,
+	tools.SourcegraphToolName,
+	tools.SymbolsToolName,
+	tools.ViewToolName,
+}
+
+// sessionTools returns the tools the agent can use in the given session. In
+// plan mode only the read-only tools are kept, otherwise everything but the
+// plan tool.
+func sessionTools(agentTools []fantasy.AgentTool, s session.Session) []fantasy.AgentTool {
+	return slices.DeleteFunc(slices.Clone(agentTools), func(tool fantasy.AgentTool) bool {
+		if !s.PlanMode {
+			return tool.Info().Name == tools.PlanToolName
+		}
+		if mcpTool, ok := unwrapTool(tool).(*tools.Tool); ok {
+			return !mcpTool.ReadOnly()
+		}
+		return !slices.Contains(planModeToolNames, tool.Info().Name)
+	})
+}
+
+// planHandedOff returns whether the session just left plan mode with an
+// approved plan, which the agent is told to carry out with the next prompt.
+func planHandedOff(s session.Session) bool {
+	return !s.PlanMode && s.Plan != ""
+}
+
+// clearPlan forgets the approved plan of the session once the agent got it,
+// so that it is not reminded of it again after the work is done. The agent
+// keeps track of the plan through its todos from then on.
+func (a *sessionAgent) clearPlan(ctx context.Context, sessionID string) {
+	currentSession, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		slog.Error("Failed to get session", "error", err)
+		return
+	}
+	currentSession.Plan = ""
+	if _, err := a.sessions.Save(ctx, currentSession); err != nil {
+		slog.Error("Failed to clear the plan", "error", err)
+	}
+}
+
+// planReminder returns the reminder that tells the agent about the plan of
+// the session, or an empty string if there is nothing to say.
+func planReminder(s session.Session) string {
+	switch {
+	case s.PlanMode && s.Plan != "":
+		return fmt.Sprintf("<system_reminder>%s\nThis is the current plan, update it if the user asks for changes:\n\n%s</system_reminder>", planModePrompt, s.Plan)
+	case s.PlanMode:
+		return fmt.Sprintf("<system_reminder>%s</system_reminder>", planModePrompt)
+	case planHandedOff(s):
+		return fmt.Sprintf(
+			"<system_reminder>The user reviewed and approved the following plan. Carry it out, using the todos tool to track progress on its steps. DO NOT mention this message to the user.\n\n%s</system_reminder>",
+			s.Plan,
+		)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanHandOff(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	s, err := env.sessions.Create(t.Context(), "Plan")
+	require.NoError(t, err)
+	agent := testSessionAgent(env, nil, nil, "").(*sessionAgent)
+
+	s.PlanMode = true
+	s.Plan = "## Summary\n\nDo it."
+	require.False(t, planHandedOff(s))
+	require.Contains(t, planReminder(s), "This is the current plan")
+
+	s.PlanMode = false
+	s, err = env.sessions.Save(t.Context(), s)
+	require.NoError(t, err)
+	require.True(t, planHandedOff(s))
+	require.Contains(t, planReminder(s), "approved the following plan")
+
+	// Once the agent got the plan it is not reminded of it again.
+	agent.clearPlan(t.Context(), s.ID)
+	s, err = env.sessions.Get(t.Context(), s.ID)
+	require.NoError(t, err)
+	require.False(t, planHandedOff(s))
+	require.Empty(t, planReminder(s))
+}
@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/memory"
 	"github.com/charmbracelet/crush/internal/shell"
 	"github.com/charmbracelet/crush/internal/skills"
 )
@@ -24,6 +26,8 @@ type Prompt struct {
 	now        func() time.Time
 	platform   string
 	workingDir string
+	// contextPaths overrides the context paths from the config when set.
+	contextPaths []string
 }
 
 type PromptDat struct {
@@ -36,6 +40,7 @@ type PromptDat struct {
 	Date          string
 	GitStatus     string
 	ContextFiles  []ContextFile
+	Memories      []memory.Memory
 	AvailSkillXML string
 }
 
@@ -64,6 +69,14 @@ func WithWorkingDir(workingDir string) Option {
 	}
 }
 
+// WithContextPaths sets the context paths used instead of the ones from
+// the config.
+func WithContextPaths(paths ...string) Option {
+	return func(p *Prompt) {
+		p.contextPaths = paths
+	}
+}
+
 func NewPrompt(name, promptTemplate string, opts ...Option) (*Prompt, error) {
 	p := &Prompt{
 		name:     name,
@@ -81,6 +94,18 @@ func (p *Prompt) Build(ctx context.Context, provider, model string, cfg config.C
 	if err != nil {
 		return "", fmt.Errorf("parsing template: %w", err)
 	}
+	// Overrides are parsed into the same template: a file with a body
+	// replaces the whole template, one that only defines blocks replaces
+	// those blocks.
+	for _, path := range cfg.PromptTemplates(p.name, provider, model) {
+		override, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("reading template override: %w", err)
+		}
+		if _, err := t.Parse(string(override)); err != nil {
+			return "", fmt.Errorf("parsing template override %s: %w", path, err)
+		}
+	}
 	var sb strings.Builder
 	d, err := p.promptData(ctx, provider, model, cfg)
 	if err != nil {
@@ -154,7 +179,11 @@ func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg con
 
 	files := map[string][]ContextFile{}
 
-	for _, pth := range cfg.Options.ContextPaths {
+	contextPaths := cfg.Options.ContextPaths
+	if p.contextPaths != nil {
+		contextPaths = p.contextPaths
+	}
+	for _, pth := range contextPaths {
 		expanded := expandPath(pth, cfg)
 		pathKey := strings.ToLower(expanded)
 		if _, ok := files[pathKey]; ok {
@@ -198,9 +227,25 @@ func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg con
 	for _, contextFiles := range files {
 		data.ContextFiles = append(data.ContextFiles, contextFiles...)
 	}
+	data.Memories = loadMemories(cfg)
 	return data, nil
 }
 
+// loadMemories returns the saved memories of the project that fit in the
+// memory token budget.
+func loadMemories(cfg config.Config) []memory.Memory {
+	maxTokens := cfg.Options.MemoryTokens
+	if cfg.Options.DataDirectory == "" || maxTokens == nil || *maxTokens <= 0 {
+		return nil
+	}
+	memories, err := memory.NewStore(cfg.Options.DataDirectory).List()
+	if err != nil {
+		slog.Warn("Failed to load memories", "error", err)
+		return nil
+	}
+	return memory.WithinBudget(memories, *maxTokens)
+}
+
 func isGitRepo(dir string) bool {
 	_, err := os.Stat(filepath.Join(dir, ".git"))
 	return err == nil
@@ -0,0 +1,50 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildOverrides(t *testing.T) {
+	globalDir := t.TempDir()
+	t.Setenv("CRUSH_GLOBAL_CONFIG", globalDir)
+
+	dataDir := t.TempDir()
+	write := func(path, content string) string {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+	write(filepath.Join(globalDir, "prompts", "coder.md.tpl"), `{{define "env"}}global env{{end}}`)
+	write(filepath.Join(dataDir, "prompts", "coder.md.tpl"), `{{define "rules"}}project rules for {{.Model}}{{end}}`)
+	gpt := write(filepath.Join(dataDir, "gpt.md.tpl"), `{{define "env"}}gpt env{{end}}`)
+	full := write(filepath.Join(dataDir, "full.md.tpl"), `full prompt for {{.Provider}}`)
+
+	cfg := config.Config{
+		Options: &config.Options{DataDirectory: dataDir},
+		Prompts: []config.PromptTemplate{
+			{Name: "coder", Path: gpt, Model: "gpt-*"},
+			{Name: "coder", Path: full, Provider: "openrouter", Model: "openai/*"},
+			{Name: "task", Path: full},
+		},
+	}
+	p, err := NewPrompt("coder", `{{block "rules" .}}rules{{end}} / {{block "env" .}}env{{end}}`)
+	require.NoError(t, err)
+
+	build := func(provider, model string) string {
+		result, err := p.Build(t.Context(), provider, model, cfg)
+		require.NoError(t, err)
+		return result
+	}
+	require.Equal(t, "project rules for claude-sonnet-4-5 / global env", build("anthropic", "claude-sonnet-4-5"))
+	require.Equal(t, "project rules for gpt-5 / gpt env", build("openai", "gpt-5"))
+	require.Equal(t, "full prompt for openrouter", build("openrouter", "openai/gpt-5"))
+
+	write(gpt, `{{define "env"}}{{end`)
+	_, err = p.Build(t.Context(), "openai", "gpt-5", cfg)
+	require.ErrorContains(t, err, gpt)
+}
@@ -17,6 +17,12 @@ var taskPromptTmpl []byte
 //go:embed templates/initialize.md.tpl
 var initializePromptTmpl []byte
 
+//go:embed templates/summary.md
+var summaryPromptTmpl []byte
+
+//go:embed templates/title.md
+var titlePromptTmpl []byte
+
 func coderPrompt(opts ...prompt.Option) (*prompt.Prompt, error) {
 	systemPrompt, err := prompt.NewPrompt("coder", string(coderPromptTmpl), opts...)
 	if err != nil {
@@ -33,6 +39,14 @@ func taskPrompt(opts ...prompt.Option) (*prompt.Prompt, error) {
 	return systemPrompt, nil
 }
 
+func summaryPrompt(opts ...prompt.Option) (*prompt.Prompt, error) {
+	return prompt.NewPrompt("summary", string(summaryPromptTmpl), opts...)
+}
+
+func titlePrompt(opts ...prompt.Option) (*prompt.Prompt, error) {
+	return prompt.NewPrompt("title", string(titlePromptTmpl), opts...)
+}
+
 func InitializePrompt(cfg config.Config) (string, error) {
 	systemPrompt, err := prompt.NewPrompt("initialize", string(initializePromptTmpl))
 	if err != nil {
@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// prunedToolResult replaces the output of pruned tool results. The result
+// itself is kept so that every tool call still has a matching result, which
+// all providers require.
+const prunedToolResult = "[Output elided to save context, re-run the tool to see it]"
+
+// pruneToolResults replaces the output of the tool results older than the
+// configured number of turns, or larger than the configured number of
+// tokens, with a short stub. The messages are only changed in the returned
+// slice, the stored ones keep the full output.
+//
+// A result that is pruned once stays pruned, so that the start of the
+// conversation sent to the model stays the same from one turn to the next
+// and providers can keep it cached.
+func pruneToolResults(msgs []message.Message, pruning config.Pruning) []message.Message {
+	if !pruning.Enabled() {
+		return msgs
+	}
+
+	turns := 0
+	for _, msg := range msgs {
+		if msg.Role == message.User {
+			turns++
+		}
+	}
+	boundary := pruneBoundary(turns, pruning.KeepTurns)
+
+	pruned := make([]message.Message, len(msgs))
+	turn := -1
+	for i, msg := range msgs {
+		switch msg.Role {
+		case message.User:
+			turn++
+		case message.Tool:
+			old := pruning.KeepTurns > 0 && turn < boundary
+			msg = pruneMessage(msg, old, pruning.MaxResultTokens)
+		}
+		pruned[i] = msg
+	}
+	return pruned
+}
+
+// pruneBoundary returns the index of the first of the given number of turns
+// whose tool results are kept, as long as they are not too large. The prompt
+// being sent starts a new turn, which counts as one of the turns to keep.
+// The boundary moves keepTurns turns at a time, rather than every turn, so
+// that between two moves the pruned conversation only grows at the end.
+func pruneBoundary(turns, keepTurns int) int {
+	if keepTurns <= 0 {
+		return 0
+	}
+	boundary := max(turns+1-keepTurns, 0)
+	return boundary - boundary%keepTurns
+}
+
+func pruneMessage(msg message.Message, old bool, maxTokens int) message.Message {
+	var clone *message.Message
+	for i, part := range msg.Parts {
+		result, ok := part.(message.ToolResult)
+		if !ok || !shouldPrune(result, old, maxTokens) {
+			continue
+		}
+		if clone == nil {
+			c := msg.Clone()
+			clone = &c
+		}
+		// The context files are only loaded once in a session, they stay
+		// for the agent to keep following them.
+		reminder := loadedContextFiles(result.Content)
+		result.Content = prunedToolResult
+		if reminder != "" {
+			result.Content += "\n\n" + reminder
+		}
+		result.Data = ""
+		result.MIMEType = ""
+		clone.Parts[i] = result
+	}
+	if clone == nil {
+		return msg
+	}
+	return *clone
+}
+
+func shouldPrune(result message.ToolResult, old bool, maxTokens int) bool {
+	size := len(result.Content) + len(result.Data)
+	if size <= len(prunedToolResult) {
+		return false
+	}
+	if old {
+		return true
+	}
+	return maxTokens > 0 && estimateTokens(size) > maxTokens
+}
+
+// estimateTokens gives a rough token count for the given number of bytes,
+// good enough to spot large outputs without a tokenizer.
+func estimateTokens(bytes int) int {
+	return bytes / 4
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneToolResults(t *testing.T) {
+	t.Parallel()
+
+	first := strings.Repeat("first output\n", 10)
+	reminder := contextFilesReminder + "<file path=\"internal/AGENTS.md\">\nKeep it simple.\n</file>\n</system_reminder>"
+	second := strings.Repeat("second output\n", 10) + "\n\n" + reminder
+	// The context files loaded by a pruned result are kept.
+	prunedSecond := prunedToolResult + "\n\n" + reminder
+	large := strings.Repeat("third output\n", 1000)
+	turn := func(id, output string) []message.Message {
+		return []message.Message{
+			{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "prompt " + id}}},
+			{Role: message.Assistant, Parts: []message.ContentPart{message.ToolCall{ID: id, Name: "view", Finished: true}}},
+			{Role: message.Tool, Parts: []message.ContentPart{message.ToolResult{ToolCallID: id, Name: "view", Content: output}}},
+		}
+	}
+	var msgs []message.Message
+	msgs = append(msgs, turn("first", first)...)
+	msgs = append(msgs, turn("second", second)...)
+	msgs = append(msgs, turn("third", large)...)
+	msgs = append(msgs, turn("fourth", "ok")...)
+
+	outputs := func(msgs []message.Message) []string {
+		var outputs []string
+		for _, msg := range msgs {
+			for _, result := range msg.ToolResults() {
+				require.NotEmpty(t, result.ToolCallID)
+				outputs = append(outputs, result.Content)
+			}
+		}
+		return outputs
+	}
+
+	tests := []struct {
+		name     string
+		pruning  config.Pruning
+		expected []string
+	}{
+		{
+			name:     "disabled",
+			expected: []string{first, second, large, "ok"},
+		},
+		{
+			name:     "older turns",
+			pruning:  config.Pruning{KeepTurns: 2},
+			expected: []string{prunedToolResult, prunedSecond, large, "ok"},
+		},
+		{
+			name:     "only the current turn",
+			pruning:  config.Pruning{KeepTurns: 1},
+			expected: []string{prunedToolResult, prunedSecond, prunedToolResult, "ok"},
+		},
+		{
+			name:     "large results",
+			pruning:  config.Pruning{MaxResultTokens: 1000},
+			expected: []string{first, second, prunedToolResult, "ok"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.expected, outputs(pruneToolResults(msgs, tt.pruning)))
+			// The stored messages keep the full output.
+			require.Equal(t, large, msgs[8].ToolResults()[0].Content)
+		})
+	}
+}
+
+func TestPruneBoundary(t *testing.T) {
+	t.Parallel()
+
+	// With 3 turns to keep, the boundary only moves every 3 turns, so that
+	// at least 3 and at most 5 turns are kept along with the new prompt.
+	var boundaries []int
+	for turns := range 9 {
+		boundaries = append(boundaries, pruneBoundary(turns, 3))
+	}
+	require.Equal(t, []int{0, 0, 0, 0, 0, 3, 3, 3, 6}, boundaries)
+	require.Equal(t, 4, pruneBoundary(4, 1))
+	require.Equal(t, 0, pruneBoundary(4, 0))
+}
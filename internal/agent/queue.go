@@ -0,0 +1,181 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/google/uuid"
+)
+
+// queuedPrompts returns the prompts queued in the session, loading them from
+// the database the first time, so the queue survives restarts.
+func (a *sessionAgent) queuedPrompts(sessionID string) []session.QueuedPrompt {
+	if queued, ok := a.messageQueue.Get(sessionID); ok {
+		return queued
+	}
+	queued, err := a.sessions.QueuedPrompts(context.Background(), sessionID)
+	if err != nil {
+		slog.Error("Failed to load queued prompts", "session_id", sessionID, "error", err)
+	}
+	a.messageQueue.Set(sessionID, queued)
+	return queued
+}
+
+// updateQueue replaces the prompts queued in the session with the result of
+// update and saves them. Updates of the same session are serialized, the
+// ones of other sessions are not held up.
+func (a *sessionAgent) updateQueue(ctx context.Context, sessionID string, update func([]session.QueuedPrompt) ([]session.QueuedPrompt, error)) error {
+	lock := a.queueLocks.GetOrSet(sessionID, func() *sync.Mutex {
+		return &sync.Mutex{}
+	})
+	lock.Lock()
+	defer lock.Unlock()
+
+	queued, err := update(slices.Clone(a.queuedPrompts(sessionID)))
+	if err != nil {
+		return err
+	}
+	a.messageQueue.Set(sessionID, queued)
+	// The queue already changed in memory, keep the database in sync even if
+	// the run was canceled.
+	if err := a.sessions.SaveQueuedPrompts(context.WithoutCancel(ctx), sessionID, queued); err != nil {
+		return fmt.Errorf("failed to save queued prompts: %w", err)
+	}
+	return nil
+}
+
+// updateQueuedPrompt applies update to the queued prompt with the given ID.
+func (a *sessionAgent) updateQueuedPrompt(ctx context.Context, sessionID, id string, update func([]session.QueuedPrompt, int) []session.QueuedPrompt) error {
+	return a.updateQueue(ctx, sessionID, func(queued []session.QueuedPrompt) ([]session.QueuedPrompt, error) {
+		i := slices.IndexFunc(queued, func(p session.QueuedPrompt) bool {
+			return p.ID == id
+		})
+		if i == -1 {
+			return nil, ErrQueuedPromptNotFound
+		}
+		return update(queued, i), nil
+	})
+}
+
+func (a *sessionAgent) enqueue(ctx context.Context, call SessionAgentCall) {
+	err := a.updateQueue(ctx, call.SessionID, func(queued []session.QueuedPrompt) ([]session.QueuedPrompt, error) {
+		return append(queued, session.QueuedPrompt{
+			ID:          uuid.New().String(),
+			Prompt:      call.Prompt,
+			Attachments: call.Attachments,
+			CreatedAt:   time.Now().Unix(),
+		}), nil
+	})
+	if err != nil {
+		slog.Error("Failed to queue prompt", "session_id", call.SessionID, "error", err)
+	}
+}
+
+// dequeue takes the first queued prompt of the session, and returns it as a
+// call with the same options as the given one.
+func (a *sessionAgent) dequeue(ctx context.Context, call SessionAgentCall) (SessionAgentCall, bool) {
+	var next *session.QueuedPrompt
+	err := a.updateQueue(ctx, call.SessionID, func(queued []session.QueuedPrompt) ([]session.QueuedPrompt, error) {
+		if len(queued) == 0 {
+			return queued, nil
+		}
+		next = &queued[0]
+		return queued[1:], nil
+	})
+	if err != nil {
+		slog.Error("Failed to dequeue prompt", "session_id", call.SessionID, "error", err)
+	}
+	if next == nil {
+		return call, false
+	}
+	call.Prompt = next.Prompt
+	call.Attachments = next.Attachments
+	return call, true
+}
+
+// takeSteeringPrompts takes the queued prompts of the session that should be
+// sent to the agent at its next step.
+func (a *sessionAgent) takeSteeringPrompts(ctx context.Context, sessionID string) []session.QueuedPrompt {
+	var steering []session.QueuedPrompt
+	err := a.updateQueue(ctx, sessionID, func(queued []session.QueuedPrompt) ([]session.QueuedPrompt, error) {
+		return slices.DeleteFunc(queued, func(p session.QueuedPrompt) bool {
+			if p.Steer {
+				steering = append(steering, p)
+			}
+			return p.Steer
+		}), nil
+	})
+	if err != nil {
+		slog.Error("Failed to take steering prompts", "session_id", sessionID, "error", err)
+	}
+	return steering
+}
+
+func (a *sessionAgent) QueuedPrompts(sessionID string) int {
+	return len(a.queuedPrompts(sessionID))
+}
+
+func (a *sessionAgent) QueuedPromptsList(sessionID string) []session.QueuedPrompt {
+	return slices.Clone(a.queuedPrompts(sessionID))
+}
+
+func (a *sessionAgent) ClearQueue(sessionID string) {
+	if a.QueuedPrompts(sessionID) == 0 {
+		return
+	}
+	slog.Info("Clearing queued prompts", "session_id", sessionID)
+	err := a.updateQueue(context.Background(), sessionID, func([]session.QueuedPrompt) ([]session.QueuedPrompt, error) {
+		return nil, nil
+	})
+	if err != nil {
+		slog.Error("Failed to clear queued prompts", "session_id", sessionID, "error", err)
+	}
+}
+
+func (a *sessionAgent) EditQueuedPrompt(ctx context.Context, sessionID, id, prompt string) error {
+	if prompt == "" {
+		return ErrEmptyPrompt
+	}
+	return a.updateQueuedPrompt(ctx, sessionID, id, func(queued []session.QueuedPrompt, i int) []session.QueuedPrompt {
+		queued[i].Prompt = prompt
+		return queued
+	})
+}
+
+func (a *sessionAgent) MoveQueuedPrompt(ctx context.Context, sessionID, id string, offset int) error {
+	return a.updateQueuedPrompt(ctx, sessionID, id, func(queued []session.QueuedPrompt, i int) []session.QueuedPrompt {
+		to := max(0, min(len(queued)-1, i+offset))
+		prompt := queued[i]
+		queued = slices.Delete(queued, i, i+1)
+		return slices.Insert(queued, to, prompt)
+	})
+}
+
+func (a *sessionAgent) RemoveQueuedPrompt(ctx context.Context, sessionID, id string) error {
+	return a.updateQueuedPrompt(ctx, sessionID, id, func(queued []session.QueuedPrompt, i int) []session.QueuedPrompt {
+		return slices.Delete(queued, i, i+1)
+	})
+}
+
+// SteerQueuedPrompt marks the queued prompt to be sent before the next step
+// of the running agent. It also moves it after the other steering prompts,
+// so it runs next if the agent finishes before taking another step.
+func (a *sessionAgent) SteerQueuedPrompt(ctx context.Context, sessionID, id string) error {
+	return a.updateQueuedPrompt(ctx, sessionID, id, func(queued []session.QueuedPrompt, i int) []session.QueuedPrompt {
+		prompt := queued[i]
+		prompt.Steer = true
+		queued = slices.Delete(queued, i, i+1)
+		to := slices.IndexFunc(queued, func(p session.QueuedPrompt) bool {
+			return !p.Steer
+		})
+		if to == -1 {
+			to = len(queued)
+		}
+		return slices.Insert(queued, to, prompt)
+	})
+}
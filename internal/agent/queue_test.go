@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueue(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	s, err := env.sessions.Create(t.Context(), "Queue")
+	require.NoError(t, err)
+
+	agent := testSessionAgent(env, nil, nil, "").(*sessionAgent)
+	prompts := func(a *sessionAgent) []string {
+		var texts []string
+		for _, p := range a.QueuedPromptsList(s.ID) {
+			texts = append(texts, p.Prompt)
+		}
+		return texts
+	}
+
+	attachment := message.Attachment{FileName: "notes.txt", MimeType: "text/plain", Content: []byte("notes")}
+	agent.enqueue(t.Context(), SessionAgentCall{SessionID: s.ID, Prompt: "first", Attachments: []message.Attachment{attachment}})
+	agent.enqueue(t.Context(), SessionAgentCall{SessionID: s.ID, Prompt: "second"})
+	agent.enqueue(t.Context(), SessionAgentCall{SessionID: s.ID, Prompt: "third"})
+	require.Equal(t, []string{"first", "second", "third"}, prompts(agent))
+	queued := agent.QueuedPromptsList(s.ID)
+
+	require.NoError(t, agent.MoveQueuedPrompt(t.Context(), s.ID, queued[2].ID, -1))
+	require.Equal(t, []string{"first", "third", "second"}, prompts(agent))
+	require.NoError(t, agent.MoveQueuedPrompt(t.Context(), s.ID, queued[0].ID, 10))
+	require.Equal(t, []string{"third", "second", "first"}, prompts(agent))
+
+	require.NoError(t, agent.EditQueuedPrompt(t.Context(), s.ID, queued[1].ID, "second, edited"))
+	require.ErrorIs(t, agent.EditQueuedPrompt(t.Context(), s.ID, queued[1].ID, ""), ErrEmptyPrompt)
+	require.NoError(t, agent.SteerQueuedPrompt(t.Context(), s.ID, queued[0].ID))
+	require.Equal(t, []string{"first", "third", "second, edited"}, prompts(agent))
+	require.NoError(t, agent.RemoveQueuedPrompt(t.Context(), s.ID, queued[2].ID))
+	require.ErrorIs(t, agent.RemoveQueuedPrompt(t.Context(), s.ID, queued[2].ID), ErrQueuedPromptNotFound)
+
+	// The queue is loaded from the database by a new agent.
+	restarted := testSessionAgent(env, nil, nil, "").(*sessionAgent)
+	require.Equal(t, []string{"first", "second, edited"}, prompts(restarted))
+	restored := restarted.QueuedPromptsList(s.ID)
+	require.True(t, restored[0].Steer)
+	require.Equal(t, []message.Attachment{attachment}, restored[0].Attachments)
+
+	steering := restarted.takeSteeringPrompts(t.Context(), s.ID)
+	require.Len(t, steering, 1)
+	require.Equal(t, "first", steering[0].Prompt)
+
+	call, ok := restarted.dequeue(t.Context(), SessionAgentCall{SessionID: s.ID, Prompt: "current", MaxOutputTokens: 100})
+	require.True(t, ok)
+	require.Equal(t, "second, edited", call.Prompt)
+	require.Equal(t, int64(100), call.MaxOutputTokens)
+	_, ok = restarted.dequeue(t.Context(), call)
+	require.False(t, ok)
+
+	stored, err := env.sessions.QueuedPrompts(t.Context(), s.ID)
+	require.NoError(t, err)
+	require.Equal(t, []session.QueuedPrompt{}, stored)
+}
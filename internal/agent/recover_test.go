@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoordinatorRunRecovers(t *testing.T) {
+	t.Setenv("CRUSH_GLOBAL_CONFIG", t.TempDir())
+	t.Setenv("CRUSH_GLOBAL_DATA", t.TempDir())
+
+	srv := replyServer(t, "Done")
+	env := testEnv(t)
+	coordinator := testCoordinator(t, env, map[string]any{
+		"test": testProvider(srv.URL, "test-model"),
+	}, map[string]any{
+		"large": map[string]any{"provider": "test", "model": "test-model"},
+		"small": map[string]any{"provider": "test", "model": "test-model"},
+	})
+
+	// Crush stopped while the agent was running a tool.
+	sess, err := env.sessions.Create(t.Context(), "Interrupted")
+	require.NoError(t, err)
+	_, err = env.messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "List the files"}},
+	})
+	require.NoError(t, err)
+	interrupted, err := env.messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+		Role:  message.Assistant,
+		Parts: []message.ContentPart{message.ToolCall{ID: "call-1", Name: "ls", Input: "{}", Finished: true}},
+	})
+	require.NoError(t, err)
+
+	_, err = coordinator.Run(t.Context(), sess.ID, "Go on")
+	require.NoError(t, err)
+
+	interrupted, err = env.messages.Get(t.Context(), interrupted.ID)
+	require.NoError(t, err)
+	require.True(t, interrupted.IsFinished())
+
+	msgs, err := env.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, message.Tool, msgs[2].Role)
+	require.Equal(t, "call-1", msgs[2].ToolResults()[0].ToolCallID)
+	require.Equal(t, "Go on", msgs[3].Content().Text)
+}
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoordinatorResendCheckpoints(t *testing.T) {
+	t.Setenv("CRUSH_GLOBAL_CONFIG", t.TempDir())
+	t.Setenv("CRUSH_GLOBAL_DATA", t.TempDir())
+
+	srv := replyServer(t, "Done")
+	env := testEnv(t)
+	coordinator := testCoordinator(t, env, map[string]any{
+		"test": testProvider(srv.URL, "test-model"),
+	}, map[string]any{
+		"large": map[string]any{"provider": "test", "model": "test-model"},
+		"small": map[string]any{"provider": "test", "model": "test-model"},
+	})
+
+	sess, err := env.sessions.Create(t.Context(), "Resend")
+	require.NoError(t, err)
+	for _, prompt := range []string{"First", "Second", "Third"} {
+		_, err = coordinator.Run(t.Context(), sess.ID, prompt)
+		require.NoError(t, err)
+	}
+
+	prompts := func() []message.Message {
+		msgs, err := env.messages.List(t.Context(), sess.ID)
+		require.NoError(t, err)
+		var prompts []message.Message
+		for _, msg := range msgs {
+			if msg.Role == message.User {
+				prompts = append(prompts, msg)
+			}
+		}
+		return prompts
+	}
+	// requireCheckpoints checks that the prompts of the session are the
+	// ones with checkpoints.
+	requireCheckpoints := func(texts ...string) {
+		checkpoints, err := env.checkpoints.List(t.Context(), sess.ID)
+		require.NoError(t, err)
+		current := prompts()
+		require.Len(t, current, len(texts))
+		require.Len(t, checkpoints, len(texts))
+		for i, prompt := range current {
+			require.Equal(t, texts[i], prompt.Content().Text)
+			require.Equal(t, prompt.ID, checkpoints[i].MessageID)
+		}
+	}
+	requireCheckpoints("First", "Second", "Third")
+
+	_, err = coordinator.Resend(t.Context(), sess.ID, prompts()[1].ID, "Second again", ResendOptions{})
+	require.NoError(t, err)
+	requireCheckpoints("First", "Second again")
+
+	// The checkpoints of a hidden branch go too.
+	_, err = coordinator.Resend(t.Context(), sess.ID, prompts()[0].ID, "First again", ResendOptions{KeepBranch: true})
+	require.NoError(t, err)
+	requireCheckpoints("First again")
+}
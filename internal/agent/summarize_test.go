@@ -0,0 +1,32 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldAutoSummarize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		contextWindow int64
+		tokens        int64
+		threshold     float64
+		want          bool
+	}{
+		{name: "small window with room left", contextWindow: 100_000, tokens: 79_000},
+		{name: "small window nearly full", contextWindow: 100_000, tokens: 80_000, want: true},
+		{name: "large window with room left", contextWindow: 1_000_000, tokens: 979_000},
+		{name: "large window nearly full", contextWindow: 1_000_000, tokens: 980_000, want: true},
+		{name: "below threshold", contextWindow: 1_000_000, tokens: 499_000, threshold: 0.5},
+		{name: "at threshold", contextWindow: 1_000_000, tokens: 500_000, threshold: 0.5, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, shouldAutoSummarize(tt.contextWindow, tt.tokens, tt.threshold))
+		})
+	}
+}
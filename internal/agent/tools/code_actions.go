@@ -0,0 +1,248 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/lsp/util"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type CodeActionsParams struct {
+	FilePath  string `json:"file_path" description:"The file to get the code actions of"`
+	Line      int    `json:"line" description:"The line of the start of the range (1-based), such as the line of a diagnostic"`
+	Column    int    `json:"column" description:"The column of the start of the range (1-based), such as the column of a diagnostic"`
+	EndLine   int    `json:"end_line,omitempty" description:"The line of the end of the range (1-based). Defaults to line."`
+	EndColumn int    `json:"end_column,omitempty" description:"The column of the end of the range (1-based). Defaults to column."`
+	Apply     string `json:"apply,omitempty" description:"The action to apply as listed by a previous call: its number followed by its title, such as \"2. Organize Imports\", or its title alone. The actions are listed when empty."`
+}
+
+type CodeActionsPermissionsParams struct {
+	FilePath string `json:"file_path"`
+	Title    string `json:"title"`
+	Command  string `json:"command"`
+}
+
+const CodeActionsToolName = "lsp_code_actions"
+
+//go:embed code_actions.md
+var codeActionsDescription []byte
+
+func NewCodeActionsTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		CodeActionsToolName,
+		string(codeActionsDescription),
+		func(ctx context.Context, params CodeActionsParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.FilePath == "" {
+				return fantasy.NewTextErrorResponse("file_path is required"), nil
+			}
+			if params.Line < 1 || params.Column < 1 {
+				return fantasy.NewTextErrorResponse("line and column are required"), nil
+			}
+			if params.EndLine == 0 {
+				params.EndLine = params.Line
+				params.EndColumn = max(params.EndColumn, params.Column)
+			}
+			params.EndColumn = max(params.EndColumn, 1)
+
+			filePath := filepathext.SmartJoin(workingDir, params.FilePath)
+			client := clientForFile(lspClients, filePath)
+			if client == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("no LSP server handles %s", params.FilePath)), nil
+			}
+
+			actions, err := client.CodeActions(ctx, filePath, params.Line, params.Column, params.EndLine, params.EndColumn)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			location := fmt.Sprintf("%s:%d:%d", params.FilePath, params.Line, params.Column)
+			if len(actions) == 0 {
+				return fantasy.NewTextResponse(fmt.Sprintf("No code actions available at %s", location)), nil
+			}
+			if params.Apply == "" {
+				return fantasy.NewTextResponse(formatCodeActions(location, actions)), nil
+			}
+
+			action, err := findCodeAction(actions, params.Apply)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("%s\n\n%s", err, formatCodeActions(location, actions))), nil
+			}
+			if action.Disabled != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("code action %q is disabled: %s", action.Title, action.Disabled.Reason)), nil
+			}
+			if action.Edit == nil && action.Data != nil {
+				resolved, err := client.ResolveCodeAction(ctx, action)
+				if err == nil {
+					action = resolved
+				} else if action.Command == nil {
+					return fantasy.NewTextErrorResponse(err.Error()), nil
+				}
+			}
+
+			editCtx := editContext{ctx, permissions, files, workingDir, fileFormatter{}}
+			var (
+				mu      sync.Mutex
+				applied []util.FileChange
+			)
+			apply := func(edit protocol.WorkspaceEdit, description string) error {
+				changes, err := util.PreviewWorkspaceEdit(edit)
+				if err != nil {
+					return err
+				}
+				description = describeWorkspaceEdit(description, edit, changes)
+				if err := applyWorkspaceEdit(editCtx, call, CodeActionsToolName, filePath, description, edit, changes); err != nil {
+					return err
+				}
+				mu.Lock()
+				applied = append(applied, changes...)
+				mu.Unlock()
+				return nil
+			}
+
+			if action.Edit != nil {
+				if err := apply(*action.Edit, fmt.Sprintf("Apply %q", action.Title)); err != nil {
+					if errors.Is(err, permission.ErrorPermissionDenied) {
+						return fantasy.ToolResponse{}, err
+					}
+					return fantasy.NewTextErrorResponse(err.Error()), nil
+				}
+			}
+			if action.Command != nil {
+				sessionID := GetSessionFromContext(ctx)
+				if sessionID == "" {
+					return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for running a code action")
+				}
+				p := permissions.Request(permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        fsext.PathOrPrefix(filePath, workingDir),
+					ToolCallID:  call.ID,
+					ToolName:    CodeActionsToolName,
+					Action:      "execute",
+					Description: fmt.Sprintf("Run %q with the %s LSP server", action.Title, client.GetName()),
+					Params: CodeActionsPermissionsParams{
+						FilePath: filePath,
+						Title:    action.Title,
+						Command:  action.Command.Command,
+					},
+				})
+				if !p {
+					return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+				}
+
+				// The server asks to apply the edits of the command, if any,
+				// while running it.
+				var denied bool
+				err := client.ExecuteCommand(ctx, *action.Command, func(edit protocol.WorkspaceEdit) error {
+					err := apply(edit, fmt.Sprintf("Apply the edits of %q", action.Title))
+					if errors.Is(err, permission.ErrorPermissionDenied) {
+						mu.Lock()
+						denied = true
+						mu.Unlock()
+					}
+					return err
+				})
+				mu.Lock()
+				wasDenied := denied
+				mu.Unlock()
+				if wasDenied {
+					return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+				}
+				if err != nil {
+					return fantasy.NewTextErrorResponse(err.Error()), nil
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			// Notify LSP clients about the changes
+			notifyLSPs(ctx, lspClients, changedPaths(applied)...)
+
+			var text string
+			if len(applied) > 0 {
+				text = fmt.Sprintf("<result>\nApplied %q to %d file(s):\n%s</result>\n", action.Title, len(applied), formatFileChanges(applied, workingDir))
+			} else {
+				text = fmt.Sprintf("<result>\nApplied %q, no files changed\n</result>\n", action.Title)
+			}
+			text += getDiagnostics(filePath, lspClients)
+			return fantasy.NewTextResponse(text), nil
+		})
+}
+
+// formatCodeActions lists the code actions by number, along with their kind.
+func formatCodeActions(location string, actions []protocol.CodeAction) string {
+	var output strings.Builder
+	fmt.Fprintf(&output, "Code actions at %s:\n", location)
+	for i, action := range actions {
+		var tags []string
+		if action.Kind != "" {
+			tags = append(tags, string(action.Kind))
+		}
+		if action.IsPreferred {
+			tags = append(tags, "preferred")
+		}
+		fmt.Fprintf(&output, "%d. %s", i+1, action.Title)
+		if len(tags) > 0 {
+			fmt.Fprintf(&output, " [%s]", strings.Join(tags, ", "))
+		}
+		if action.Disabled != nil {
+			fmt.Fprintf(&output, " (disabled: %s)", action.Disabled.Reason)
+		}
+		output.WriteString("\n")
+	}
+	output.WriteString("\nApply one by calling this tool again with apply set to its number and title as listed, such as \"1. Title\", or to its title.\n")
+	return output.String()
+}
+
+// findCodeAction returns the action listed as choice: its 1-based number
+// followed by its title, as in "2. Organize Imports", or its title alone.
+// The title makes sure the number still lists the same action, as the list
+// is fetched again to apply it.
+func findCodeAction(actions []protocol.CodeAction, choice string) (protocol.CodeAction, error) {
+	choice = strings.TrimSpace(choice)
+	number, title, _ := strings.Cut(choice, ".")
+	if n, err := strconv.Atoi(strings.TrimSpace(number)); err == nil {
+		title = strings.TrimSpace(title)
+		if title == "" {
+			return protocol.CodeAction{}, fmt.Errorf("give the title of code action %d along with its number, as in \"%d. Title\"", n, n)
+		}
+		if n < 1 || n > len(actions) {
+			return protocol.CodeAction{}, fmt.Errorf("no code action %d", n)
+		}
+		action := actions[n-1]
+		if !listsTitle(title, action.Title) {
+			return protocol.CodeAction{}, fmt.Errorf("code action %d is now %q, not %q", n, action.Title, title)
+		}
+		return action, nil
+	}
+	for _, action := range actions {
+		if action.Title == choice {
+			return action, nil
+		}
+	}
+	for _, action := range actions {
+		if strings.EqualFold(action.Title, choice) {
+			return action, nil
+		}
+	}
+	return protocol.CodeAction{}, fmt.Errorf("no code action %q", choice)
+}
+
+// listsTitle returns whether the listed text is the title, possibly
+// followed by the kind and state shown in the list.
+func listsTitle(listed, title string) bool {
+	listed, title = strings.ToLower(listed), strings.ToLower(title)
+	return listed == title || strings.HasPrefix(listed, title+" [") || strings.HasPrefix(listed, title+" (")
+}
@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp/util"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindCodeAction(t *testing.T) {
+	t.Parallel()
+
+	actions := []protocol.CodeAction{
+		{Title: `Add import: "fmt"`, Kind: "quickfix", IsPreferred: true},
+		{Title: "Organize Imports", Kind: "source.organizeImports"},
+	}
+
+	for choice, want := range map[string]string{
+		`1. Add import: "fmt"`:                       `Add import: "fmt"`,
+		`1. Add import: "fmt" [quickfix, preferred]`: `Add import: "fmt"`,
+		"2. organize imports":                        "Organize Imports",
+		"Organize Imports":                           "Organize Imports",
+		" organize imports ":                         "Organize Imports",
+	} {
+		action, err := findCodeAction(actions, choice)
+		require.NoError(t, err, choice)
+		require.Equal(t, want, action.Title, choice)
+	}
+
+	for choice, msg := range map[string]string{
+		"1":                   "give the title of code action 1",
+		"2.":                  "give the title of code action 2",
+		"3. Organize Imports": "no code action 3",
+		"1. Organize Imports": `code action 1 is now "Add import: \"fmt\"", not "Organize Imports"`,
+		"2. Organize":         `code action 2 is now "Organize Imports", not "Organize"`,
+		"Extract function":    `no code action "Extract function"`,
+	} {
+		_, err := findCodeAction(actions, choice)
+		require.ErrorContains(t, err, msg, choice)
+	}
+}
+
+func TestFormatCodeActions(t *testing.T) {
+	t.Parallel()
+
+	actions := []protocol.CodeAction{
+		{Title: `Add import: "fmt"`, Kind: "quickfix", IsPreferred: true},
+		{Title: "Extract function", Kind: "refactor.extract", Disabled: &protocol.CodeActionDisabled{Reason: "no statements selected"}},
+		{Title: "Run tests"},
+	}
+
+	require.Equal(t, `Code actions at main.go:3:2:
+1. Add import: "fmt" [quickfix, preferred]
+2. Extract function [refactor.extract] (disabled: no statements selected)
+3. Run tests
+
+Apply one by calling this tool again with apply set to its number and title as listed, such as "1. Title", or to its title.
+`, formatCodeActions("main.go:3:2", actions))
+}
+
+type versionsHistoryService struct {
+	mockHistoryService
+	versions []string
+}
+
+func (m *versionsHistoryService) GetByPathAndSession(ctx context.Context, path, sessionID string) (history.File, error) {
+	return history.File{}, errors.New("not found")
+}
+
+func (m *versionsHistoryService) Create(ctx context.Context, sessionID, path, content string) (history.File, error) {
+	m.versions = append(m.versions, content)
+	return history.File{Path: path, Content: content}, nil
+}
+
+func (m *versionsHistoryService) CreateVersion(ctx context.Context, sessionID, path, content string) (history.File, error) {
+	m.versions = append(m.versions, content)
+	return history.File{Path: path, Content: content}, nil
+}
+
+func TestRecordWorkspaceEditHistoryNewFile(t *testing.T) {
+	t.Parallel()
+
+	files := &versionsHistoryService{}
+	err := recordWorkspaceEditHistory(t.Context(), files, "session", util.FileChange{
+		Path:       "/tmp/main.go",
+		OldContent: "package main\n",
+		NewContent: "package main\n\nfunc main() {}\n",
+	})
+	require.NoError(t, err)
+	// The original content is stored once, not again as an intermediate
+	// version.
+	require.Equal(t, []string{"package main\n", "package main\n\nfunc main() {}\n"}, files.versions)
+}
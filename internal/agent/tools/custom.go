@@ -0,0 +1,150 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+const defaultCustomToolTimeout = 2 * time.Minute
+
+// customToolEnvPrefix prefixes the environment variables holding the
+// parameters of a custom tool.
+const customToolEnvPrefix = "CRUSH_PARAM_"
+
+// NewCustomTools returns the tools declared in the config that run a shell
+// command.
+func NewCustomTools(customTools []config.CustomTool, permissions permission.Service, workingDir string) []fantasy.AgentTool {
+	result := make([]fantasy.AgentTool, len(customTools))
+	for i, tool := range customTools {
+		result[i] = &CustomTool{
+			cfg:         tool,
+			permissions: permissions,
+			workingDir:  workingDir,
+		}
+	}
+	return result
+}
+
+// CustomTool is a tool declared in the config.
+type CustomTool struct {
+	cfg             config.CustomTool
+	permissions     permission.Service
+	workingDir      string
+	providerOptions fantasy.ProviderOptions
+}
+
+func (t *CustomTool) SetProviderOptions(opts fantasy.ProviderOptions) {
+	t.providerOptions = opts
+}
+
+func (t *CustomTool) ProviderOptions() fantasy.ProviderOptions {
+	return t.providerOptions
+}
+
+func (t *CustomTool) Info() fantasy.ToolInfo {
+	parameters, required := schemaParameters(t.cfg.InputSchema)
+	return fantasy.ToolInfo{
+		Name:        t.cfg.Name,
+		Description: t.cfg.Description,
+		Parameters:  parameters,
+		Required:    required,
+	}
+}
+
+func (t *CustomTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	input := strings.TrimSpace(call.Input)
+	if input == "" {
+		input = "{}"
+	}
+	var params map[string]any
+	if err := json.Unmarshal([]byte(input), &params); err != nil {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid parameters: %s", err)), nil
+	}
+
+	sessionID := GetSessionFromContext(ctx)
+	if sessionID == "" {
+		return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for running a custom tool")
+	}
+	if t.cfg.Permission != config.CustomToolPermissionAllow {
+		p := t.permissions.Request(
+			permission.CreatePermissionRequest{
+				SessionID:   sessionID,
+				ToolCallID:  call.ID,
+				Path:        t.workingDir,
+				ToolName:    t.cfg.Name,
+				Action:      "execute",
+				Description: fmt.Sprintf("Execute command: %s", t.cfg.Command),
+				Params:      input,
+			},
+		)
+		if !p {
+			return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+		}
+	}
+
+	timeout := defaultCustomToolTimeout
+	if t.cfg.Timeout > 0 {
+		timeout = time.Duration(t.cfg.Timeout) * time.Second
+	}
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	sh := shell.NewShell(&shell.Options{
+		WorkingDir: t.workingDir,
+		Env:        append(os.Environ(), customToolEnv(params)...),
+		BlockFuncs: blockFuncs(),
+	})
+	stdout, stderr, execErr := sh.ExecInput(execCtx, t.cfg.Command, strings.NewReader(input))
+	if ctx.Err() != nil {
+		return fantasy.ToolResponse{}, ctx.Err()
+	}
+	if shell.ExitCode(execErr) == 0 && !shell.IsInterrupt(execErr) && execErr != nil {
+		return fantasy.ToolResponse{}, fmt.Errorf("error executing command: %w", execErr)
+	}
+
+	output := formatOutput(stdout, stderr, execErr)
+	if output == "" {
+		output = BashNoOutput
+	}
+	return fantasy.NewTextResponse(output), nil
+}
+
+// customToolEnv returns the parameters as environment variables. Strings are
+// passed as they are, other values as JSON.
+func customToolEnv(params map[string]any) []string {
+	env := make([]string, 0, len(params))
+	for name, value := range params {
+		str, ok := value.(string)
+		if !ok {
+			data, err := json.Marshal(value)
+			if err != nil {
+				continue
+			}
+			str = string(data)
+		}
+		env = append(env, customToolEnvName(name)+"="+str)
+	}
+	return env
+}
+
+func customToolEnvName(name string) string {
+	return customToolEnvPrefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomTool(t *testing.T) {
+	t.Parallel()
+
+	permissions := &mockPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()}
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	run := func(t *testing.T, cfg config.CustomTool, input string) fantasy.ToolResponse {
+		t.Helper()
+		tool := NewCustomTools([]config.CustomTool{cfg}, permissions, t.TempDir())[0]
+		response, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: cfg.Name, Input: input})
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("info", func(t *testing.T) {
+		t.Parallel()
+		tool := NewCustomTools([]config.CustomTool{{
+			Name:        "greet",
+			Description: "Greets someone",
+			InputSchema: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"name": map[string]any{"type": "string"}},
+				"required":   []any{"name"},
+			},
+			Command: "echo hello",
+		}}, permissions, t.TempDir())[0]
+		info := tool.Info()
+		require.Equal(t, "greet", info.Name)
+		require.Equal(t, "Greets someone", info.Description)
+		require.Contains(t, info.Parameters, "name")
+		require.Equal(t, []string{"name"}, info.Required)
+	})
+
+	t.Run("params as environment variables", func(t *testing.T) {
+		t.Parallel()
+		response := run(t, config.CustomTool{
+			Name:    "greet",
+			Command: `echo "hello $CRUSH_PARAM_NAME $CRUSH_PARAM_TIMES"`,
+		}, `{"name":"crush","times":2}`)
+		require.False(t, response.IsError)
+		require.Equal(t, "hello crush 2\n", response.Content)
+	})
+
+	t.Run("params on standard input", func(t *testing.T) {
+		t.Parallel()
+		response := run(t, config.CustomTool{
+			Name:    "stdin",
+			Command: "cat",
+		}, `{"name":"crush"}`)
+		require.Equal(t, `{"name":"crush"}`, response.Content)
+	})
+
+	t.Run("exit code", func(t *testing.T) {
+		t.Parallel()
+		response := run(t, config.CustomTool{
+			Name:    "fail",
+			Command: "echo failed >&2; exit 3",
+		}, "")
+		require.Contains(t, response.Content, "failed")
+		require.Contains(t, response.Content, "Exit code 3")
+	})
+
+	t.Run("blocked commands", func(t *testing.T) {
+		t.Parallel()
+		response := run(t, config.CustomTool{
+			Name:    "install",
+			Command: "apt-get install foo",
+		}, "")
+		require.Contains(t, response.Content, "not allowed")
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		t.Parallel()
+		response := run(t, config.CustomTool{
+			Name:    "greet",
+			Command: "echo hello",
+		}, "not json")
+		require.True(t, response.IsError)
+	})
+}
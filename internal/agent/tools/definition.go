@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type DefinitionParams struct {
+	Symbol   string `json:"symbol,omitempty" description:"The symbol name to look up (e.g., function name, type name, pkg.Func)"`
+	Path     string `json:"path,omitempty" description:"The directory or file to search the symbol in. Defaults to the current working directory."`
+	FilePath string `json:"file_path,omitempty" description:"The file where the symbol is used, to look it up by position instead of by name"`
+	Line     int    `json:"line,omitempty" description:"The line of the symbol in file_path (1-based)"`
+	Column   int    `json:"column,omitempty" description:"The column of the symbol in file_path (1-based)"`
+}
+
+const DefinitionToolName = "lsp_definition"
+
+// definitionSnippetLines is the number of lines of source shown from each
+// definition.
+const definitionSnippetLines = 12
+
+//go:embed definition.md
+var definitionDescription []byte
+
+func NewDefinitionTool(lspClients *csync.Map[string, *lsp.Client]) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		DefinitionToolName,
+		string(definitionDescription),
+		func(ctx context.Context, params DefinitionParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if lspClients.Len() == 0 {
+				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
+			}
+			positions, err := symbolPositions(ctx, lspClients, params.Symbol, params.Path, params.FilePath, params.Line, params.Column)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			var allErrs error
+			for _, pos := range positions {
+				locations, err := pos.client.Definition(ctx, pos.path, pos.line, pos.column)
+				if err != nil {
+					if strings.Contains(err.Error(), "no identifier found") {
+						continue
+					}
+					slog.Error("Failed to find definition", "error", err, "path", pos.path, "line", pos.line, "column", pos.column)
+					allErrs = errors.Join(allErrs, err)
+					continue
+				}
+				// Every use of the symbol leads to the same definition, the
+				// first answer is enough.
+				if len(locations) > 0 {
+					return fantasy.NewTextResponse(formatDefinitions(cleanupLocations(locations))), nil
+				}
+			}
+
+			if allErrs != nil {
+				return fantasy.NewTextErrorResponse(allErrs.Error()), nil
+			}
+			if params.FilePath != "" {
+				return fantasy.NewTextResponse(fmt.Sprintf("No definition found at %s:%d:%d", params.FilePath, params.Line, params.Column)), nil
+			}
+			return fantasy.NewTextResponse(fmt.Sprintf("No definition found for symbol '%s'", params.Symbol)), nil
+		})
+}
+
+func formatDefinitions(locations []protocol.Location) string {
+	var output strings.Builder
+	fmt.Fprintf(&output, "Found %d definition(s):\n", len(locations))
+	for _, loc := range locations {
+		path, err := loc.URI.Path()
+		if err != nil {
+			slog.Error("Failed to convert location URI to path", "uri", loc.URI, "error", err)
+			continue
+		}
+		line := int(loc.Range.Start.Line)
+		fmt.Fprintf(&output, "\n%s:%d:%d\n", path, line+1, loc.Range.Start.Character+1)
+		if snippet := sourceSnippet(path, line, definitionSnippetLines); snippet != "" {
+			output.WriteString(snippet + "\n")
+		}
+	}
+	return output.String()
+}
+
+// sourceSnippet returns count lines of the file starting at the given 0-based
+// line, with line numbers.
+func sourceSnippet(path string, line, count int) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(string(content), "\n")
+	if line >= len(lines) {
+		return ""
+	}
+	end := min(len(lines), line+count)
+	return addLineNumbers(strings.Join(lines[line:end], "\n"), line+1)
+}
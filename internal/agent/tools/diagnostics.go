@@ -38,17 +38,20 @@ func NewDiagnosticsTool(lspClients *csync.Map[string, *lsp.Client]) fantasy.Agen
 		})
 }
 
-func notifyLSPs(ctx context.Context, lsps *csync.Map[string, *lsp.Client], filepath string) {
-	if filepath == "" {
-		return
-	}
+func notifyLSPs(ctx context.Context, lsps *csync.Map[string, *lsp.Client], filepaths ...string) {
 	for client := range lsps.Seq() {
-		if !client.HandlesFile(filepath) {
-			continue
+		notified := false
+		for _, filepath := range filepaths {
+			if filepath == "" || !client.HandlesFile(filepath) {
+				continue
+			}
+			_ = client.OpenFileOnDemand(ctx, filepath)
+			_ = client.NotifyChange(ctx, filepath)
+			notified = true
+		}
+		if notified {
+			client.WaitForDiagnostics(ctx, 5*time.Second)
 		}
-		_ = client.OpenFileOnDemand(ctx, filepath)
-		_ = client.NotifyChange(ctx, filepath)
-		client.WaitForDiagnostics(ctx, 5*time.Second)
 	}
 }
 
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/diff"
 	"github.com/charmbracelet/crush/internal/filepathext"
@@ -51,9 +52,10 @@ type editContext struct {
 	permissions permission.Service
 	files       history.Service
 	workingDir  string
+	formatter   fileFormatter
 }
 
-func NewEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+func NewEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string, formatting config.Formatting) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		EditToolName,
 		string(editDescription),
@@ -67,7 +69,7 @@ func NewEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 			var response fantasy.ToolResponse
 			var err error
 
-			editCtx := editContext{ctx, permissions, files, workingDir}
+			editCtx := editContext{ctx, permissions, files, workingDir, newFileFormatter(lspClients, formatting, workingDir)}
 
 			if params.OldString == "" {
 				response, err = createNewFile(editCtx, params.FilePath, params.NewString, call)
@@ -144,6 +146,7 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
+	content, formatter := edit.formatter.format(edit.ctx, filePath, content)
 
 	// File can't be in the history so we create a new file history
 	_, err = edit.files.Create(edit.ctx, sessionID, filePath, "")
@@ -163,7 +166,7 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 	recordFileRead(filePath)
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("File created: "+filePath),
+		fantasy.NewTextResponse("File created: "+filePath+formattedNotice(filePath, formatter)),
 		EditResponseMetadata{
 			OldContent: "",
 			NewContent: content,
@@ -269,11 +272,12 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
+	newContent, formatter := edit.formatter.format(edit.ctx, filePath, newContent)
 
 	// Check if file exists in history
 	file, err := edit.files.GetByPathAndSession(edit.ctx, filePath, sessionID)
 	if err != nil {
-		_, err = edit.files.Create(edit.ctx, sessionID, filePath, oldContent)
+		file, err = edit.files.Create(edit.ctx, sessionID, filePath, oldContent)
 		if err != nil {
 			// Log error but don't fail the operation
 			return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
@@ -296,7 +300,7 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 	recordFileRead(filePath)
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("Content deleted from file: "+filePath),
+		fantasy.NewTextResponse("Content deleted from file: "+filePath+formattedNotice(filePath, formatter)),
 		EditResponseMetadata{
 			OldContent: oldContent,
 			NewContent: newContent,
@@ -404,11 +408,12 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
+	newContent, formatter := edit.formatter.format(edit.ctx, filePath, newContent)
 
 	// Check if file exists in history
 	file, err := edit.files.GetByPathAndSession(edit.ctx, filePath, sessionID)
 	if err != nil {
-		_, err = edit.files.Create(edit.ctx, sessionID, filePath, oldContent)
+		file, err = edit.files.Create(edit.ctx, sessionID, filePath, oldContent)
 		if err != nil {
 			// Log error but don't fail the operation
 			return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
@@ -431,7 +436,7 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 	recordFileRead(filePath)
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("Content replaced in file: "+filePath),
+		fantasy.NewTextResponse("Content replaced in file: "+filePath+formattedNotice(filePath, formatter)),
 		EditResponseMetadata{
 			OldContent: oldContent,
 			NewContent: newContent,
@@ -0,0 +1,153 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/lsp/util"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// formatTimeout bounds the time a file takes to format.
+const formatTimeout = 30 * time.Second
+
+// fileFormatter formats the files the editing tools write, through the LSP
+// server handling them or a configured formatter command. The zero value
+// does not format anything.
+type fileFormatter struct {
+	lspClients *csync.Map[string, *lsp.Client]
+	cfg        config.Formatting
+	workingDir string
+}
+
+func newFileFormatter(lspClients *csync.Map[string, *lsp.Client], cfg config.Formatting, workingDir string) fileFormatter {
+	return fileFormatter{lspClients: lspClients, cfg: cfg, workingDir: workingDir}
+}
+
+// format formats the file just written with content, when formatting on
+// write is enabled. It returns the content of the file afterwards and the
+// name of the formatter if it changed it. Formatting errors are logged and
+// leave the file as written. The LSP servers are not told of the formatted
+// content, the tools notify them once they are done with the file.
+func (f fileFormatter) format(ctx context.Context, path, content string) (string, string) {
+	if !f.cfg.OnWrite {
+		return content, ""
+	}
+	ctx, cancel := context.WithTimeout(ctx, formatTimeout)
+	defer cancel()
+
+	var (
+		name string
+		err  error
+	)
+	if client := f.lspFormatter(path); client != nil {
+		name = client.GetName()
+		err = formatWithLSP(ctx, client, path, content)
+	} else if formatter := f.commandFormatter(path); formatter != nil {
+		name = formatter.Command
+		err = formatWithCommand(ctx, *formatter, path, f.workingDir)
+	} else {
+		return content, ""
+	}
+	if err != nil {
+		slog.Warn("Failed to format file", "path", path, "formatter", name, "error", err)
+		return content, ""
+	}
+
+	formatted, err := os.ReadFile(path)
+	if err != nil {
+		slog.Warn("Failed to read formatted file", "path", path, "error", err)
+		return content, ""
+	}
+	if string(formatted) == content {
+		return content, ""
+	}
+	return string(formatted), name
+}
+
+func (f fileFormatter) lspFormatter(path string) *lsp.Client {
+	if f.cfg.DisableLSP || f.lspClients == nil {
+		return nil
+	}
+	client := clientForFile(f.lspClients, path)
+	if client == nil || !client.SupportsFormatting() {
+		return nil
+	}
+	return client
+}
+
+func (f fileFormatter) commandFormatter(path string) *config.Formatter {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	for i, formatter := range f.cfg.Formatters {
+		if slices.ContainsFunc(formatter.FileTypes, func(fileType string) bool {
+			return strings.TrimPrefix(strings.ToLower(fileType), ".") == ext
+		}) {
+			return &f.cfg.Formatters[i]
+		}
+	}
+	return nil
+}
+
+// documentFormatter is the part of an LSP client formatting files.
+type documentFormatter interface {
+	IsFileOpen(path string) bool
+	NotifyChange(ctx context.Context, path string) error
+	Format(ctx context.Context, path, content string) ([]protocol.TextEdit, error)
+}
+
+// formatWithLSP writes the edits the server formats the file with. The
+// server is left with the content before them.
+func formatWithLSP(ctx context.Context, client documentFormatter, path, content string) error {
+	// The server formats the content it knows of. An open file gets the new
+	// one first, Format opens the others with it.
+	if client.IsFileOpen(path) {
+		if err := client.NotifyChange(ctx, path); err != nil {
+			return err
+		}
+	}
+	edits, err := client.Format(ctx, path, content)
+	if err != nil {
+		return err
+	}
+	if len(edits) == 0 {
+		return nil
+	}
+	return util.ApplyWorkspaceEdit(protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			protocol.URIFromPath(path): edits,
+		},
+	})
+}
+
+func formatWithCommand(ctx context.Context, formatter config.Formatter, path, workingDir string) error {
+	quoted, err := syntax.Quote(path, syntax.LangBash)
+	if err != nil {
+		return err
+	}
+	sh := shell.NewShell(&shell.Options{WorkingDir: workingDir})
+	_, stderr, err := sh.Exec(ctx, formatter.Command+" "+quoted)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+	}
+	return nil
+}
+
+// formattedNotice tells the agent a file changed after its edit, so that it
+// does not edit it based on the content it wrote.
+func formattedNotice(path, formatter string) string {
+	if formatter == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n\nThe file %s was reformatted by %s after the change. View it again before editing it, the content you wrote may no longer match.", path, formatter)
+}
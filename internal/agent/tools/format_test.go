@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileFormatter(t *testing.T) {
+	t.Parallel()
+
+	write := func(t *testing.T, name, content string) string {
+		path := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+	cfg := config.Formatting{
+		OnWrite: true,
+		Formatters: []config.Formatter{
+			{FileTypes: []string{".txt", "MD"}, Command: "echo formatted >"},
+		},
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+		path := write(t, "a.txt", "content\n")
+		content, formatter := fileFormatter{}.format(t.Context(), path, "content\n")
+		require.Equal(t, "content\n", content)
+		require.Empty(t, formatter)
+	})
+
+	t.Run("command", func(t *testing.T) {
+		t.Parallel()
+		for _, name := range []string{"a.txt", "b.md"} {
+			path := write(t, name, "content\n")
+			content, formatter := newFileFormatter(nil, cfg, t.TempDir()).format(t.Context(), path, "content\n")
+			require.Equal(t, "formatted\n", content)
+			require.Equal(t, "echo formatted >", formatter)
+			require.NotEmpty(t, formattedNotice(path, formatter))
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		t.Parallel()
+		path := write(t, "a.txt", "formatted\n")
+		content, formatter := newFileFormatter(nil, cfg, t.TempDir()).format(t.Context(), path, "formatted\n")
+		require.Equal(t, "formatted\n", content)
+		require.Empty(t, formatter)
+		require.Empty(t, formattedNotice(path, formatter))
+	})
+
+	t.Run("no formatter", func(t *testing.T) {
+		t.Parallel()
+		path := write(t, "a.go", "content\n")
+		content, formatter := newFileFormatter(nil, cfg, t.TempDir()).format(t.Context(), path, "content\n")
+		require.Equal(t, "content\n", content)
+		require.Empty(t, formatter)
+	})
+}
+
+type fakeFormatter struct {
+	open  bool
+	edits []protocol.TextEdit
+	calls []string
+}
+
+func (f *fakeFormatter) IsFileOpen(path string) bool {
+	return f.open
+}
+
+func (f *fakeFormatter) NotifyChange(ctx context.Context, path string) error {
+	f.calls = append(f.calls, "change")
+	return nil
+}
+
+func (f *fakeFormatter) Format(ctx context.Context, path, content string) ([]protocol.TextEdit, error) {
+	f.calls = append(f.calls, "format")
+	return f.edits, nil
+}
+
+func TestFormatWithLSP(t *testing.T) {
+	t.Parallel()
+
+	content := "package main\nfunc main()  {}\n"
+	edits := []protocol.TextEdit{
+		{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 1, Character: 0},
+				End:   protocol.Position{Line: 1, Character: 0},
+			},
+			NewText: "\n",
+		},
+		{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 1, Character: 11},
+				End:   protocol.Position{Line: 1, Character: 13},
+			},
+			NewText: " ",
+		},
+	}
+
+	for _, tt := range []struct {
+		name  string
+		open  bool
+		calls []string
+	}{
+		{"open file", true, []string{"change", "format"}},
+		{"closed file", false, []string{"format"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "main.go")
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+			client := &fakeFormatter{open: tt.open, edits: edits}
+			require.NoError(t, formatWithLSP(t.Context(), client, path, content))
+			require.Equal(t, tt.calls, client.calls)
+
+			formatted, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, "package main\n\nfunc main() {}\n", string(formatted))
+		})
+	}
+
+	t.Run("no edits", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "main.go")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		require.NoError(t, formatWithLSP(t.Context(), &fakeFormatter{}, path, content))
+		formatted, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, content, string(formatted))
+	})
+}
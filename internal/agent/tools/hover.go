@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/lsp"
+)
+
+type HoverParams struct {
+	Symbol   string `json:"symbol,omitempty" description:"The symbol name to look up (e.g., function name, type name, pkg.Func)"`
+	Path     string `json:"path,omitempty" description:"The directory or file to search the symbol in. Defaults to the current working directory."`
+	FilePath string `json:"file_path,omitempty" description:"The file where the symbol is used, to look it up by position instead of by name"`
+	Line     int    `json:"line,omitempty" description:"The line of the symbol in file_path (1-based)"`
+	Column   int    `json:"column,omitempty" description:"The column of the symbol in file_path (1-based)"`
+}
+
+const HoverToolName = "lsp_hover"
+
+//go:embed hover.md
+var hoverDescription []byte
+
+func NewHoverTool(lspClients *csync.Map[string, *lsp.Client]) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		HoverToolName,
+		string(hoverDescription),
+		func(ctx context.Context, params HoverParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if lspClients.Len() == 0 {
+				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
+			}
+			positions, err := symbolPositions(ctx, lspClients, params.Symbol, params.Path, params.FilePath, params.Line, params.Column)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			var allErrs error
+			for _, pos := range positions {
+				text, err := pos.client.Hover(ctx, pos.path, pos.line, pos.column)
+				if err != nil {
+					if strings.Contains(err.Error(), "no identifier found") {
+						continue
+					}
+					slog.Error("Failed to get hover information", "error", err, "path", pos.path, "line", pos.line, "column", pos.column)
+					allErrs = errors.Join(allErrs, err)
+					continue
+				}
+				if text != "" {
+					return fantasy.NewTextResponse(text), nil
+				}
+			}
+
+			if allErrs != nil {
+				return fantasy.NewTextErrorResponse(allErrs.Error()), nil
+			}
+			if params.FilePath != "" {
+				return fantasy.NewTextResponse(fmt.Sprintf("No information found at %s:%d:%d", params.FilePath, params.Line, params.Column)), nil
+			}
+			return fantasy.NewTextResponse(fmt.Sprintf("No information found for symbol '%s'", params.Symbol)), nil
+		})
+}
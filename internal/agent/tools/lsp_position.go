@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/lsp"
+)
+
+// symbolPosition is a position of a symbol in a file, along with the LSP
+// client handling that file. Lines and columns are 1-based.
+type symbolPosition struct {
+	client *lsp.Client
+	path   string
+	line   int
+	column int
+}
+
+// errSymbolOrPosition is returned when a tool is given neither a symbol nor a
+// position.
+var errSymbolOrPosition = errors.New("either symbol, or file_path with line and column, is required")
+
+// clientForFile returns the LSP client handling the file, if any.
+func clientForFile(lspClients *csync.Map[string, *lsp.Client], absPath string) *lsp.Client {
+	for c := range lspClients.Seq() {
+		if c.HandlesFile(absPath) {
+			return c
+		}
+	}
+	return nil
+}
+
+// symbolPositions returns where to ask the LSP servers about a symbol: the
+// given position of filePath when set, or the occurrences of symbol under
+// path otherwise. Occurrences in files no server handles are skipped.
+func symbolPositions(ctx context.Context, lspClients *csync.Map[string, *lsp.Client], symbol, path, filePath string, line, column int) ([]symbolPosition, error) {
+	if filePath != "" {
+		if line < 1 || column < 1 {
+			return nil, errors.New("line and column are required with file_path")
+		}
+		absPath, err := filepath.Abs(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path: %s", err)
+		}
+		client := clientForFile(lspClients, absPath)
+		if client == nil {
+			return nil, fmt.Errorf("no LSP server handles %s", filePath)
+		}
+		return []symbolPosition{{client: client, path: absPath, line: line, column: column}}, nil
+	}
+	if symbol == "" {
+		return nil, errSymbolOrPosition
+	}
+
+	matches, _, err := searchFiles(ctx, regexp.QuoteMeta(symbol), cmp.Or(path, "."), "", 100)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for symbol: %s", err)
+	}
+	var positions []symbolPosition
+	for _, match := range matches {
+		absPath, err := filepath.Abs(match.path)
+		if err != nil {
+			continue
+		}
+		client := clientForFile(lspClients, absPath)
+		if client == nil {
+			continue
+		}
+		positions = append(positions, symbolPosition{
+			client: client,
+			path:   absPath,
+			line:   match.lineNum,
+			column: match.charNum + getSymbolOffset(symbol),
+		})
+	}
+	return positions, nil
+}
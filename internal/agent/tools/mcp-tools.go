@@ -54,26 +54,15 @@ func (m *Tool) MCPToolName() string {
 	return m.tool.Name
 }
 
-func (m *Tool) Info() fantasy.ToolInfo {
-	parameters := make(map[string]any)
-	required := make([]string, 0)
+// ReadOnly reports whether the MCP server annotated the tool as one that does
+// not modify its environment.
+func (m *Tool) ReadOnly() bool {
+	return m.tool.Annotations != nil && m.tool.Annotations.ReadOnlyHint
+}
 
-	if input, ok := m.tool.InputSchema.(map[string]any); ok {
-		if props, ok := input["properties"].(map[string]any); ok {
-			parameters = props
-		}
-		if req, ok := input["required"].([]any); ok {
-			// Convert []any -> []string when elements are strings
-			for _, v := range req {
-				if s, ok := v.(string); ok {
-					required = append(required, s)
-				}
-			}
-		} else if reqStr, ok := input["required"].([]string); ok {
-			// Handle case where it's already []string
-			required = reqStr
-		}
-	}
+func (m *Tool) Info() fantasy.ToolInfo {
+	input, _ := m.tool.InputSchema.(map[string]any)
+	parameters, required := schemaParameters(input)
 
 	return fantasy.ToolInfo{
 		Name:        m.Name(),
@@ -83,6 +72,29 @@ func (m *Tool) Info() fantasy.ToolInfo {
 	}
 }
 
+// schemaParameters returns the properties and the required properties of a
+// JSON schema.
+func schemaParameters(schema map[string]any) (map[string]any, []string) {
+	parameters := make(map[string]any)
+	required := make([]string, 0)
+
+	if props, ok := schema["properties"].(map[string]any); ok {
+		parameters = props
+	}
+	if req, ok := schema["required"].([]any); ok {
+		// Convert []any -> []string when elements are strings
+		for _, v := range req {
+			if s, ok := v.(string); ok {
+				required = append(required, s)
+			}
+		}
+	} else if reqStr, ok := schema["required"].([]string); ok {
+		// Handle case where it's already []string
+		required = reqStr
+	}
+	return parameters, required
+}
+
 func (m *Tool) Run(ctx context.Context, params fantasy.ToolCall) (fantasy.ToolResponse, error) {
 	sessionID := GetSessionFromContext(ctx)
 	if sessionID == "" {
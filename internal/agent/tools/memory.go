@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/memory"
+)
+
+//go:embed memory.md
+var memoryDescription []byte
+
+const MemoryToolName = "memory"
+
+const (
+	MemoryActionAdd    = "add"
+	MemoryActionList   = "list"
+	MemoryActionUpdate = "update"
+	MemoryActionDelete = "delete"
+)
+
+type MemoryParams struct {
+	Action  string `json:"action" description:"One of add, list, update or delete"`
+	Content string `json:"content,omitempty" description:"The fact to remember, required to add or update a memory"`
+	ID      string `json:"id,omitempty" description:"The id of the memory to update or delete"`
+}
+
+type MemoryResponseMetadata struct {
+	Action   string          `json:"action"`
+	Memories []memory.Memory `json:"memories,omitempty"`
+}
+
+func NewMemoryTool(dataDir string) fantasy.AgentTool {
+	store := memory.NewStore(dataDir)
+	return fantasy.NewAgentTool(
+		MemoryToolName,
+		string(memoryDescription),
+		func(ctx context.Context, params MemoryParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			var (
+				response string
+				changed  memory.Memory
+				err      error
+			)
+			switch params.Action {
+			case MemoryActionAdd:
+				if strings.TrimSpace(params.Content) == "" {
+					return fantasy.NewTextErrorResponse("content is required to add a memory"), nil
+				}
+				changed, err = store.Add(params.Content, GetSessionFromContext(ctx))
+				response = fmt.Sprintf("Saved memory %s.", changed.ID)
+			case MemoryActionUpdate:
+				if params.ID == "" || strings.TrimSpace(params.Content) == "" {
+					return fantasy.NewTextErrorResponse("id and content are required to update a memory"), nil
+				}
+				changed, err = store.Update(params.ID, params.Content)
+				response = fmt.Sprintf("Updated memory %s.", params.ID)
+			case MemoryActionDelete:
+				if params.ID == "" {
+					return fantasy.NewTextErrorResponse("id is required to delete a memory"), nil
+				}
+				err = store.Delete(params.ID)
+				response = fmt.Sprintf("Deleted memory %s.", params.ID)
+			case MemoryActionList:
+				memories, err := store.List()
+				if err != nil {
+					return fantasy.ToolResponse{}, err
+				}
+				return fantasy.WithResponseMetadata(fantasy.NewTextResponse(formatMemories(memories)), MemoryResponseMetadata{
+					Action:   params.Action,
+					Memories: memories,
+				}), nil
+			default:
+				return fantasy.NewTextErrorResponse("action must be add, list, update or delete"), nil
+			}
+			if errors.Is(err, memory.ErrNotFound) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("memory %s not found", params.ID)), nil
+			}
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+
+			metadata := MemoryResponseMetadata{Action: params.Action}
+			if changed.ID != "" {
+				metadata.Memories = []memory.Memory{changed}
+			}
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(response), metadata), nil
+		})
+}
+
+func formatMemories(memories []memory.Memory) string {
+	if len(memories) == 0 {
+		return "No memories saved."
+	}
+	var sb strings.Builder
+	for _, m := range memories {
+		fmt.Fprintf(&sb, "- [%s] (%s) %s\n", m.ID, m.UpdatedAt.Format("2006-01-02"), m.Content)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
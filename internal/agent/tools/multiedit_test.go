@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/lsp"
@@ -30,6 +31,10 @@ func (m *mockPermissionService) GrantPersistent(req permission.PermissionRequest
 
 func (m *mockPermissionService) AutoApproveSession(sessionID string) {}
 
+func (m *mockPermissionService) ApproveToolCall(toolCallID string) {}
+
+func (m *mockPermissionService) ForgetToolCall(toolCallID string) {}
+
 func (m *mockPermissionService) SetSkipRequests(skip bool) {}
 
 func (m *mockPermissionService) SkipRequests() bool {
@@ -76,6 +81,22 @@ func (m *mockHistoryService) DeleteSessionFiles(ctx context.Context, sessionID s
 	return nil
 }
 
+func (m *mockHistoryService) Versions(ctx context.Context, sessionID string) (history.Versions, error) {
+	return nil, nil
+}
+
+func (m *mockHistoryService) Changes(ctx context.Context, sessionID string, since history.Versions) ([]history.Change, error) {
+	return nil, nil
+}
+
+func (m *mockHistoryService) Revert(ctx context.Context, sessionID string, changes ...history.Change) ([]history.File, error) {
+	return nil, nil
+}
+
+func (m *mockHistoryService) Restore(ctx context.Context, sessionID string, since history.Versions) ([]history.Change, error) {
+	return nil, nil
+}
+
 func TestApplyEditToContentPartialSuccess(t *testing.T) {
 	t.Parallel()
 
@@ -116,7 +137,7 @@ func TestMultiEditSequentialApplication(t *testing.T) {
 	files := &mockHistoryService{Broker: pubsub.NewBroker[history.File]()}
 
 	// Create multiedit tool.
-	_ = NewMultiEditTool(lspClients, permissions, files, tmpDir)
+	_ = NewMultiEditTool(lspClients, permissions, files, tmpDir, config.Formatting{})
 
 	// Simulate reading the file first.
 	recordFileRead(testFile)
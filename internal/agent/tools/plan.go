@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+//go:embed plan.md
+var planDescription []byte
+
+const PlanToolName = "plan"
+
+type PlanParams struct {
+	Summary string     `json:"summary" description:"A short summary of what will change and why"`
+	Steps   []PlanStep `json:"steps" description:"The ordered steps needed to carry out the plan"`
+	Risks   []string   `json:"risks,omitempty" description:"Open questions or risks the user should be aware of"`
+}
+
+type PlanStep struct {
+	Description string   `json:"description" description:"What this step changes (imperative form)"`
+	Files       []string `json:"files,omitempty" description:"The files this step is expected to touch"`
+}
+
+type PlanResponseMetadata struct {
+	Plan  string `json:"plan"`
+	Steps int    `json:"steps"`
+}
+
+func NewPlanTool(sessions session.Service) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		PlanToolName,
+		string(planDescription),
+		func(ctx context.Context, params PlanParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for writing a plan")
+			}
+			if len(params.Steps) == 0 {
+				return fantasy.NewTextErrorResponse("the plan must have at least one step"), nil
+			}
+
+			currentSession, err := sessions.Get(ctx, sessionID)
+			if err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("failed to get session: %w", err)
+			}
+			if !currentSession.PlanMode {
+				return fantasy.NewTextErrorResponse("the session is not in plan mode"), nil
+			}
+
+			plan := FormatPlan(params)
+			currentSession.Plan = plan
+			if _, err := sessions.Save(ctx, currentSession); err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("failed to save plan: %w", err)
+			}
+
+			response := fmt.Sprintf("Plan saved with %d steps. The user will review it and switch the session to execution mode when ready. Do not start implementing it.", len(params.Steps))
+			metadata := PlanResponseMetadata{
+				Plan:  plan,
+				Steps: len(params.Steps),
+			}
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(response), metadata), nil
+		})
+}
+
+// FormatPlan renders the plan as markdown, which is how it is stored and
+// shown to the user for review.
+func FormatPlan(params PlanParams) string {
+	var sb strings.Builder
+	sb.WriteString("## Summary\n\n")
+	sb.WriteString(strings.TrimSpace(params.Summary))
+	sb.WriteString("\n\n## Steps\n\n")
+	for i, step := range params.Steps {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, strings.TrimSpace(step.Description))
+		for _, file := range step.Files {
+			fmt.Fprintf(&sb, "   - `%s`\n", file)
+		}
+	}
+	if len(params.Risks) > 0 {
+		sb.WriteString("\n## Risks\n\n")
+		for _, risk := range params.Risks {
+			fmt.Fprintf(&sb, "- %s\n", strings.TrimSpace(risk))
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
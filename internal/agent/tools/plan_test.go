@@ -0,0 +1,33 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatPlan(t *testing.T) {
+	t.Parallel()
+
+	t.Run("steps with files", func(t *testing.T) {
+		t.Parallel()
+		plan := FormatPlan(PlanParams{
+			Summary: "Add a plan mode.",
+			Steps: []PlanStep{
+				{Description: "Store the plan in the session", Files: []string{"internal/session/session.go"}},
+				{Description: "Add the plan tool"},
+			},
+		})
+		require.Equal(t, "## Summary\n\nAdd a plan mode.\n\n## Steps\n\n1. Store the plan in the session\n   - `internal/session/session.go`\n2. Add the plan tool", plan)
+	})
+
+	t.Run("risks", func(t *testing.T) {
+		t.Parallel()
+		plan := FormatPlan(PlanParams{
+			Summary: "Rename the package.",
+			Steps:   []PlanStep{{Description: "Rename it"}},
+			Risks:   []string{"Breaks importers"},
+		})
+		require.Contains(t, plan, "## Risks\n\n- Breaks importers")
+	})
+}
@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/lsp/util"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type RenameParams struct {
+	Symbol   string `json:"symbol,omitempty" description:"The symbol name to rename (e.g., function name, type name, pkg.Func)"`
+	Path     string `json:"path,omitempty" description:"The directory or file to search the symbol in. Defaults to the current working directory."`
+	FilePath string `json:"file_path,omitempty" description:"The file where the symbol is used, to rename it by position instead of by name"`
+	Line     int    `json:"line,omitempty" description:"The line of the symbol in file_path (1-based)"`
+	Column   int    `json:"column,omitempty" description:"The column of the symbol in file_path (1-based)"`
+	NewName  string `json:"new_name" description:"The new name of the symbol"`
+}
+
+const RenameToolName = "lsp_rename"
+
+//go:embed rename.md
+var renameDescription []byte
+
+func NewRenameTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		RenameToolName,
+		string(renameDescription),
+		func(ctx context.Context, params RenameParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.NewName == "" {
+				return fantasy.NewTextErrorResponse("new_name is required"), nil
+			}
+			if lspClients.Len() == 0 {
+				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
+			}
+			positions, err := symbolPositions(ctx, lspClients, params.Symbol, params.Path, params.FilePath, params.Line, params.Column)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			var (
+				edit    protocol.WorkspaceEdit
+				target  symbolPosition
+				found   bool
+				allErrs error
+			)
+			for _, pos := range positions {
+				edit, err = pos.client.Rename(ctx, pos.path, pos.line, pos.column, params.NewName)
+				if err != nil {
+					if strings.Contains(err.Error(), "no identifier found") {
+						continue
+					}
+					slog.Error("Failed to rename symbol", "error", err, "path", pos.path, "line", pos.line, "column", pos.column)
+					allErrs = errors.Join(allErrs, err)
+					continue
+				}
+				if len(edit.Changes) > 0 || len(edit.DocumentChanges) > 0 {
+					target, found = pos, true
+					break
+				}
+			}
+			if !found {
+				if allErrs != nil {
+					return fantasy.NewTextErrorResponse(allErrs.Error()), nil
+				}
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("Nothing to rename for %s", renameTarget(params))), nil
+			}
+
+			changes, err := util.PreviewWorkspaceEdit(edit)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			description := describeWorkspaceEdit(fmt.Sprintf("Rename %s to %s", renameTarget(params), params.NewName), edit, changes)
+			editCtx := editContext{ctx, permissions, files, workingDir, fileFormatter{}}
+			if err := applyWorkspaceEdit(editCtx, call, RenameToolName, target.path, description, edit, changes); err != nil {
+				if errors.Is(err, permission.ErrorPermissionDenied) {
+					return fantasy.ToolResponse{}, err
+				}
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			// Notify LSP clients about the changes
+			notifyLSPs(ctx, lspClients, changedPaths(changes)...)
+
+			text := fmt.Sprintf("<result>\n%s:\n%s</result>\n", strings.Replace(description, "Rename", "Renamed", 1), formatFileChanges(changes, workingDir))
+			text += getDiagnostics(target.path, lspClients)
+			return fantasy.NewTextResponse(text), nil
+		})
+}
+
+// renameTarget describes the symbol being renamed.
+func renameTarget(params RenameParams) string {
+	if params.FilePath != "" {
+		return fmt.Sprintf("symbol at %s:%d:%d", params.FilePath, params.Line, params.Column)
+	}
+	return params.Symbol
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/symbols"
+)
+
+type SymbolsParams struct {
+	FilePath string `json:"file_path,omitempty" description:"The file to outline"`
+	Query    string `json:"query,omitempty" description:"The symbol name to search for in the whole project, matched fuzzily"`
+}
+
+const SymbolsToolName = "lsp_symbols"
+
+// maxSymbolResults is the number of symbols listed at most.
+const maxSymbolResults = 200
+
+//go:embed symbols.md
+var symbolsDescription []byte
+
+func NewSymbolsTool(index *symbols.Index, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		SymbolsToolName,
+		string(symbolsDescription),
+		func(ctx context.Context, params SymbolsParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			var (
+				result []symbols.Symbol
+				err    error
+			)
+			switch {
+			case params.FilePath != "":
+				filePath := filepathext.SmartJoin(workingDir, params.FilePath)
+				result, err = index.Document(ctx, filePath)
+				if errors.Is(err, symbols.ErrUnsupported) {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("cannot outline %s: no LSP server handles it", params.FilePath)), nil
+				}
+			case params.Query != "":
+				result, err = index.Workspace(ctx, params.Query, 0)
+			default:
+				return fantasy.NewTextErrorResponse("either file_path or query is required"), nil
+			}
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if len(result) == 0 {
+				return fantasy.NewTextResponse("No symbols found"), nil
+			}
+
+			var output strings.Builder
+			for i, symbol := range result {
+				if i == maxSymbolResults {
+					fmt.Fprintf(&output, "(%d more symbols, narrow the query to see them)\n", len(result)-maxSymbolResults)
+					break
+				}
+				output.WriteString(symbol.String() + "\n")
+			}
+			return fantasy.NewTextResponse(output.String()), nil
+		})
+}
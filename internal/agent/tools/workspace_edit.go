@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp/util"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type WorkspaceEditFile struct {
+	FilePath   string `json:"file_path"`
+	OldContent string `json:"old_content,omitempty"`
+	NewContent string `json:"new_content,omitempty"`
+}
+
+// WorkspaceEditPermissionsParams are the permission parameters of the tools
+// applying LSP workspace edits, which can change several files at once.
+type WorkspaceEditPermissionsParams struct {
+	Files []WorkspaceEditFile `json:"files"`
+}
+
+// describeWorkspaceEdit completes the description of an edit with the number
+// of files it changes.
+func describeWorkspaceEdit(description string, edit protocol.WorkspaceEdit, changes []util.FileChange) string {
+	description = fmt.Sprintf("%s in %d file(s)", description, len(changes))
+	fileOperations := 0
+	for _, change := range edit.DocumentChanges {
+		if change.TextDocumentEdit == nil {
+			fileOperations++
+		}
+	}
+	if fileOperations > 0 {
+		description += fmt.Sprintf(" with %d file operation(s)", fileOperations)
+	}
+	return description
+}
+
+// applyWorkspaceEdit asks for permission to apply an LSP workspace edit,
+// showing the changes it makes to every file, then applies it and records
+// the new content of the files in the history. Files created, renamed or
+// deleted by the edit are recorded too, deleted ones with no content.
+func applyWorkspaceEdit(edit editContext, call fantasy.ToolCall, toolName, path, description string, workspaceEdit protocol.WorkspaceEdit, changes []util.FileChange) error {
+	sessionID := GetSessionFromContext(edit.ctx)
+	if sessionID == "" {
+		return fmt.Errorf("session ID is required for applying edits")
+	}
+
+	permissionFiles := make([]WorkspaceEditFile, 0, len(changes))
+	for _, change := range changes {
+		permissionFiles = append(permissionFiles, WorkspaceEditFile{
+			FilePath:   change.Path,
+			OldContent: change.OldContent,
+			NewContent: change.NewContent,
+		})
+	}
+	p := edit.permissions.Request(permission.CreatePermissionRequest{
+		SessionID:   sessionID,
+		Path:        fsext.PathOrPrefix(path, edit.workingDir),
+		ToolCallID:  call.ID,
+		ToolName:    toolName,
+		Action:      "write",
+		Description: description,
+		Params: WorkspaceEditPermissionsParams{
+			Files: permissionFiles,
+		},
+	})
+	if !p {
+		return permission.ErrorPermissionDenied
+	}
+
+	// The files may have changed while the permission was asked for, the
+	// edit would not apply to what the user approved anymore.
+	if err := util.CheckFileChanges(changes); err != nil {
+		return fmt.Errorf("%w, run the tool again", err)
+	}
+	if err := util.ApplyWorkspaceEdit(workspaceEdit); err != nil {
+		return fmt.Errorf("failed to apply edits: %w", err)
+	}
+
+	for _, change := range changes {
+		if err := recordWorkspaceEditHistory(edit.ctx, edit.files, sessionID, change); err != nil {
+			return err
+		}
+		if change.Deleted {
+			continue
+		}
+		recordFileWrite(change.Path)
+		recordFileRead(change.Path)
+	}
+	return nil
+}
+
+// recordWorkspaceEditHistory stores the edited content of a file as a new
+// version.
+func recordWorkspaceEditHistory(ctx context.Context, files history.Service, sessionID string, change util.FileChange) error {
+	file, err := files.GetByPathAndSession(ctx, change.Path, sessionID)
+	if err != nil {
+		file, err = files.Create(ctx, sessionID, change.Path, change.OldContent)
+		if err != nil {
+			return fmt.Errorf("error creating file history: %w", err)
+		}
+	}
+	if file.Content != change.OldContent {
+		// User manually changed the content, store an intermediate version
+		_, err = files.CreateVersion(ctx, sessionID, change.Path, change.OldContent)
+		if err != nil {
+			slog.Error("Error creating file history version", "error", err)
+		}
+	}
+
+	// Store the new version
+	_, err = files.CreateVersion(ctx, sessionID, change.Path, change.NewContent)
+	if err != nil {
+		slog.Error("Error creating file history version", "error", err)
+	}
+	return nil
+}
+
+// formatFileChanges lists the changed files along with their numbers of
+// added and removed lines.
+func formatFileChanges(changes []util.FileChange, workingDir string) string {
+	var output strings.Builder
+	for _, change := range changes {
+		_, additions, removals := diff.GenerateDiff(change.OldContent, change.NewContent, strings.TrimPrefix(change.Path, workingDir))
+		fmt.Fprintf(&output, "- %s (+%d -%d)\n", change.Path, additions, removals)
+	}
+	return output.String()
+}
+
+// changedPaths returns the paths of the changed files.
+func changedPaths(changes []util.FileChange) []string {
+	paths := make([]string, 0, len(changes))
+	for _, change := range changes {
+		paths = append(paths, change.Path)
+	}
+	return paths
+}
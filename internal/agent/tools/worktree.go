@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/worktree"
+)
+
+//go:embed worktree.md
+var worktreeDescription []byte
+
+const WorktreeToolName = "worktree"
+
+const (
+	WorktreeActionMerge   = "merge"
+	WorktreeActionDiscard = "discard"
+)
+
+type WorktreeParams struct {
+	ID     string `json:"id" description:"The id of the worktree reported by the agent tool"`
+	Action string `json:"action" description:"Either merge to apply the changes to the working directory or discard to drop them"`
+}
+
+type WorktreeResponseMetadata struct {
+	Action string   `json:"action"`
+	Files  []string `json:"files,omitempty"`
+}
+
+func NewWorktreeTool(permissions permission.Service, files history.Service, workingDir, dataDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		WorktreeToolName,
+		string(worktreeDescription),
+		func(ctx context.Context, params WorktreeParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if !worktree.ValidID(params.ID) {
+				return fantasy.NewTextErrorResponse("a valid worktree id is required"), nil
+			}
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for merging a worktree")
+			}
+
+			path := worktree.Path(dataDir, sessionID, params.ID)
+			changed, err := worktree.ChangedFiles(ctx, path)
+			if errors.Is(err, worktree.ErrNotFound) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("worktree %s not found, it was already merged or discarded", params.ID)), nil
+			}
+			if err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("error listing worktree changes: %w", err)
+			}
+
+			switch params.Action {
+			case WorktreeActionDiscard:
+				if err := worktree.Remove(ctx, workingDir, path); err != nil {
+					return fantasy.ToolResponse{}, fmt.Errorf("error removing worktree: %w", err)
+				}
+				response := fmt.Sprintf("Discarded the changes to %d files of worktree %s.", len(changed), params.ID)
+				return fantasy.WithResponseMetadata(fantasy.NewTextResponse(response), WorktreeResponseMetadata{
+					Action: params.Action,
+					Files:  changed,
+				}), nil
+			case WorktreeActionMerge:
+			default:
+				return fantasy.NewTextErrorResponse("action must be merge or discard"), nil
+			}
+
+			patch, err := worktree.Diff(ctx, path)
+			if err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("error getting worktree diff: %w", err)
+			}
+			p := permissions.Request(
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        workingDir,
+					ToolCallID:  call.ID,
+					ToolName:    WorktreeToolName,
+					Action:      "write",
+					Description: fmt.Sprintf("Merge the changes to %d files made by a sub-agent", len(changed)),
+					Params:      patch,
+				},
+			)
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			root, err := worktree.Root(ctx, workingDir)
+			if err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("error finding repository root: %w", err)
+			}
+			paths := make([]string, len(changed))
+			before := make([]string, len(changed))
+			for i, name := range changed {
+				paths[i] = filepath.Join(root, name)
+				before[i] = readContent(paths[i])
+			}
+
+			if err := worktree.Apply(ctx, path, workingDir); err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("The changes do not apply to the working directory, nothing was merged: %s", err)), nil
+			}
+
+			// Record the merged files so they can be undone like any other
+			// change of the session.
+			for i, filePath := range paths {
+				recordMergedFile(ctx, files, sessionID, filePath, before[i], readContent(filePath))
+			}
+
+			if err := worktree.Remove(ctx, workingDir, path); err != nil {
+				slog.Error("Error removing merged worktree", "path", path, "error", err)
+			}
+
+			pretty := make([]string, len(paths))
+			for i, filePath := range paths {
+				pretty[i] = fsext.PrettyPath(filePath)
+			}
+			response := fmt.Sprintf("Merged the changes of worktree %s:\n%s", params.ID, strings.Join(pretty, "\n"))
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(response), WorktreeResponseMetadata{
+				Action: params.Action,
+				Files:  changed,
+			}), nil
+		})
+}
+
+// readContent returns the content of a file, or an empty string when it
+// does not exist.
+func readContent(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(content)
+}
+
+func recordMergedFile(ctx context.Context, files history.Service, sessionID, filePath, oldContent, newContent string) {
+	file, err := files.GetByPathAndSession(ctx, filePath, sessionID)
+	if err != nil {
+		if _, err := files.Create(ctx, sessionID, filePath, oldContent); err != nil {
+			slog.Error("Error creating file history", "error", err)
+			return
+		}
+	} else if file.Content != oldContent {
+		// User Manually changed the content store an intermediate version
+		if _, err := files.CreateVersion(ctx, sessionID, filePath, oldContent); err != nil {
+			slog.Error("Error creating file history version", "error", err)
+		}
+	}
+	if _, err := files.CreateVersion(ctx, sessionID, filePath, newContent); err != nil {
+		slog.Error("Error creating file history version", "error", err)
+	}
+	recordFileWrite(filePath)
+}
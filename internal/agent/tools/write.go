@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/diff"
 	"github.com/charmbracelet/crush/internal/filepathext"
@@ -50,7 +51,8 @@ type WriteResponseMetadata struct {
 
 const WriteToolName = "write"
 
-func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string, formatting config.Formatting) fantasy.AgentTool {
+	formatter := newFileFormatter(lspClients, formatting, workingDir)
 	return fantasy.NewAgentTool(
 		WriteToolName,
 		string(writeDescription),
@@ -133,11 +135,12 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error writing file: %w", err)
 			}
+			content, formattedBy := formatter.format(ctx, filePath, params.Content)
 
 			// Check if file exists in history
 			file, err := files.GetByPathAndSession(ctx, filePath, sessionID)
 			if err != nil {
-				_, err = files.Create(ctx, sessionID, filePath, oldContent)
+				file, err = files.Create(ctx, sessionID, filePath, oldContent)
 				if err != nil {
 					// Log error but don't fail the operation
 					return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
@@ -151,7 +154,7 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 				}
 			}
 			// Store the new version
-			_, err = files.CreateVersion(ctx, sessionID, filePath, params.Content)
+			_, err = files.CreateVersion(ctx, sessionID, filePath, content)
 			if err != nil {
 				slog.Error("Error creating file history version", "error", err)
 			}
@@ -162,6 +165,7 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 			notifyLSPs(ctx, lspClients, params.FilePath)
 
 			result := fmt.Sprintf("File successfully written: %s", filePath)
+			result += formattedNotice(filePath, formattedBy)
 			result = fmt.Sprintf("<result>\n%s\n</result>", result)
 			result += getDiagnostics(filePath, lspClients)
 			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(result),
@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/worktree"
+)
+
+//go:embed templates/worktree_agent.md
+var worktreeAgentPrompt string
+
+// maxWorktreeDiffLength is how much of the diff of a worktree is handed back
+// to the parent agent. It can view the files in the worktree for the rest.
+const maxWorktreeDiffLength = 20000
+
+// worktreeAgent creates a worktree for a sub-agent and builds an agent whose
+// tools work in it. It returns the path of the worktree along with the agent.
+func (c *coordinator) worktreeAgent(ctx context.Context, agentCfg config.Agent, sessionID, id string) (string, SessionAgent, error) {
+	path := worktree.Path(c.cfg.Options.DataDirectory, sessionID, id)
+	workingDir, err := worktree.Create(ctx, c.cfg.WorkingDir(), c.cfg.Options.DataDirectory, path)
+	if err != nil {
+		return "", nil, err
+	}
+	agent, err := c.buildWorktreeAgent(ctx, agentCfg, workingDir)
+	if err != nil {
+		c.removeWorktree(path)
+		return "", nil, err
+	}
+	return path, agent, nil
+}
+
+func (c *coordinator) buildWorktreeAgent(ctx context.Context, agentCfg config.Agent, workingDir string) (SessionAgent, error) {
+	prompt, err := agentPrompt(agentCfg, workingDir)
+	if err != nil {
+		return nil, err
+	}
+	large, small, err := c.buildAgentModels(ctx, agentCfg)
+	if err != nil {
+		return nil, err
+	}
+	side, err := c.buildSideModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	systemPrompt, summaryPrompt, titlePrompt, err := buildPrompts(ctx, c.cfg, prompt, large.ModelCfg, small.ModelCfg)
+	if err != nil {
+		return nil, err
+	}
+	// LSP servers only run on the working directory, not on worktrees.
+	agentTools, err := c.buildTools(ctx, agentCfg, workingDir, csync.NewMap[string, *lsp.Client]())
+	if err != nil {
+		return nil, err
+	}
+
+	providerCfg, _ := c.cfg.Providers.Get(large.ModelCfg.Provider)
+	return NewSessionAgent(SessionAgentOptions{
+		LargeModel:           large,
+		SmallModel:           small,
+		SystemPromptPrefix:   providerCfg.SystemPromptPrefix,
+		SystemPrompt:         systemPrompt + "\n\n" + worktreeAgentPrompt,
+		SummaryPrompt:        summaryPrompt,
+		TitlePrompt:          titlePrompt,
+		IsSubAgent:           true,
+		DisableAutoSummarize: c.cfg.Options.DisableAutoSummarize,
+		IsYolo:               c.permissions.SkipRequests(),
+		Sessions:             c.sessions,
+		Messages:             c.messages,
+		Budgets:              *c.cfg.Options.Budgets,
+		Pruning:              *c.cfg.Options.Pruning,
+		Titles:               *c.cfg.Options.Titles,
+		Summaries:            *c.cfg.Options.Summaries,
+		Tools:                agentTools,
+		TitleModel:           side.title,
+		SummaryModel:         side.summary,
+		SummaryOptions:       side.summaryOptions,
+	}), nil
+}
+
+// worktreeResult appends the changes a sub-agent made in its worktree to its
+// response, so the parent agent can review them and merge or discard them
+// with the worktree tool. Worktrees without changes are removed right away.
+func (c *coordinator) worktreeResult(ctx context.Context, path, id, response string) (string, error) {
+	files, err := worktree.ChangedFiles(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		c.removeWorktree(path)
+		return response + "\n\nThe agent did not change any file.", nil
+	}
+	diff, err := worktree.Diff(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	if len(diff) > maxWorktreeDiffLength {
+		diff = diff[:maxWorktreeDiffLength] + fmt.Sprintf("\n... [%d more bytes, view the files in the worktree for the rest]\n", len(diff)-maxWorktreeDiffLength)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(response)
+	fmt.Fprintf(&sb, "\n\n<worktree id=%q path=%q>\nChanged files:\n", id, path)
+	for _, file := range files {
+		fmt.Fprintf(&sb, "- %s\n", file)
+	}
+	fmt.Fprintf(&sb, "\n```diff\n%s```\n</worktree>\n", diff)
+	fmt.Fprintf(&sb, "Use the %s tool to merge these changes into the working directory or discard them.", tools.WorktreeToolName)
+	return sb.String(), nil
+}
+
+func (c *coordinator) removeWorktree(path string) {
+	if err := worktree.Remove(context.Background(), c.cfg.WorkingDir(), path); err != nil {
+		slog.Error("Failed to remove worktree", "path", path, "error", err)
+	}
+}
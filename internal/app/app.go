@@ -5,11 +5,13 @@ package app
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +21,7 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/crush/internal/agent"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/checkpoint"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/db"
@@ -31,6 +34,7 @@ import (
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/symbols"
 	"github.com/charmbracelet/crush/internal/tui/components/anim"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/update"
@@ -44,11 +48,13 @@ type App struct {
 	Sessions    session.Service
 	Messages    message.Service
 	History     history.Service
+	Checkpoints checkpoint.Service
 	Permissions permission.Service
 
 	AgentCoordinator agent.Coordinator
 
 	LSPClients *csync.Map[string, *lsp.Client]
+	Symbols    *symbols.Index
 
 	config *config.Config
 
@@ -65,7 +71,7 @@ type App struct {
 // New initializes a new application instance.
 func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 	q := db.New(conn)
-	sessions := session.NewService(q)
+	sessions := session.NewService(q, conn)
 	messages := message.NewService(q)
 	files := history.NewService(q, conn)
 	skipPermissionsRequests := cfg.Permissions != nil && cfg.Permissions.SkipRequests
@@ -78,6 +84,7 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 		Sessions:    sessions,
 		Messages:    messages,
 		History:     files,
+		Checkpoints: checkpoint.NewService(q, files),
 		Permissions: permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools),
 		LSPClients:  csync.NewMap[string, *lsp.Client](),
 
@@ -90,6 +97,8 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 		tuiWG:           &sync.WaitGroup{},
 	}
 
+	app.Symbols = symbols.NewIndex(app.LSPClients, cfg.WorkingDir(), cfg.LSP)
+
 	app.setupEvents()
 
 	// Initialize LSP clients in the background.
@@ -122,11 +131,26 @@ func (app *App) Config() *config.Config {
 	return app.config
 }
 
+// NonInteractiveOptions controls how a non-interactive run prints its output.
+type NonInteractiveOptions struct {
+	// Quiet hides the spinner.
+	Quiet bool
+	// Format is either [FormatText] or [FormatStreamJSON].
+	Format string
+	// OutputSchema is the JSON schema the final answer must match, if any.
+	OutputSchema *agent.OutputSchema
+}
+
 // RunNonInteractive runs the application in non-interactive mode with the
-// given prompt, printing to stdout.
-func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt string, quiet bool) error {
+// given prompt, printing to stdout. With an output schema, only the final
+// answer is printed, as a JSON document matching the schema. With the
+// stream-json format, everything that happens is printed as JSON events, one
+// per line.
+func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt string, opts NonInteractiveOptions) error {
 	slog.Info("Running in non-interactive mode")
 
+	quiet := opts.Quiet || opts.Format == FormatStreamJSON
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -192,6 +216,20 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt
 	}
 	slog.Info("Created session for non-interactive run", "session_id", sess.ID)
 
+	var (
+		stream           *streamWriter
+		permissionEvents <-chan pubsub.Event[permission.PermissionNotification]
+		sessionEvents    <-chan pubsub.Event[session.Session]
+	)
+	if opts.Format == FormatStreamJSON {
+		stream = newStreamWriter(output, sess.ID)
+		if err := stream.sessionCreated(); err != nil {
+			return err
+		}
+		permissionEvents = app.Permissions.SubscribeNotifications(ctx)
+		sessionEvents = app.Sessions.Subscribe(ctx)
+	}
+
 	// Automatically approve all permission requests for this non-interactive
 	// session.
 	app.Permissions.AutoApproveSession(sess.ID)
@@ -224,7 +262,9 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt
 
 		// Always print a newline at the end. If output is a TTY this will
 		// prevent the prompt from overwriting the last line of output.
-		_, _ = fmt.Fprintln(output)
+		if stream == nil {
+			_, _ = fmt.Fprintln(output)
+		}
 	}()
 
 	for {
@@ -237,17 +277,56 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt
 		select {
 		case result := <-done:
 			stopSpinner()
-			if result.err != nil {
-				if errors.Is(result.err, context.Canceled) || errors.Is(result.err, agent.ErrRequestCancelled) {
-					slog.Info("Non-interactive: agent processing cancelled", "session_id", sess.ID)
-					return nil
+			err := result.err
+			cancelled := errors.Is(err, context.Canceled) || errors.Is(err, agent.ErrRequestCancelled)
+			if err != nil && !cancelled {
+				err = fmt.Errorf("agent processing failed: %w", err)
+			}
+			if err == nil {
+				err = app.checkBudget(ctx, sess.ID)
+			}
+			var doc json.RawMessage
+			if err == nil && opts.OutputSchema != nil {
+				doc, err = app.AgentCoordinator.Output(ctx, sess.ID, opts.OutputSchema)
+			}
+
+			if stream != nil {
+				if streamErr := app.finishStream(context.WithoutCancel(ctx), stream, doc, err); streamErr != nil {
+					return streamErr
+				}
+			} else if doc != nil {
+				if _, writeErr := fmt.Fprintf(output, "%s\n", doc); writeErr != nil {
+					return writeErr
 				}
-				return fmt.Errorf("agent processing failed: %w", result.err)
 			}
-			return nil
+			if cancelled {
+				slog.Info("Non-interactive: agent processing cancelled", "session_id", sess.ID)
+				return nil
+			}
+			return err
+
+		case event := <-permissionEvents:
+			if err := stream.permission(event.Payload); err != nil {
+				return err
+			}
+
+		case event := <-sessionEvents:
+			if err := stream.session(event.Payload); err != nil {
+				return err
+			}
 
 		case event := <-messageEvents:
 			msg := event.Payload
+			if stream != nil {
+				if err := stream.message(msg); err != nil {
+					return err
+				}
+				continue
+			}
+			if opts.OutputSchema != nil {
+				// Only the final document is written.
+				continue
+			}
 			if msg.SessionID == sess.ID && msg.Role == message.Assistant && len(msg.Parts) > 0 {
 				stopSpinner()
 
@@ -276,6 +355,51 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt
 	}
 }
 
+// finishStream writes the events of the run that were not written yet,
+// followed by its result.
+func (app *App) finishStream(ctx context.Context, stream *streamWriter, doc json.RawMessage, runErr error) error {
+	msgs, err := app.Messages.List(ctx, stream.sessionID)
+	if err != nil {
+		return err
+	}
+	var text string
+	for _, msg := range msgs {
+		if err := stream.message(msg); err != nil {
+			return err
+		}
+		if msg.Role == message.Assistant {
+			text = msg.Content().Text
+		}
+	}
+	sess, err := app.Sessions.Get(ctx, stream.sessionID)
+	if err != nil {
+		return err
+	}
+	if err := stream.session(sess); err != nil {
+		return err
+	}
+	return stream.result(text, doc, runErr)
+}
+
+// checkBudget returns [agent.ErrBudgetExceeded] when a budget stopped the
+// last run of the session.
+func (app *App) checkBudget(ctx context.Context, sessionID string) error {
+	msgs, err := app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	for _, msg := range slices.Backward(msgs) {
+		if msg.Role != message.Assistant {
+			continue
+		}
+		if finish := msg.FinishPart(); finish != nil && finish.Reason == message.FinishReasonBudgetExceeded {
+			return fmt.Errorf("%w: %s", agent.ErrBudgetExceeded, finish.Details)
+		}
+		return nil
+	}
+	return nil
+}
+
 func (app *App) UpdateAgentModel(ctx context.Context) error {
 	if app.AgentCoordinator == nil {
 		return fmt.Errorf("agent configuration is missing")
@@ -283,6 +407,15 @@ func (app *App) UpdateAgentModel(ctx context.Context) error {
 	return app.AgentCoordinator.UpdateModels(ctx)
 }
 
+// MainAgent returns the agent the coordinator runs prompts with, or the
+// coder agent while the coordinator is not set up yet.
+func (app *App) MainAgent() config.Agent {
+	if app.AgentCoordinator == nil {
+		return app.config.Agents[config.AgentCoder]
+	}
+	return app.AgentCoordinator.MainAgent()
+}
+
 func (app *App) setupEvents() {
 	ctx, cancel := context.WithCancel(app.globalCtx)
 	app.eventsCtx = ctx
@@ -293,6 +426,8 @@ func (app *App) setupEvents() {
 	setupSubscriber(ctx, app.serviceEventsWG, "history", app.History.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "mcp", mcp.SubscribeEvents, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "lsp", SubscribeLSPEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "fallbacks", agent.SubscribeFallbackEvents, app.events)
+	app.serviceEventsWG.Go(func() { app.removeDeletedWorktrees(ctx) })
 	cleanupFunc := func() error {
 		cancel()
 		app.serviceEventsWG.Wait()
@@ -347,7 +482,9 @@ func (app *App) InitCoderAgent(ctx context.Context) error {
 		app.Messages,
 		app.Permissions,
 		app.History,
+		app.Checkpoints,
 		app.LSPClients,
+		app.Symbols,
 	)
 	if err != nil {
 		slog.Error("Failed to create coder agent", "err", err)
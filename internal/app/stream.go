@@ -0,0 +1,211 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+// Output formats of non-interactive runs.
+const (
+	FormatText       = "text"
+	FormatStreamJSON = "stream-json"
+)
+
+// StreamEventVersion is the version of the events written with the
+// stream-json format. It changes when an event changes in a way that breaks
+// existing readers.
+const StreamEventVersion = 1
+
+type StreamEventType string
+
+const (
+	StreamEventSessionCreated StreamEventType = "session_created"
+	StreamEventTextDelta      StreamEventType = "text_delta"
+	StreamEventReasoningDelta StreamEventType = "reasoning_delta"
+	StreamEventToolCall       StreamEventType = "tool_call"
+	StreamEventToolResult     StreamEventType = "tool_result"
+	StreamEventPermission     StreamEventType = "permission"
+	StreamEventUsage          StreamEventType = "usage"
+	StreamEventResult         StreamEventType = "result"
+)
+
+// StreamEvent is a line of the stream-json output of a non-interactive run.
+type StreamEvent struct {
+	Version    int               `json:"version" jsonschema:"description=Version of the event format"`
+	Type       StreamEventType   `json:"type" jsonschema:"description=Type of the event,enum=session_created,enum=text_delta,enum=reasoning_delta,enum=tool_call,enum=tool_result,enum=permission,enum=usage,enum=result"`
+	SessionID  string            `json:"session_id" jsonschema:"description=ID of the session of the run"`
+	MessageID  string            `json:"message_id,omitempty" jsonschema:"description=ID of the message the text or tool call belongs to"`
+	Text       string            `json:"text,omitempty" jsonschema:"description=Text added to the response or the reasoning of the assistant"`
+	ToolCall   *StreamToolCall   `json:"tool_call,omitempty" jsonschema:"description=Tool call the agent started"`
+	ToolResult *StreamToolResult `json:"tool_result,omitempty" jsonschema:"description=Result of a tool call"`
+	Permission *StreamPermission `json:"permission,omitempty" jsonschema:"description=Decision on a permission request of a tool call: requests granted without asking are reported as granted too"`
+	Usage      *StreamUsage      `json:"usage,omitempty" jsonschema:"description=Token usage and cost of the session so far"`
+	Result     *StreamResult     `json:"result,omitempty" jsonschema:"description=Outcome of the run"`
+}
+
+type StreamToolCall struct {
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input" jsonschema:"type=object,description=Input of the tool call"`
+}
+
+type StreamToolResult struct {
+	ToolCallID string `json:"tool_call_id"`
+	Name       string `json:"name"`
+	Content    string `json:"content"`
+	IsError    bool   `json:"is_error"`
+}
+
+type StreamPermission struct {
+	ToolCallID string `json:"tool_call_id"`
+	Granted    bool   `json:"granted"`
+}
+
+type StreamUsage struct {
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost" jsonschema:"description=Cost of the session in USD"`
+}
+
+type StreamResult struct {
+	Text   string          `json:"text" jsonschema:"description=Final response of the assistant"`
+	Output json.RawMessage `json:"output,omitempty" jsonschema:"description=Final answer matching the output schema when one was given"`
+	Error  string          `json:"error,omitempty" jsonschema:"description=Error that stopped the run"`
+}
+
+// streamWriter turns the message, permission and session events of a run
+// into stream events. Messages are sent in full on every update, so it keeps
+// track of what it already wrote for each of them.
+type streamWriter struct {
+	enc       *json.Encoder
+	sessionID string
+
+	textBytes      map[string]int
+	reasoningBytes map[string]int
+	toolCalls      map[string]bool
+	toolResults    map[string]bool
+	usage          StreamUsage
+}
+
+func newStreamWriter(w io.Writer, sessionID string) *streamWriter {
+	return &streamWriter{
+		enc:            json.NewEncoder(w),
+		sessionID:      sessionID,
+		textBytes:      make(map[string]int),
+		reasoningBytes: make(map[string]int),
+		toolCalls:      make(map[string]bool),
+		toolResults:    make(map[string]bool),
+	}
+}
+
+func (w *streamWriter) write(event StreamEvent) error {
+	event.Version = StreamEventVersion
+	event.SessionID = w.sessionID
+	return w.enc.Encode(event)
+}
+
+func (w *streamWriter) sessionCreated() error {
+	return w.write(StreamEvent{Type: StreamEventSessionCreated})
+}
+
+func (w *streamWriter) message(msg message.Message) error {
+	if msg.SessionID != w.sessionID {
+		return nil
+	}
+	switch msg.Role {
+	case message.Assistant:
+		if reasoning := msg.ReasoningContent().Thinking; len(reasoning) > w.reasoningBytes[msg.ID] {
+			delta := reasoning[w.reasoningBytes[msg.ID]:]
+			w.reasoningBytes[msg.ID] = len(reasoning)
+			if err := w.write(StreamEvent{Type: StreamEventReasoningDelta, MessageID: msg.ID, Text: delta}); err != nil {
+				return err
+			}
+		}
+		if text := msg.Content().Text; len(text) > w.textBytes[msg.ID] {
+			delta := text[w.textBytes[msg.ID]:]
+			w.textBytes[msg.ID] = len(text)
+			if err := w.write(StreamEvent{Type: StreamEventTextDelta, MessageID: msg.ID, Text: delta}); err != nil {
+				return err
+			}
+		}
+		for _, call := range msg.ToolCalls() {
+			if !call.Finished || w.toolCalls[call.ID] {
+				continue
+			}
+			w.toolCalls[call.ID] = true
+			input := json.RawMessage(call.Input)
+			if !json.Valid(input) {
+				input = json.RawMessage("{}")
+			}
+			if err := w.write(StreamEvent{
+				Type:      StreamEventToolCall,
+				MessageID: msg.ID,
+				ToolCall:  &StreamToolCall{ID: call.ID, Name: call.Name, Input: input},
+			}); err != nil {
+				return err
+			}
+		}
+	case message.Tool:
+		for _, result := range msg.ToolResults() {
+			if w.toolResults[result.ToolCallID] {
+				continue
+			}
+			w.toolResults[result.ToolCallID] = true
+			if err := w.write(StreamEvent{
+				Type:      StreamEventToolResult,
+				MessageID: msg.ID,
+				ToolResult: &StreamToolResult{
+					ToolCallID: result.ToolCallID,
+					Name:       result.Name,
+					Content:    result.Content,
+					IsError:    result.IsError,
+				},
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *streamWriter) permission(notification permission.PermissionNotification) error {
+	if notification.SessionID != w.sessionID || !notification.Granted && !notification.Denied {
+		// The request is of another session or still pending.
+		return nil
+	}
+	return w.write(StreamEvent{
+		Type: StreamEventPermission,
+		Permission: &StreamPermission{
+			ToolCallID: notification.ToolCallID,
+			Granted:    notification.Granted,
+		},
+	})
+}
+
+func (w *streamWriter) session(s session.Session) error {
+	usage := StreamUsage{
+		PromptTokens:     s.PromptTokens,
+		CompletionTokens: s.CompletionTokens,
+		Cost:             s.Cost,
+	}
+	if s.ID != w.sessionID || usage == w.usage {
+		return nil
+	}
+	w.usage = usage
+	return w.write(StreamEvent{Type: StreamEventUsage, Usage: &usage})
+}
+
+func (w *streamWriter) result(text string, output json.RawMessage, runErr error) error {
+	result := StreamResult{
+		Text:   text,
+		Output: output,
+	}
+	if runErr != nil {
+		result.Error = runErr.Error()
+	}
+	return w.write(StreamEvent{Type: StreamEventResult, Result: &result})
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamWriter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	w := newStreamWriter(&buf, "session")
+
+	assistant := message.Message{ID: "assistant", SessionID: "session", Role: message.Assistant}
+	require.NoError(t, w.sessionCreated())
+	assistant.Parts = []message.ContentPart{message.TextContent{Text: "Hello"}}
+	require.NoError(t, w.message(assistant))
+	assistant.Parts = []message.ContentPart{
+		message.TextContent{Text: "Hello world"},
+		message.ToolCall{ID: "call", Name: "view", Input: `{"file_path":"main.go"}`},
+	}
+	require.NoError(t, w.message(assistant))
+	assistant.Parts[1] = message.ToolCall{ID: "call", Name: "view", Input: `{"file_path":"main.go"}`, Finished: true}
+	require.NoError(t, w.message(assistant))
+	// Nothing changed, nothing is written.
+	require.NoError(t, w.message(assistant))
+	// Messages of other sessions are skipped.
+	require.NoError(t, w.message(message.Message{ID: "other", SessionID: "other", Role: message.Assistant, Parts: []message.ContentPart{message.TextContent{Text: "Hi"}}}))
+	require.NoError(t, w.permission(permission.PermissionNotification{SessionID: "session", ToolCallID: "call"}))
+	require.NoError(t, w.permission(permission.PermissionNotification{SessionID: "other", ToolCallID: "other", Granted: true}))
+	require.NoError(t, w.permission(permission.PermissionNotification{SessionID: "session", ToolCallID: "call", Granted: true}))
+	require.NoError(t, w.message(message.Message{ID: "tool", SessionID: "session", Role: message.Tool, Parts: []message.ContentPart{message.ToolResult{ToolCallID: "call", Name: "view", Content: "package main"}}}))
+	require.NoError(t, w.session(session.Session{ID: "session", PromptTokens: 10, CompletionTokens: 5, Cost: 0.01}))
+	require.NoError(t, w.session(session.Session{ID: "session", PromptTokens: 10, CompletionTokens: 5, Cost: 0.01}))
+	require.NoError(t, w.result("Hello world", nil, errors.New("boom")))
+
+	var events []StreamEvent
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var event StreamEvent
+		require.NoError(t, dec.Decode(&event))
+		require.Equal(t, StreamEventVersion, event.Version)
+		require.Equal(t, "session", event.SessionID)
+		events = append(events, event)
+	}
+
+	var types []StreamEventType
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	require.Equal(t, []StreamEventType{
+		StreamEventSessionCreated,
+		StreamEventTextDelta,
+		StreamEventTextDelta,
+		StreamEventToolCall,
+		StreamEventPermission,
+		StreamEventToolResult,
+		StreamEventUsage,
+		StreamEventResult,
+	}, types)
+	require.Equal(t, " world", events[2].Text)
+	require.JSONEq(t, `{"file_path":"main.go"}`, string(events[3].ToolCall.Input))
+	require.True(t, events[4].Permission.Granted)
+	require.Equal(t, "package main", events[5].ToolResult.Content)
+	require.Equal(t, "boom", events[7].Result.Error)
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/worktree"
+)
+
+// removeDeletedWorktrees removes the worktrees sub-agents of a session
+// worked in once the session is deleted.
+func (app *App) removeDeletedWorktrees(ctx context.Context) {
+	events := app.Sessions.Subscribe(ctx)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if event.Type != pubsub.DeletedEvent {
+				continue
+			}
+			err := worktree.RemoveSession(ctx, app.config.WorkingDir(), app.config.Options.DataDirectory, event.Payload.ID)
+			if err != nil {
+				slog.Error("Failed to remove session worktrees", "session", event.Payload.ID, "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/google/uuid"
+)
+
+// ErrNoChanges is returned when there are no file changes to undo.
+var ErrNoChanges = errors.New("no file changes to undo")
+
+// Checkpoint marks the start of a user turn. Restoring it puts the files
+// the session changed since then back to their recorded content.
+type Checkpoint struct {
+	ID        string
+	SessionID string
+	MessageID string
+	// Number is the position of the checkpoint in its session, starting at 1.
+	Number    int
+	CreatedAt int64
+	// FileVersions are the versions the files of the session had when the
+	// checkpoint was recorded.
+	FileVersions history.Versions
+}
+
+// Result is the outcome of restoring a checkpoint.
+type Result struct {
+	Checkpoint Checkpoint
+	Reverted   []history.Change
+	// Conflicts are files modified outside of the session after the agent
+	// wrote them. They are left untouched.
+	Conflicts []history.Change
+}
+
+type Service interface {
+	// Create records a checkpoint for the user message that starts a turn.
+	Create(ctx context.Context, sessionID, messageID string, createdAt int64) (Checkpoint, error)
+	List(ctx context.Context, sessionID string) ([]Checkpoint, error)
+	Get(ctx context.Context, sessionID string, number int) (Checkpoint, error)
+	// GetByMessage returns the checkpoint recorded for the given user message.
+	GetByMessage(ctx context.Context, sessionID, messageID string) (Checkpoint, error)
+	// Changes lists the files changed since the checkpoint.
+	Changes(ctx context.Context, checkpoint Checkpoint) ([]history.Change, error)
+	// Restore reverts the files changed since the checkpoint and drops it,
+	// along with every later checkpoint. When some files are left untouched
+	// because of conflicts the checkpoints are kept, so that the restore can
+	// be tried again.
+	Restore(ctx context.Context, checkpoint Checkpoint) (Result, error)
+	// Undo restores the latest checkpoint that has file changes.
+	Undo(ctx context.Context, sessionID string) (Result, error)
+	// Discard drops the checkpoints recorded for the given user messages,
+	// once the turns they start are rewound.
+	Discard(ctx context.Context, sessionID string, messageIDs ...string) error
+}
+
+type service struct {
+	q       db.Querier
+	history history.Service
+}
+
+func NewService(q db.Querier, history history.Service) Service {
+	return &service{
+		q:       q,
+		history: history,
+	}
+}
+
+func (s *service) Create(ctx context.Context, sessionID, messageID string, createdAt int64) (Checkpoint, error) {
+	versions, err := s.history.Versions(ctx, sessionID)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	versionsJSON, err := json.Marshal(versions)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	dbCheckpoint, err := s.q.CreateCheckpoint(ctx, db.CreateCheckpointParams{
+		ID:           uuid.New().String(),
+		SessionID:    sessionID,
+		MessageID:    messageID,
+		CreatedAt:    createdAt,
+		FileVersions: string(versionsJSON),
+	})
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	checkpoints, err := s.List(ctx, sessionID)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	for _, checkpoint := range checkpoints {
+		if checkpoint.ID == dbCheckpoint.ID {
+			return checkpoint, nil
+		}
+	}
+	return Checkpoint{}, fmt.Errorf("checkpoint %s not found", dbCheckpoint.ID)
+}
+
+func (s *service) List(ctx context.Context, sessionID string) ([]Checkpoint, error) {
+	dbCheckpoints, err := s.q.ListCheckpointsBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := make([]Checkpoint, len(dbCheckpoints))
+	for i, item := range dbCheckpoints {
+		var versions history.Versions
+		if err := json.Unmarshal([]byte(item.FileVersions), &versions); err != nil {
+			return nil, fmt.Errorf("failed to read file versions of checkpoint %s: %w", item.ID, err)
+		}
+		checkpoints[i] = Checkpoint{
+			ID:           item.ID,
+			SessionID:    item.SessionID,
+			MessageID:    item.MessageID,
+			Number:       i + 1,
+			CreatedAt:    item.CreatedAt,
+			FileVersions: versions,
+		}
+	}
+	return checkpoints, nil
+}
+
+func (s *service) Get(ctx context.Context, sessionID string, number int) (Checkpoint, error) {
+	checkpoints, err := s.List(ctx, sessionID)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	if number < 1 || number > len(checkpoints) {
+		return Checkpoint{}, fmt.Errorf("checkpoint %d not found, the session has %d", number, len(checkpoints))
+	}
+	return checkpoints[number-1], nil
+}
+
+func (s *service) GetByMessage(ctx context.Context, sessionID, messageID string) (Checkpoint, error) {
+	checkpoints, err := s.List(ctx, sessionID)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	for _, checkpoint := range checkpoints {
+		if checkpoint.MessageID == messageID {
+			return checkpoint, nil
+		}
+	}
+	return Checkpoint{}, fmt.Errorf("no checkpoint recorded for message %s", messageID)
+}
+
+func (s *service) Changes(ctx context.Context, checkpoint Checkpoint) ([]history.Change, error) {
+	return s.history.Changes(ctx, checkpoint.SessionID, checkpoint.FileVersions)
+}
+
+func (s *service) Restore(ctx context.Context, checkpoint Checkpoint) (Result, error) {
+	changes, err := s.Changes(ctx, checkpoint)
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{Checkpoint: checkpoint}
+	for _, change := range changes {
+		if change.Conflict() {
+			result.Conflicts = append(result.Conflicts, change)
+			continue
+		}
+		result.Reverted = append(result.Reverted, change)
+	}
+	if _, err := s.history.Revert(ctx, checkpoint.SessionID, result.Reverted...); err != nil {
+		return Result{}, err
+	}
+	if len(result.Conflicts) > 0 {
+		return result, nil
+	}
+
+	checkpoints, err := s.List(ctx, checkpoint.SessionID)
+	if err != nil {
+		return Result{}, err
+	}
+	idx := slices.IndexFunc(checkpoints, func(c Checkpoint) bool {
+		return c.ID == checkpoint.ID
+	})
+	if idx == -1 {
+		return result, nil
+	}
+	for _, later := range checkpoints[idx:] {
+		if err := s.q.DeleteCheckpoint(ctx, later.ID); err != nil {
+			return Result{}, err
+		}
+	}
+	return result, nil
+}
+
+func (s *service) Undo(ctx context.Context, sessionID string) (Result, error) {
+	checkpoints, err := s.List(ctx, sessionID)
+	if err != nil {
+		return Result{}, err
+	}
+	// Turns that did not touch any file have nothing to undo, so walk back
+	// to the latest one that did.
+	for i := len(checkpoints) - 1; i >= 0; i-- {
+		changes, err := s.Changes(ctx, checkpoints[i])
+		if err != nil {
+			return Result{}, err
+		}
+		if len(changes) > 0 {
+			return s.Restore(ctx, checkpoints[i])
+		}
+	}
+	return Result{}, ErrNoChanges
+}
+
+func (s *service) Discard(ctx context.Context, sessionID string, messageIDs ...string) error {
+	checkpoints, err := s.List(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	for _, checkpoint := range checkpoints {
+		if !slices.Contains(messageIDs, checkpoint.MessageID) {
+			continue
+		}
+		if err := s.q.DeleteCheckpoint(ctx, checkpoint.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
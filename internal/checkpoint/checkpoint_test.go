@@ -0,0 +1,118 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUndo(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	files := history.NewService(q, conn)
+	checkpoints := NewService(q, files)
+	sess, err := session.NewService(q, conn).Create(t.Context(), "Session")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	write := func(path, content string) {
+		if _, err := files.GetByPathAndSession(t.Context(), path, sess.ID); err != nil {
+			_, err := files.Create(t.Context(), sess.ID, path, "")
+			require.NoError(t, err)
+		}
+		_, err := files.CreateVersion(t.Context(), sess.ID, path, content)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	// Turns happen within the same second.
+	now := time.Now().Unix()
+
+	first, err := checkpoints.Create(t.Context(), sess.ID, uuid.NewString(), now)
+	require.NoError(t, err)
+	require.Equal(t, 1, first.Number)
+
+	// The first turn edits a file.
+	edited := filepath.Join(dir, "edited.go")
+	_, err = files.Create(t.Context(), sess.ID, edited, "original")
+	require.NoError(t, err)
+	write(edited, "first turn")
+
+	second, err := checkpoints.Create(t.Context(), sess.ID, uuid.NewString(), now)
+	require.NoError(t, err)
+
+	// The second turn edits it again and creates another one.
+	write(edited, "second turn")
+	created := filepath.Join(dir, "created.go")
+	write(created, "new file")
+
+	// The third turn does not touch any file.
+	third, err := checkpoints.Create(t.Context(), sess.ID, uuid.NewString(), now)
+	require.NoError(t, err)
+	require.Equal(t, 3, third.Number)
+
+	found, err := checkpoints.GetByMessage(t.Context(), sess.ID, second.MessageID)
+	require.NoError(t, err)
+	require.Equal(t, second.ID, found.ID)
+
+	result, err := checkpoints.Undo(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Checkpoint.Number)
+	require.Len(t, result.Reverted, 2)
+	require.Empty(t, result.Conflicts)
+
+	content, err := os.ReadFile(edited)
+	require.NoError(t, err)
+	require.Equal(t, "first turn", string(content))
+	_, err = os.Stat(created)
+	require.True(t, os.IsNotExist(err))
+
+	list, err := checkpoints.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	// Someone else changes the file, so restoring the first checkpoint
+	// must not clobber it, and the checkpoint is kept to try again.
+	require.NoError(t, os.WriteFile(edited, []byte("external"), 0o644))
+	result, err = checkpoints.Restore(t.Context(), first)
+	require.NoError(t, err)
+	require.Empty(t, result.Reverted)
+	require.Len(t, result.Conflicts, 1)
+	require.Equal(t, edited, result.Conflicts[0].Path)
+
+	content, err = os.ReadFile(edited)
+	require.NoError(t, err)
+	require.Equal(t, "external", string(content))
+
+	list, err = checkpoints.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	require.NoError(t, os.WriteFile(edited, []byte("first turn"), 0o644))
+	result, err = checkpoints.Undo(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Checkpoint.Number)
+	require.Len(t, result.Reverted, 1)
+
+	content, err = os.ReadFile(edited)
+	require.NoError(t, err)
+	require.Equal(t, "original", string(content))
+
+	list, err = checkpoints.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+	_, err = checkpoints.Undo(t.Context(), sess.ID)
+	require.ErrorIs(t, err, ErrNoChanges)
+}
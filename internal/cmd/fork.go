@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var forkCmd = &cobra.Command{
+	Use:   "fork <session-id>",
+	Short: "Fork a session",
+	Long: `Create a new session branched off an existing one.
+The new session gets a copy of the messages up to the chosen message, along
+with the file history as it was at that point. The original session is left
+untouched.`,
+	Example: `
+# Fork a session from its latest message
+crush fork 6f1c1e2a-0b9e-4d3c-9a52-3b1f0d3c2e11
+
+# Fork a session from a specific message
+crush fork 6f1c1e2a-0b9e-4d3c-9a52-3b1f0d3c2e11 --message 0d3c2e11-9a52-4d3c-0b9e-6f1c1e2a3b1f
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		messageID, _ := cmd.Flags().GetString("message")
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		ctx := cmd.Context()
+
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load(cwd, dataDir, false)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %v", err)
+		}
+
+		conn, err := db.Connect(ctx, cfg.Options.DataDirectory)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		q := db.New(conn)
+		sessions := session.NewService(q, conn)
+
+		sessionID := args[0]
+		if messageID == "" {
+			msgs, err := message.NewService(q).List(ctx, sessionID)
+			if err != nil {
+				return err
+			}
+			if len(msgs) == 0 {
+				return fmt.Errorf("session %s has no messages to fork from", sessionID)
+			}
+			messageID = msgs[len(msgs)-1].ID
+		}
+
+		fork, err := sessions.Fork(ctx, sessionID, messageID)
+		if err != nil {
+			return err
+		}
+		cmd.Println(fork.ID)
+		return nil
+	},
+}
+
+func init() {
+	forkCmd.Flags().StringP("message", "m", "", "Message to fork from (defaults to the latest message)")
+}
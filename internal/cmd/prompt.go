@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Inspect the prompts Crush sends",
+}
+
+var promptRenderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Print the final system prompt",
+	Long: `Print the system prompt an agent runs with, rendered for the models selected
+in the configuration and with the prompt template overrides applied.
+Overrides are read from <name>.md.tpl files in the prompts directory of the
+global configuration and of the project, and from the prompts configuration.`,
+	Example: `
+# Print the system prompt of the coder agent
+crush prompt render
+
+# Print the system prompt of a custom agent
+crush prompt render --agent reviewer
+
+# Print the prompt used to summarize sessions
+crush prompt render --template summary
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		agentID, _ := cmd.Flags().GetString("agent")
+		tmpl, _ := cmd.Flags().GetString("template")
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load(cwd, dataDir, false)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %v", err)
+		}
+
+		system, summary, title, err := agent.RenderPrompts(cmd.Context(), cfg, agentID)
+		if err != nil {
+			return err
+		}
+		switch tmpl {
+		case "system":
+			fmt.Println(system)
+		case "summary":
+			fmt.Println(summary)
+		case "title":
+			fmt.Println(title)
+		default:
+			return fmt.Errorf("unknown template %q, expected system, summary or title", tmpl)
+		}
+		return nil
+	},
+}
+
+func init() {
+	promptRenderCmd.Flags().StringP("agent", "a", config.AgentCoder, "Agent whose prompts to render")
+	promptRenderCmd.Flags().StringP("template", "t", "system", "Prompt to render: system, summary or title")
+	promptCmd.AddCommand(promptRenderCmd)
+}
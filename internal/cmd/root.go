@@ -15,6 +15,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/colorprofile"
+	"github.com/charmbracelet/crush/internal/agent"
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/db"
@@ -46,6 +47,9 @@ func init() {
 		logsCmd,
 		schemaCmd,
 		loginCmd,
+		forkCmd,
+		undoCmd,
+		promptCmd,
 	)
 }
 
@@ -149,6 +153,12 @@ func Execute() {
 		fang.WithVersion(version.Version),
 		fang.WithNotifySignal(os.Interrupt),
 	); err != nil {
+		if errors.Is(err, agent.ErrBudgetExceeded) {
+			os.Exit(ExitCodeBudgetExceeded)
+		}
+		if errors.Is(err, agent.ErrInvalidOutput) {
+			os.Exit(ExitCodeInvalidOutput)
+		}
 		os.Exit(1)
 	}
 }
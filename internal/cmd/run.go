@@ -8,15 +8,30 @@ import (
 	"os/signal"
 	"strings"
 
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/event"
 	"github.com/spf13/cobra"
 )
 
+// ExitCodeBudgetExceeded is the exit code used when one of the configured
+// budgets stopped the agent.
+const ExitCodeBudgetExceeded = 3
+
+// ExitCodeInvalidOutput is the exit code used when the answer of the agent
+// does not match the schema given with --output-schema.
+const ExitCodeInvalidOutput = 4
+
 var runCmd = &cobra.Command{
 	Use:   "run [prompt...]",
 	Short: "Run a single non-interactive prompt",
 	Long: `Run a single prompt in non-interactive mode and exit.
-The prompt can be provided as arguments or piped from stdin.`,
+The prompt can be provided as arguments or piped from stdin.
+When one of the configured budgets stops the agent, crush exits with code 3.
+With --output-schema, only the final answer is printed, as a JSON document
+matching the schema. Crush exits with code 4 when the agent cannot produce one.
+With --format stream-json, the run is printed as JSON events, one per line.
+Their schema is printed by 'crush schema --events'.`,
 	Example: `
 # Run a simple prompt
 crush run Explain the use of context in Go
@@ -29,9 +44,41 @@ crush run "What is this code doing?" <<< prrr.go
 
 # Run in quiet mode (hide the spinner)
 crush run --quiet "Generate a README for this project"
+
+# Run with an agent declared in crush.json
+crush run --agent reviewer "Review the staged changes"
+
+# Get the answer as JSON matching a schema
+crush run --output-schema schema.json "List the TODOs of this project"
+
+# Print everything that happens as JSON events, one per line
+crush run --format stream-json "Fix the failing tests"
   `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		quiet, _ := cmd.Flags().GetBool("quiet")
+		agentID, _ := cmd.Flags().GetString("agent")
+		outputSchemaPath, _ := cmd.Flags().GetString("output-schema")
+		outputFormat, _ := cmd.Flags().GetString("format")
+		if outputFormat != app.FormatText && outputFormat != app.FormatStreamJSON {
+			return fmt.Errorf("invalid format %q, must be %s or %s", outputFormat, app.FormatText, app.FormatStreamJSON)
+		}
+
+		var outputSchema *agent.OutputSchema
+		if outputSchemaPath != "" {
+			data, err := os.ReadFile(outputSchemaPath)
+			if err != nil {
+				return fmt.Errorf("failed to read output schema: %w", err)
+			}
+			outputSchema, err = agent.NewOutputSchema(data)
+			if err != nil {
+				return err
+			}
+		}
+		opts := app.NonInteractiveOptions{
+			Quiet:        quiet,
+			Format:       outputFormat,
+			OutputSchema: outputSchema,
+		}
 
 		// Cancel on SIGINT or SIGTERM.
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
@@ -47,6 +94,12 @@ crush run --quiet "Generate a README for this project"
 			return fmt.Errorf("no providers configured - please run 'crush' to set up a provider interactively")
 		}
 
+		if agentID != "" {
+			if err := app.AgentCoordinator.SetMainAgent(ctx, agentID); err != nil {
+				return err
+			}
+		}
+
 		prompt := strings.Join(args, " ")
 
 		prompt, err = MaybePrependStdin(prompt)
@@ -62,7 +115,7 @@ crush run --quiet "Generate a README for this project"
 		event.SetInteractive(true)
 		event.AppInitialized()
 
-		return app.RunNonInteractive(ctx, os.Stdout, prompt, quiet)
+		return app.RunNonInteractive(ctx, os.Stdout, prompt, opts)
 	},
 	PostRun: func(cmd *cobra.Command, args []string) {
 		event.AppExited()
@@ -71,4 +124,7 @@ crush run --quiet "Generate a README for this project"
 
 func init() {
 	runCmd.Flags().BoolP("quiet", "q", false, "Hide spinner")
+	runCmd.Flags().StringP("agent", "a", "", "Agent to run the prompt with")
+	runCmd.Flags().String("output-schema", "", "JSON schema file the final answer must match, printed as JSON")
+	runCmd.Flags().StringP("format", "f", app.FormatText, "Output format: text or stream-json")
 }
@@ -4,19 +4,28 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/invopop/jsonschema"
 	"github.com/spf13/cobra"
 )
 
 var schemaCmd = &cobra.Command{
-	Use:    "schema",
-	Short:  "Generate JSON schema for configuration",
-	Long:   "Generate JSON schema for the crush configuration file",
+	Use:   "schema",
+	Short: "Generate JSON schema for configuration",
+	Long: `Generate JSON schema for the crush configuration file.
+With --events, generate the JSON schema of the events printed by
+'crush run --format stream-json' instead.`,
 	Hidden: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		events, _ := cmd.Flags().GetBool("events")
+
+		var v any = &config.Config{}
+		if events {
+			v = &app.StreamEvent{}
+		}
 		reflector := new(jsonschema.Reflector)
-		bts, err := json.MarshalIndent(reflector.Reflect(&config.Config{}), "", "  ")
+		bts, err := json.MarshalIndent(reflector.Reflect(v), "", "  ")
 		if err != nil {
 			return fmt.Errorf("failed to marshal schema: %w", err)
 		}
@@ -24,3 +33,7 @@ var schemaCmd = &cobra.Command{
 		return nil
 	},
 }
+
+func init() {
+	schemaCmd.Flags().Bool("events", false, "Generate the schema of the stream-json events of crush run")
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/checkpoint"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo file changes made by the agent",
+	Long: `Revert the files changed by a session since a checkpoint.
+A checkpoint is recorded at the start of every user turn. By default the
+changes of the latest turn that modified files are reverted. Files created by
+the agent are deleted, and files modified outside of crush after the agent
+wrote them are reported as conflicts and left untouched.`,
+	Example: `
+# Undo the file changes of the latest turn
+crush undo --session 6f1c1e2a-0b9e-4d3c-9a52-3b1f0d3c2e11
+
+# List the checkpoints of a session
+crush undo --session 6f1c1e2a-0b9e-4d3c-9a52-3b1f0d3c2e11 --list
+
+# Restore the files to checkpoint 3
+crush undo --session 6f1c1e2a-0b9e-4d3c-9a52-3b1f0d3c2e11 --checkpoint 3
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sessionID, _ := cmd.Flags().GetString("session")
+		number, _ := cmd.Flags().GetInt("checkpoint")
+		list, _ := cmd.Flags().GetBool("list")
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		ctx := cmd.Context()
+
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load(cwd, dataDir, false)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %v", err)
+		}
+
+		conn, err := db.Connect(ctx, cfg.Options.DataDirectory)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		q := db.New(conn)
+		checkpoints := checkpoint.NewService(q, history.NewService(q, conn))
+
+		if list {
+			items, err := checkpoints.List(ctx, sessionID)
+			if err != nil {
+				return err
+			}
+			for _, item := range items {
+				changes, err := checkpoints.Changes(ctx, item)
+				if err != nil {
+					return err
+				}
+				cmd.Printf("#%d\t%s\t%d files changed since\n", item.Number, time.Unix(item.CreatedAt, 0).Local().Format(time.DateTime), len(changes))
+			}
+			return nil
+		}
+
+		var result checkpoint.Result
+		if number > 0 {
+			item, err := checkpoints.Get(ctx, sessionID, number)
+			if err != nil {
+				return err
+			}
+			result, err = checkpoints.Restore(ctx, item)
+			if err != nil {
+				return err
+			}
+		} else {
+			result, err = checkpoints.Undo(ctx, sessionID)
+			if errors.Is(err, checkpoint.ErrNoChanges) {
+				cmd.Println("No file changes to undo")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		cmd.Printf("Restored checkpoint #%d\n", result.Checkpoint.Number)
+		for _, change := range result.Reverted {
+			action := "reverted"
+			if change.Created() {
+				action = "deleted"
+			}
+			cmd.Printf("  %s %s\n", action, fsext.PrettyPath(change.Path))
+		}
+		for _, change := range result.Conflicts {
+			cmd.Printf("  skipped %s (changed outside of crush)\n", fsext.PrettyPath(change.Path))
+		}
+		if len(result.Conflicts) > 0 {
+			return fmt.Errorf("%d files were changed outside of crush and were left untouched", len(result.Conflicts))
+		}
+		return nil
+	},
+}
+
+func init() {
+	undoCmd.Flags().StringP("session", "s", "", "Session to undo changes in")
+	undoCmd.Flags().IntP("checkpoint", "n", 0, "Checkpoint to restore (defaults to the latest one with file changes)")
+	undoCmd.Flags().BoolP("list", "l", false, "List the checkpoints of the session")
+	_ = undoCmd.MarkFlagRequired("session")
+}
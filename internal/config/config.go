@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
@@ -18,6 +19,7 @@ import (
 	hyperp "github.com/charmbracelet/crush/internal/agent/hyper"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/env"
+	"github.com/charmbracelet/crush/internal/home"
 	"github.com/charmbracelet/crush/internal/oauth"
 	"github.com/charmbracelet/crush/internal/oauth/claude"
 	"github.com/charmbracelet/crush/internal/oauth/copilot"
@@ -31,6 +33,7 @@ const (
 	appName              = "crush"
 	defaultDataDirectory = ".crush"
 	defaultInitializeAs  = "AGENTS.md"
+	defaultMemoryTokens  = 2000
 )
 
 var defaultContextPaths = []string{
@@ -62,6 +65,7 @@ const (
 const (
 	AgentCoder string = "coder"
 	AgentTask  string = "task"
+	AgentFetch string = "fetch"
 )
 
 type SelectedModel struct {
@@ -88,6 +92,10 @@ type SelectedModel struct {
 
 	// Override provider specific options.
 	ProviderOptions map[string]any `json:"provider_options,omitempty" jsonschema:"description=Additional provider-specific options for the model"`
+
+	// Models to switch to, in order, when the provider keeps failing with
+	// rate limit, overload or server errors.
+	Fallbacks []SelectedModel `json:"fallbacks,omitempty" jsonschema:"description=Models to switch to in order when the provider keeps failing with rate limit or server errors"`
 }
 
 type ProviderConfig struct {
@@ -267,6 +275,61 @@ type Options struct {
 	Attribution               *Attribution `json:"attribution,omitempty" jsonschema:"description=Attribution settings for generated content"`
 	DisableMetrics            bool         `json:"disable_metrics,omitempty" jsonschema:"description=Disable sending metrics,default=false"`
 	InitializeAs              string       `json:"initialize_as,omitempty" jsonschema:"description=Name of the context file to create/update during project initialization,default=AGENTS.md,example=AGENTS.md,example=CRUSH.md,example=CLAUDE.md,example=docs/LLMs.md"`
+	Budgets                   *Budgets     `json:"budgets,omitempty" jsonschema:"description=Limits that stop the agent before it spends too much"`
+	Pruning                   *Pruning     `json:"pruning,omitempty" jsonschema:"description=Elide old tool results from the context sent to the model"`
+	SubAgentWorktrees         bool         `json:"sub_agent_worktrees,omitempty" jsonschema:"description=Run each sub-agent in its own git worktree where it can edit files and run commands,default=false"`
+	MemoryTokens              *int         `json:"memory_tokens,omitempty" jsonschema:"description=Maximum number of tokens of saved project memories added to the system prompt; 0 adds none,default=2000,minimum=0,example=2000"`
+	Titles                    *Titles      `json:"titles,omitempty" jsonschema:"description=Generation of session titles"`
+	Summaries                 *Summaries   `json:"summaries,omitempty" jsonschema:"description=Summarization of long conversations"`
+	Formatting                *Formatting  `json:"formatting,omitempty" jsonschema:"description=Formatting of the files changed by the agent"`
+}
+
+// Formatting configures the formatting of the files the edit, multiedit and
+// write tools change.
+type Formatting struct {
+	OnWrite    bool        `json:"on_write,omitempty" jsonschema:"description=Format the files changed by the edit multiedit and write tools,default=false"`
+	DisableLSP bool        `json:"disable_lsp,omitempty" jsonschema:"description=Only use the configured formatters even for files an LSP server can format,default=false"`
+	Formatters []Formatter `json:"formatters,omitempty" jsonschema:"description=Formatter commands for the files no LSP server formats"`
+}
+
+// Formatter is a command formatting files of some types in place.
+type Formatter struct {
+	FileTypes []string `json:"filetypes" jsonschema:"required,description=File types this formatter handles,example=go,example=ts,example=tsx"`
+	Command   string   `json:"command" jsonschema:"required,description=Command formatting a file in place; the path of the file is appended to it,example=gofumpt -w,example=prettier --write"`
+}
+
+// Titles configures how sessions get their title.
+type Titles struct {
+	Disabled bool           `json:"disabled,omitempty" jsonschema:"description=Do not send the first prompt of a session to a model to generate its title,default=false"`
+	Model    *SelectedModel `json:"model,omitempty" jsonschema:"description=Model generating session titles instead of the small model"`
+}
+
+// Summaries configures how conversations are summarized once they get too
+// long, or when asked to.
+type Summaries struct {
+	Model        *SelectedModel `json:"model,omitempty" jsonschema:"description=Model writing summaries instead of the model of the agent"`
+	Instructions string         `json:"instructions,omitempty" jsonschema:"description=Additional instructions for the summary such as what to keep from the conversation,example=Keep the exact commands used to run the tests."`
+	Threshold    float64        `json:"threshold,omitempty" jsonschema:"description=Fraction of the context window in use at which the conversation is summarized; by default it is summarized when 20% or 20000 tokens of the context window are left,minimum=0,maximum=1,example=0.8"`
+	Review       bool           `json:"review,omitempty" jsonschema:"description=Stop after summarizing a long conversation instead of going on with the interrupted task so the summary can be reviewed and edited first,default=false"`
+}
+
+// Budgets limit how much the agent can spend. A zero value means no limit.
+type Budgets struct {
+	MaxSessionCost float64 `json:"max_session_cost,omitempty" jsonschema:"description=Maximum cost in USD of a session after which the agent stops,minimum=0,example=5"`
+	MaxRunTokens   int64   `json:"max_run_tokens,omitempty" jsonschema:"description=Maximum number of tokens a single prompt can use,minimum=0,example=2000000"`
+	MaxRunSteps    int     `json:"max_run_steps,omitempty" jsonschema:"description=Maximum number of steps the agent can take for a single prompt,minimum=0,example=100"`
+}
+
+// Pruning replaces the output of old or large tool results with a short stub
+// in the context sent to the model. A zero value disables that rule.
+type Pruning struct {
+	KeepTurns       int `json:"keep_turns,omitempty" jsonschema:"description=Number of recent turns whose tool results are sent in full; older ones are elided,minimum=0,example=3"`
+	MaxResultTokens int `json:"max_result_tokens,omitempty" jsonschema:"description=Tool results of earlier turns larger than this number of tokens are elided,minimum=0,example=2000"`
+}
+
+// Enabled reports whether any pruning rule is set.
+func (p Pruning) Enabled() bool {
+	return p.KeepTurns > 0 || p.MaxResultTokens > 0
 }
 
 type MCPs map[string]MCPConfig
@@ -333,30 +396,59 @@ func (m MCPConfig) ResolvedHeaders() map[string]string {
 }
 
 type Agent struct {
-	ID          string `json:"id,omitempty"`
-	Name        string `json:"name,omitempty"`
-	Description string `json:"description,omitempty"`
+	ID          string `json:"id,omitempty" jsonschema:"description=Unique identifier of the agent; defaults to its key in the agents map"`
+	Name        string `json:"name,omitempty" jsonschema:"description=Display name of the agent,example=Reviewer"`
+	Description string `json:"description,omitempty" jsonschema:"description=Short description of what the agent does"`
 	// This is the id of the system prompt used by the agent
-	Disabled bool `json:"disabled,omitempty"`
+	Disabled bool `json:"disabled,omitempty" jsonschema:"description=Disable this agent,default=false"`
 
-	Model SelectedModelType `json:"model" jsonschema:"required,description=The model type to use for this agent,enum=large,enum=small,default=large"`
+	Model SelectedModelType `json:"model,omitempty" jsonschema:"description=The model type to use for this agent,enum=large,enum=small,default=large"`
+
+	// Explicit model for this agent, takes precedence over Model when set.
+	ModelConfig *SelectedModel `json:"model_config,omitempty" jsonschema:"description=Explicit provider and model to use for this agent instead of the model type"`
+
+	// Inline system prompt template, takes precedence over PromptFile.
+	Prompt string `json:"prompt,omitempty" jsonschema:"description=Inline system prompt template for the agent"`
+	// Path to a file containing the system prompt template, relative to the
+	// working directory.
+	PromptFile string `json:"prompt_file,omitempty" jsonschema:"description=Path to a file containing the system prompt template for the agent,example=.crush/agents/reviewer.md.tpl"`
 
 	// The available tools for the agent
 	//  if this is nil, all tools are available
-	AllowedTools []string `json:"allowed_tools,omitempty"`
+	AllowedTools []string `json:"allowed_tools,omitempty" jsonschema:"description=Built-in tools available to the agent; all tools are available if omitted,example=view,example=grep"`
 
 	// this tells us which MCPs are available for this agent
 	//  if this is empty all mcps are available
 	//  the string array is the list of tools from the AllowedMCP the agent has available
 	//  if the string array is nil, all tools from the AllowedMCP are available
-	AllowedMCP map[string][]string `json:"allowed_mcp,omitempty"`
+	AllowedMCP map[string][]string `json:"allowed_mcp,omitempty" jsonschema:"description=MCP servers and their tools available to the agent; all MCPs are available if omitted"`
 
 	// Overrides the context paths for this agent
-	ContextPaths []string `json:"context_paths,omitempty"`
+	ContextPaths []string `json:"context_paths,omitempty" jsonschema:"description=Paths to files containing context information for this agent,example=AGENTS.md"`
 }
 
 type Tools struct {
-	Ls ToolLs `json:"ls,omitzero"`
+	Ls     ToolLs       `json:"ls,omitzero"`
+	Custom []CustomTool `json:"custom,omitempty" jsonschema:"description=Tools that run a shell command with the parameters given by the model"`
+}
+
+type CustomToolPermission string
+
+const (
+	CustomToolPermissionAsk   CustomToolPermission = "ask"
+	CustomToolPermissionAllow CustomToolPermission = "allow"
+)
+
+// CustomTool is a tool declared in the config that runs a shell command.
+// The parameters are passed as JSON on the standard input and as
+// CRUSH_PARAM_<NAME> environment variables.
+type CustomTool struct {
+	Name        string               `json:"name" jsonschema:"required,description=Name of the tool as seen by the model,example=run_tests"`
+	Description string               `json:"description" jsonschema:"required,description=Description of what the tool does and when to use it"`
+	InputSchema map[string]any       `json:"input_schema,omitempty" jsonschema:"description=JSON schema of the tool parameters"`
+	Command     string               `json:"command" jsonschema:"required,description=Shell command to run,example=go test $CRUSH_PARAM_PACKAGE"`
+	Permission  CustomToolPermission `json:"permission,omitempty" jsonschema:"description=Whether to ask for permission before running the tool,enum=ask,enum=allow,default=ask"`
+	Timeout     int                  `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds for the command,default=120,example=30"`
 }
 
 type ToolLs struct {
@@ -368,6 +460,67 @@ func (t ToolLs) Limits() (depth, items int) {
 	return ptrValOr(t.MaxDepth, 0), ptrValOr(t.MaxItems, 0)
 }
 
+// Hooks are commands run at defined points of an agent run. Each hook gets
+// the event as JSON on its standard input.
+type Hooks struct {
+	PreToolUse       []Hook `json:"pre_tool_use,omitempty" jsonschema:"description=Hooks run before a tool call that can approve or deny it or rewrite its input"`
+	PostToolUse      []Hook `json:"post_tool_use,omitempty" jsonschema:"description=Hooks run after a tool call that can append feedback to its result"`
+	UserPromptSubmit []Hook `json:"user_prompt_submit,omitempty" jsonschema:"description=Hooks run when a prompt is submitted that can deny it"`
+	Stop             []Hook `json:"stop,omitempty" jsonschema:"description=Hooks run when the agent finishes responding"`
+	SessionStart     []Hook `json:"session_start,omitempty" jsonschema:"description=Hooks run on the first prompt of a session"`
+}
+
+type Hook struct {
+	Matcher string `json:"matcher,omitempty" jsonschema:"description=Regular expression matched against the tool name for tool hooks; matches all tools when empty,example=^(bash|edit)$"`
+	Command string `json:"command" jsonschema:"required,description=Shell command to run,example=./scripts/check-tool.sh"`
+	Timeout int    `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds for the command,default=60,example=10"`
+
+	matcher *regexp.Regexp
+}
+
+// MatchesTool reports whether the hook runs for the given tool. Hooks run
+// for all tools when they have no matcher.
+func (h Hook) MatchesTool(name string) bool {
+	if h.Matcher == "" || name == "" {
+		return true
+	}
+	return h.matcher != nil && h.matcher.MatchString(name)
+}
+
+// CompileMatchers compiles the matchers of the hooks that were not compiled
+// yet, and drops the hooks with an invalid matcher.
+func (h *Hooks) CompileMatchers() {
+	for _, hooks := range []*[]Hook{&h.PreToolUse, &h.PostToolUse, &h.UserPromptSubmit, &h.Stop, &h.SessionStart} {
+		var valid []Hook
+		for _, hook := range *hooks {
+			if hook.Matcher != "" && hook.matcher == nil {
+				re, err := regexp.Compile(hook.Matcher)
+				if err != nil {
+					slog.Warn("Skipping hook with an invalid matcher", "matcher", hook.Matcher, "command", hook.Command, "error", err)
+					continue
+				}
+				hook.matcher = re
+			}
+			valid = append(valid, hook)
+		}
+		*hooks = valid
+	}
+}
+
+// PromptTemplate overrides a prompt template for the providers and models it
+// matches. The file is parsed on top of the embedded template, so it can
+// either replace the whole template or only redefine some of its blocks.
+type PromptTemplate struct {
+	Name     string `json:"name" jsonschema:"required,description=Template to override: coder or task or summary or title or initialize or the ID of a custom agent,example=coder"`
+	Path     string `json:"path" jsonschema:"required,description=Path to the template file; relative paths are resolved against the working directory,example=.crush/prompts/coder-gpt.md.tpl"`
+	Provider string `json:"provider,omitempty" jsonschema:"description=Glob matched against the provider ID; matches all providers when empty,example=openai"`
+	Model    string `json:"model,omitempty" jsonschema:"description=Glob matched against the model ID; matches all models when empty,example=gpt-*"`
+}
+
+func (h Hooks) HasToolHooks() bool {
+	return len(h.PreToolUse) > 0 || len(h.PostToolUse) > 0
+}
+
 // Config holds the configuration for crush.
 type Config struct {
 	Schema string `json:"$schema,omitempty"`
@@ -390,7 +543,11 @@ type Config struct {
 
 	Tools Tools `json:"tools,omitzero" jsonschema:"description=Tool configurations"`
 
-	Agents map[string]Agent `json:"-"`
+	Hooks Hooks `json:"hooks,omitzero" jsonschema:"description=Commands run before and after tool calls and at other points of an agent run"`
+
+	Agents map[string]Agent `json:"agents,omitempty" jsonschema:"description=Agent configurations keyed by agent ID"`
+
+	Prompts []PromptTemplate `json:"prompts,omitempty" jsonschema:"description=Prompt template overrides for specific providers and models"`
 
 	// Internal
 	workingDir string `json:"-"`
@@ -404,6 +561,44 @@ func (c *Config) WorkingDir() string {
 	return c.workingDir
 }
 
+// PromptTemplates returns the files overriding the named prompt template for
+// the given provider and model, in the order they apply: <name>.md.tpl in the
+// global prompts directory, then in the prompts directory of the project, then
+// the matching entries of the prompts configuration.
+func (c *Config) PromptTemplates(name, provider, model string) []string {
+	dirs := []string{GlobalPromptsDir()}
+	if c.Options != nil && c.Options.DataDirectory != "" {
+		dirs = append(dirs, filepath.Join(c.Options.DataDirectory, "prompts"))
+	}
+	var paths []string
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name+".md.tpl")
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	for _, tmpl := range c.Prompts {
+		if tmpl.Name == name && matchGlob(tmpl.Provider, provider) && matchGlob(tmpl.Model, model) {
+			paths = append(paths, tmpl.Path)
+		}
+	}
+	return paths
+}
+
+// matchGlob reports whether s matches pattern, where * matches any run of
+// characters, slashes included, and ? any single one. An empty pattern
+// matches everything.
+func matchGlob(pattern, s string) bool {
+	if pattern == "" {
+		return true
+	}
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	matched, _ := regexp.MatchString("^"+expr+"$", s)
+	return matched
+}
+
 func (c *Config) EnabledProviders() []ProviderConfig {
 	var enabled []ProviderConfig
 	for p := range c.Providers.Seq() {
@@ -692,6 +887,11 @@ func allToolNames() []string {
 		"multiedit",
 		"lsp_diagnostics",
 		"lsp_references",
+		"lsp_definition",
+		"lsp_hover",
+		"lsp_rename",
+		"lsp_symbols",
+		"lsp_code_actions",
 		"fetch",
 		"agentic_fetch",
 		"glob",
@@ -699,8 +899,11 @@ func allToolNames() []string {
 		"ls",
 		"sourcegraph",
 		"todos",
+		"memory",
+		"plan",
 		"view",
 		"write",
+		"worktree",
 	}
 }
 
@@ -718,6 +921,16 @@ func resolveReadOnlyTools(tools []string) []string {
 	return filterSlice(tools, readOnlyTools, true)
 }
 
+// resolveWorktreeTools returns the tools of a sub-agent working in its own
+// worktree. It can change files there, but cannot start sub-agents itself,
+// has no LSP servers running on the worktree and cannot save project
+// memories, which only the main agent does.
+func resolveWorktreeTools(tools []string) []string {
+	excluded := []string{"agent", "plan", "worktree", "memory", "lsp_diagnostics", "lsp_references", "lsp_definition", "lsp_hover", "lsp_rename", "lsp_symbols", "lsp_code_actions"}
+	// filter out tools that are in the mask (exclude mode)
+	return filterSlice(tools, excluded, false)
+}
+
 func filterSlice(data []string, mask []string, include bool) []string {
 	filtered := []string{}
 	for _, s := range data {
@@ -731,7 +944,21 @@ func filterSlice(data []string, mask []string, include bool) []string {
 }
 
 func (c *Config) SetupAgents() {
-	allowedTools := resolveAllowedTools(allToolNames(), c.Options.DisabledTools)
+	toolNames := allToolNames()
+	for _, tool := range c.Tools.Custom {
+		toolNames = append(toolNames, tool.Name)
+	}
+	disabledTools := c.Options.DisabledTools
+	if !c.Options.SubAgentWorktrees {
+		// Without worktrees there is no sub-agent work to merge.
+		disabledTools = append(slices.Clone(disabledTools), "worktree")
+	}
+	allowedTools := resolveAllowedTools(toolNames, disabledTools)
+	taskTools := resolveReadOnlyTools(allowedTools)
+	if c.Options.SubAgentWorktrees {
+		// Only the main agent merges or discards the work of sub-agents.
+		taskTools = resolveWorktreeTools(allowedTools)
+	}
 
 	agents := map[string]Agent{
 		AgentCoder: {
@@ -744,19 +971,139 @@ func (c *Config) SetupAgents() {
 		},
 
 		AgentTask: {
-			ID:           AgentCoder,
+			ID:           AgentTask,
 			Name:         "Task",
 			Description:  "An agent that helps with searching for context and finding implementation details.",
 			Model:        SelectedModelTypeLarge,
 			ContextPaths: c.Options.ContextPaths,
-			AllowedTools: resolveReadOnlyTools(allowedTools),
+			AllowedTools: taskTools,
 			// NO MCPs or LSPs by default
 			AllowedMCP: map[string][]string{},
 		},
+
+		AgentFetch: {
+			ID:          AgentFetch,
+			Name:        "Fetch",
+			Description: "An agent that searches the web and analyzes web content.",
+			Model:       SelectedModelTypeSmall,
+			// The fetch agent builds its own set of tools.
+			AllowedTools: []string{},
+			AllowedMCP:   map[string][]string{},
+		},
+	}
+
+	// Merge the agents declared in the config files on top of the built-in
+	// ones.
+	for id, agent := range c.Agents {
+		if builtin, ok := agents[id]; ok {
+			agent = builtin.merge(agent)
+		}
+		agents[id] = c.resolveAgent(id, agent, allowedTools)
+	}
+	if agents[AgentCoder].Disabled {
+		slog.Warn("The coder agent cannot be disabled")
+		coder := agents[AgentCoder]
+		coder.Disabled = false
+		agents[AgentCoder] = coder
 	}
 	c.Agents = agents
 }
 
+// resolveAgent fills in the defaults of an agent and makes sure it cannot
+// use tools that are disabled globally.
+func (c *Config) resolveAgent(id string, agent Agent, allowedTools []string) Agent {
+	agent.ID = id
+	agent.Name = cmp.Or(agent.Name, id)
+	agent.Model = cmp.Or(agent.Model, SelectedModelTypeLarge)
+	if agent.AllowedTools == nil {
+		agent.AllowedTools = allowedTools
+	} else {
+		agent.AllowedTools = filterSlice(agent.AllowedTools, allowedTools, true)
+	}
+	if agent.ContextPaths == nil {
+		agent.ContextPaths = c.Options.ContextPaths
+	}
+	if agent.ModelConfig != nil && c.Providers != nil {
+		modelCfg := *agent.ModelConfig
+		model := c.GetModel(modelCfg.Provider, modelCfg.Model)
+		if model == nil {
+			slog.Warn("Agent model not found, falling back to the model type", "agent", id, "provider", modelCfg.Provider, "model", modelCfg.Model)
+			agent.ModelConfig = nil
+		} else {
+			if modelCfg.MaxTokens == 0 {
+				modelCfg.MaxTokens = model.DefaultMaxTokens
+			}
+			agent.ModelConfig = &modelCfg
+		}
+	}
+	if agent.PromptFile != "" {
+		agent.PromptFile = home.Long(agent.PromptFile)
+		if !filepath.IsAbs(agent.PromptFile) {
+			agent.PromptFile = filepath.Join(c.workingDir, agent.PromptFile)
+		}
+	}
+	return agent
+}
+
+// merge returns a copy of a with the non-zero fields of b applied on top.
+func (a Agent) merge(b Agent) Agent {
+	a.Name = cmp.Or(b.Name, a.Name)
+	a.Description = cmp.Or(b.Description, a.Description)
+	a.Disabled = a.Disabled || b.Disabled
+	a.Model = cmp.Or(b.Model, a.Model)
+	a.Prompt = cmp.Or(b.Prompt, a.Prompt)
+	a.PromptFile = cmp.Or(b.PromptFile, a.PromptFile)
+	if b.ModelConfig != nil {
+		a.ModelConfig = b.ModelConfig
+	}
+	if b.AllowedTools != nil {
+		a.AllowedTools = b.AllowedTools
+	}
+	if b.AllowedMCP != nil {
+		a.AllowedMCP = b.AllowedMCP
+	}
+	if b.ContextPaths != nil {
+		a.ContextPaths = b.ContextPaths
+	}
+	return a
+}
+
+// AgentModel returns the model config used by the given agent.
+func (c *Config) AgentModel(agent Agent) SelectedModel {
+	if agent.ModelConfig != nil {
+		return *agent.ModelConfig
+	}
+	return c.Models[agent.Model]
+}
+
+// IsSubAgent returns whether the agent with the given ID only runs on
+// behalf of another agent.
+func IsSubAgent(agentID string) bool {
+	return agentID == AgentTask || agentID == AgentFetch
+}
+
+// SelectableAgents returns the enabled agents that can drive a session,
+// with the coder first and the rest sorted by name.
+func (c *Config) SelectableAgents() []Agent {
+	var agents []Agent
+	for _, agent := range c.Agents {
+		if agent.Disabled || IsSubAgent(agent.ID) {
+			continue
+		}
+		agents = append(agents, agent)
+	}
+	slices.SortFunc(agents, func(a, b Agent) int {
+		switch {
+		case a.ID == AgentCoder:
+			return -1
+		case b.ID == AgentCoder:
+			return 1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return agents
+}
+
 func (c *Config) Resolver() VariableResolver {
 	return c.resolver
 }
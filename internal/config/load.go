@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"slices"
 	"strconv"
@@ -326,6 +327,25 @@ func (c *Config) setDefaults(workingDir, dataDir string) {
 	if c.Options.TUI == nil {
 		c.Options.TUI = &TUIOptions{}
 	}
+	if c.Options.Budgets == nil {
+		c.Options.Budgets = &Budgets{}
+	}
+	if c.Options.Pruning == nil {
+		c.Options.Pruning = &Pruning{}
+	}
+	if c.Options.Titles == nil {
+		c.Options.Titles = &Titles{}
+	}
+	if c.Options.Summaries == nil {
+		c.Options.Summaries = &Summaries{}
+	}
+	if threshold := c.Options.Summaries.Threshold; threshold < 0 || threshold > 1 {
+		slog.Warn("Ignoring summary threshold outside of (0, 1], using the default", "threshold", threshold)
+		c.Options.Summaries.Threshold = 0
+	}
+	if c.Options.Formatting == nil {
+		c.Options.Formatting = &Formatting{}
+	}
 	if c.Options.ContextPaths == nil {
 		c.Options.ContextPaths = []string{}
 	}
@@ -360,11 +380,22 @@ func (c *Config) setDefaults(workingDir, dataDir string) {
 	// Apply defaults to LSP configurations
 	c.applyLSPDefaults()
 
+	c.Tools.Custom = validCustomTools(c.Tools.Custom)
+	c.Hooks.CompileMatchers()
+
 	// Add the default context paths if they are not already present
 	c.Options.ContextPaths = append(defaultContextPaths, c.Options.ContextPaths...)
 	slices.Sort(c.Options.ContextPaths)
 	c.Options.ContextPaths = slices.Compact(c.Options.ContextPaths)
 
+	for i, tmpl := range c.Prompts {
+		tmpl.Path = home.Long(tmpl.Path)
+		if !filepath.IsAbs(tmpl.Path) {
+			tmpl.Path = filepath.Join(workingDir, tmpl.Path)
+		}
+		c.Prompts[i] = tmpl
+	}
+
 	// Add the default skills directory if not already present.
 	defaultSkillsDir := GlobalSkillsDir()
 	if !slices.Contains(c.Options.SkillsPaths, defaultSkillsDir) {
@@ -395,6 +426,33 @@ func (c *Config) setDefaults(workingDir, dataDir string) {
 	if c.Options.InitializeAs == "" {
 		c.Options.InitializeAs = defaultInitializeAs
 	}
+	if c.Options.MemoryTokens == nil {
+		memoryTokens := defaultMemoryTokens
+		c.Options.MemoryTokens = &memoryTokens
+	}
+}
+
+var customToolNameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// validCustomTools drops the custom tools that cannot be given to the model,
+// either because their name is invalid or taken or they have no command.
+func validCustomTools(tools []CustomTool) []CustomTool {
+	var valid []CustomTool
+	names := allToolNames()
+	for _, tool := range tools {
+		switch {
+		case !customToolNameRe.MatchString(tool.Name):
+			slog.Warn("Skipping custom tool with an invalid name", "name", tool.Name)
+		case slices.Contains(names, tool.Name) || strings.HasPrefix(tool.Name, "mcp_"):
+			slog.Warn("Skipping custom tool with a name already in use", "name", tool.Name)
+		case tool.Command == "":
+			slog.Warn("Skipping custom tool without a command", "name", tool.Name)
+		default:
+			valid = append(valid, tool)
+			names = append(names, tool.Name)
+		}
+	}
+	return valid
 }
 
 // applyLSPDefaults applies default values from powernap to LSP configurations
@@ -556,6 +614,7 @@ func (c *Config) configureSelectedModels(knownProviders []catwalk.Provider) erro
 			if largeModelSelected.PresencePenalty != nil {
 				large.PresencePenalty = largeModelSelected.PresencePenalty
 			}
+			large.Fallbacks = largeModelSelected.Fallbacks
 		}
 	}
 	smallModelSelected, smallModelConfigured := c.Models[SelectedModelTypeSmall]
@@ -600,6 +659,7 @@ func (c *Config) configureSelectedModels(knownProviders []catwalk.Provider) erro
 				small.PresencePenalty = smallModelSelected.PresencePenalty
 			}
 			small.Think = smallModelSelected.Think
+			small.Fallbacks = smallModelSelected.Fallbacks
 		}
 	}
 	c.Models[SelectedModelTypeLarge] = large
@@ -767,3 +827,10 @@ func GlobalSkillsDir() string {
 
 	return filepath.Join(home.Dir(), ".config", appName, "skills")
 }
+
+// GlobalPromptsDir returns the directory holding the prompt templates that
+// override the embedded ones in every project. It sits next to the global
+// config file.
+func GlobalPromptsDir() string {
+	return filepath.Join(filepath.Dir(GlobalConfig()), "prompts")
+}
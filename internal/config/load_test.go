@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -55,6 +56,18 @@ func TestConfig_setDefaults(t *testing.T) {
 		require.Contains(t, cfg.Options.ContextPaths, path)
 	}
 	require.Equal(t, "/tmp", cfg.workingDir)
+	require.Equal(t, defaultMemoryTokens, *cfg.Options.MemoryTokens)
+
+	noMemories := 0
+	cfg = &Config{Options: &Options{MemoryTokens: &noMemories}}
+	cfg.setDefaults("/tmp", "")
+	require.Zero(t, *cfg.Options.MemoryTokens)
+
+	for threshold, expected := range map[float64]float64{0.8: 0.8, 1: 1, 1.5: 0, -0.2: 0} {
+		cfg = &Config{Options: &Options{Summaries: &Summaries{Threshold: threshold}}}
+		cfg.setDefaults("/tmp", "")
+		require.Equal(t, expected, cfg.Options.Summaries.Threshold)
+	}
 }
 
 func TestConfig_configureProviders(t *testing.T) {
@@ -465,7 +478,9 @@ func TestConfig_setupAgentsWithNoDisabledTools(t *testing.T) {
 	cfg.SetupAgents()
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
-	assert.Equal(t, allToolNames(), coderAgent.AllowedTools)
+	assert.Equal(t, slices.DeleteFunc(allToolNames(), func(name string) bool {
+		return name == "worktree"
+	}), coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
@@ -487,13 +502,65 @@ func TestConfig_setupAgentsWithDisabledTools(t *testing.T) {
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
 
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "multiedit", "lsp_diagnostics", "lsp_references", "fetch", "agentic_fetch", "glob", "ls", "sourcegraph", "todos", "view", "write"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_definition", "lsp_hover", "lsp_rename", "lsp_symbols", "lsp_code_actions", "fetch", "agentic_fetch", "glob", "ls", "sourcegraph", "todos", "memory", "plan", "view", "write"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
 	assert.Equal(t, []string{"glob", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
 }
 
+func TestConfig_setupAgentsWithSubAgentWorktrees(t *testing.T) {
+	cfg := &Config{
+		Options: &Options{
+			DisabledTools:     []string{"download"},
+			SubAgentWorktrees: true,
+		},
+	}
+
+	cfg.SetupAgents()
+	coderAgent, ok := cfg.Agents[AgentCoder]
+	require.True(t, ok)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "edit", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_definition", "lsp_hover", "lsp_rename", "lsp_symbols", "lsp_code_actions", "fetch", "agentic_fetch", "glob", "grep", "ls", "sourcegraph", "todos", "memory", "plan", "view", "write", "worktree"}, coderAgent.AllowedTools)
+
+	taskAgent, ok := cfg.Agents[AgentTask]
+	require.True(t, ok)
+	assert.Equal(t, []string{"bash", "job_output", "job_kill", "edit", "multiedit", "fetch", "agentic_fetch", "glob", "grep", "ls", "sourcegraph", "todos", "view", "write"}, taskAgent.AllowedTools)
+
+	// The worktree tool can be disabled like any other.
+	cfg.Options.DisabledTools = append(cfg.Options.DisabledTools, "worktree")
+	cfg.SetupAgents()
+	assert.NotContains(t, cfg.Agents[AgentCoder].AllowedTools, "worktree")
+}
+
+func TestConfig_setupAgentsWithCustomTools(t *testing.T) {
+	cfg := &Config{
+		Options: &Options{
+			DisabledTools: []string{"deploy"},
+		},
+		Tools: Tools{
+			Custom: validCustomTools([]CustomTool{
+				{Name: "run_tests", Command: "go test ./..."},
+				{Name: "deploy", Command: "./deploy.sh"},
+				{Name: "bash", Command: "bash"},
+				{Name: "run_tests", Command: "go test -race ./..."},
+				{Name: "bad name", Command: "true"},
+				{Name: "no_command"},
+			}),
+		},
+	}
+	require.Len(t, cfg.Tools.Custom, 2)
+
+	cfg.SetupAgents()
+	coderAgent, ok := cfg.Agents[AgentCoder]
+	require.True(t, ok)
+	assert.Contains(t, coderAgent.AllowedTools, "run_tests")
+	assert.NotContains(t, coderAgent.AllowedTools, "deploy")
+
+	taskAgent, ok := cfg.Agents[AgentTask]
+	require.True(t, ok)
+	assert.NotContains(t, taskAgent.AllowedTools, "run_tests")
+}
+
 func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 	cfg := &Config{
 		Options: &Options{
@@ -510,13 +577,153 @@ func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 	cfg.SetupAgents()
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "download", "edit", "multiedit", "lsp_diagnostics", "lsp_references", "fetch", "agentic_fetch", "todos", "write"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "download", "edit", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_definition", "lsp_hover", "lsp_rename", "lsp_symbols", "lsp_code_actions", "fetch", "agentic_fetch", "todos", "memory", "plan", "write"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
 	assert.Equal(t, []string{}, taskAgent.AllowedTools)
 }
 
+func TestConfig_setupAgentsWithUserAgents(t *testing.T) {
+	workingDir := t.TempDir()
+	cfg := &Config{
+		workingDir: workingDir,
+		Options: &Options{
+			ContextPaths:  []string{"AGENTS.md"},
+			DisabledTools: []string{"bash"},
+		},
+		Agents: map[string]Agent{
+			"reviewer": {
+				Description:  "Reviews changes.",
+				Model:        SelectedModelTypeSmall,
+				PromptFile:   "reviewer.md.tpl",
+				AllowedTools: []string{"view", "grep", "bash"},
+			},
+			AgentTask: {
+				Model: SelectedModelTypeSmall,
+			},
+			AgentCoder: {
+				Disabled: true,
+				Prompt:   "You are a coder.",
+			},
+		},
+	}
+
+	cfg.SetupAgents()
+
+	reviewer, ok := cfg.Agents["reviewer"]
+	require.True(t, ok)
+	require.Equal(t, "reviewer", reviewer.ID)
+	require.Equal(t, "reviewer", reviewer.Name)
+	require.Equal(t, SelectedModelTypeSmall, reviewer.Model)
+	require.Equal(t, filepath.Join(workingDir, "reviewer.md.tpl"), reviewer.PromptFile)
+	require.Equal(t, []string{"view", "grep"}, reviewer.AllowedTools)
+	require.Equal(t, []string{"AGENTS.md"}, reviewer.ContextPaths)
+	require.Nil(t, reviewer.AllowedMCP)
+
+	task, ok := cfg.Agents[AgentTask]
+	require.True(t, ok)
+	require.Equal(t, AgentTask, task.ID)
+	require.Equal(t, "Task", task.Name)
+	require.Equal(t, SelectedModelTypeSmall, task.Model)
+	require.Equal(t, []string{"glob", "grep", "ls", "sourcegraph", "view"}, task.AllowedTools)
+	require.Equal(t, map[string][]string{}, task.AllowedMCP)
+
+	coder, ok := cfg.Agents[AgentCoder]
+	require.True(t, ok)
+	require.False(t, coder.Disabled)
+	require.Equal(t, "You are a coder.", coder.Prompt)
+
+	selectable := cfg.SelectableAgents()
+	require.Len(t, selectable, 2)
+	require.Equal(t, AgentCoder, selectable[0].ID)
+	require.Equal(t, "reviewer", selectable[1].ID)
+}
+
+func TestConfig_setupAgentsWithModelConfig(t *testing.T) {
+	cfg := &Config{
+		Options: &Options{},
+		Models: map[SelectedModelType]SelectedModel{
+			SelectedModelTypeLarge: {Provider: "openai", Model: "large-model"},
+			SelectedModelTypeSmall: {Provider: "openai", Model: "small-model"},
+		},
+		Providers: csync.NewMapFrom(map[string]ProviderConfig{
+			"openai": {
+				ID: "openai",
+				Models: []catwalk.Model{
+					{ID: "large-model", DefaultMaxTokens: 1000},
+					{ID: "small-model", DefaultMaxTokens: 500},
+					{ID: "cheap-model", DefaultMaxTokens: 200},
+				},
+			},
+		}),
+		Agents: map[string]Agent{
+			AgentTask: {
+				ModelConfig: &SelectedModel{
+					Provider:        "openai",
+					Model:           "cheap-model",
+					ReasoningEffort: "low",
+				},
+			},
+			"explorer": {
+				ModelConfig: &SelectedModel{
+					Provider: "openai",
+					Model:    "missing-model",
+				},
+			},
+		},
+	}
+
+	cfg.SetupAgents()
+
+	task := cfg.Agents[AgentTask]
+	require.Equal(t, SelectedModel{
+		Provider:        "openai",
+		Model:           "cheap-model",
+		ReasoningEffort: "low",
+		MaxTokens:       200,
+	}, cfg.AgentModel(task))
+
+	explorer := cfg.Agents["explorer"]
+	require.Nil(t, explorer.ModelConfig)
+	require.Equal(t, "large-model", cfg.AgentModel(explorer).Model)
+
+	fetch, ok := cfg.Agents[AgentFetch]
+	require.True(t, ok)
+	require.Equal(t, "small-model", cfg.AgentModel(fetch).Model)
+	require.Empty(t, fetch.AllowedTools)
+
+	require.True(t, IsSubAgent(AgentTask))
+	require.True(t, IsSubAgent(AgentFetch))
+	require.False(t, IsSubAgent(AgentCoder))
+}
+
+func TestConfig_loadUserAgents(t *testing.T) {
+	t.Parallel()
+
+	data := `{
+		"agents": {
+			"docs": {
+				"name": "Docs Writer",
+				"prompt": "Write documentation for {{.WorkingDir}}.",
+				"model_config": {"provider": "openai", "model": "gpt-4o"},
+				"allowed_mcp": {"docs": ["search"]}
+			}
+		}
+	}`
+	cfg, err := loadFromReaders([]io.Reader{strings.NewReader(data)})
+	require.NoError(t, err)
+
+	agent, ok := cfg.Agents["docs"]
+	require.True(t, ok)
+	require.Equal(t, "Docs Writer", agent.Name)
+	require.Equal(t, "Write documentation for {{.WorkingDir}}.", agent.Prompt)
+	require.NotNil(t, agent.ModelConfig)
+	require.Equal(t, "openai", agent.ModelConfig.Provider)
+	require.Equal(t, "gpt-4o", agent.ModelConfig.Model)
+	require.Equal(t, map[string][]string{"docs": {"search"}}, agent.AllowedMCP)
+}
+
 func TestConfig_configureProvidersWithDisabledProvider(t *testing.T) {
 	knownProviders := []catwalk.Provider{
 		{
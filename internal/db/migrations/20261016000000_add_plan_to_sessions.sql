@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sessions ADD COLUMN plan TEXT;
+ALTER TABLE sessions ADD COLUMN plan_mode INTEGER DEFAULT 0 NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sessions DROP COLUMN plan_mode;
+ALTER TABLE sessions DROP COLUMN plan;
+-- +goose StatementEnd
@@ -8,6 +8,14 @@ import (
 	"database/sql"
 )
 
+type Checkpoint struct {
+	ID           string `json:"id"`
+	SessionID    string `json:"session_id"`
+	MessageID    string `json:"message_id"`
+	CreatedAt    int64  `json:"created_at"`
+	FileVersions string `json:"file_versions"`
+}
+
 type File struct {
 	ID        string `json:"id"`
 	SessionID string `json:"session_id"`
@@ -29,18 +37,33 @@ type Message struct {
 	FinishedAt       sql.NullInt64  `json:"finished_at"`
 	Provider         sql.NullString `json:"provider"`
 	IsSummaryMessage int64          `json:"is_summary_message"`
+	BranchID         sql.NullString `json:"branch_id"`
+}
+
+type QueuedPrompt struct {
+	ID          string `json:"id"`
+	SessionID   string `json:"session_id"`
+	Position    int64  `json:"position"`
+	Prompt      string `json:"prompt"`
+	Attachments string `json:"attachments"`
+	Steer       int64  `json:"steer"`
+	CreatedAt   int64  `json:"created_at"`
 }
 
 type Session struct {
-	ID               string         `json:"id"`
-	ParentSessionID  sql.NullString `json:"parent_session_id"`
-	Title            string         `json:"title"`
-	MessageCount     int64          `json:"message_count"`
-	PromptTokens     int64          `json:"prompt_tokens"`
-	CompletionTokens int64          `json:"completion_tokens"`
-	Cost             float64        `json:"cost"`
-	UpdatedAt        int64          `json:"updated_at"`
-	CreatedAt        int64          `json:"created_at"`
-	SummaryMessageID sql.NullString `json:"summary_message_id"`
-	Todos            sql.NullString `json:"todos"`
+	ID                  string         `json:"id"`
+	ParentSessionID     sql.NullString `json:"parent_session_id"`
+	Title               string         `json:"title"`
+	MessageCount        int64          `json:"message_count"`
+	PromptTokens        int64          `json:"prompt_tokens"`
+	CompletionTokens    int64          `json:"completion_tokens"`
+	Cost                float64        `json:"cost"`
+	UpdatedAt           int64          `json:"updated_at"`
+	CreatedAt           int64          `json:"created_at"`
+	SummaryMessageID    sql.NullString `json:"summary_message_id"`
+	Todos               sql.NullString `json:"todos"`
+	Plan                sql.NullString `json:"plan"`
+	PlanMode            int64          `json:"plan_mode"`
+	ForkedFromMessageID sql.NullString `json:"forked_from_message_id"`
+	ContextFiles        sql.NullString `json:"context_files"`
 }
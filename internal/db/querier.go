@@ -6,14 +6,22 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 type Querier interface {
+	CopyFile(ctx context.Context, arg CopyFileParams) error
+	CopyMessage(ctx context.Context, arg CopyMessageParams) error
+	CreateCheckpoint(ctx context.Context, arg CreateCheckpointParams) (Checkpoint, error)
 	CreateFile(ctx context.Context, arg CreateFileParams) (File, error)
+	CreateForkSession(ctx context.Context, arg CreateForkSessionParams) (Session, error)
 	CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error)
+	CreateQueuedPrompt(ctx context.Context, arg CreateQueuedPromptParams) error
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	DeleteCheckpoint(ctx context.Context, id string) error
 	DeleteFile(ctx context.Context, id string) error
 	DeleteMessage(ctx context.Context, id string) error
+	DeleteQueuedPromptsBySession(ctx context.Context, sessionID string) error
 	DeleteSession(ctx context.Context, id string) error
 	DeleteSessionFiles(ctx context.Context, sessionID string) error
 	DeleteSessionMessages(ctx context.Context, sessionID string) error
@@ -21,11 +29,15 @@ type Querier interface {
 	GetFileByPathAndSession(ctx context.Context, arg GetFileByPathAndSessionParams) (File, error)
 	GetMessage(ctx context.Context, id string) (Message, error)
 	GetSessionByID(ctx context.Context, id string) (Session, error)
+	HideMessage(ctx context.Context, arg HideMessageParams) error
+	ListCheckpointsBySession(ctx context.Context, sessionID string) ([]Checkpoint, error)
+	ListChildSessions(ctx context.Context, parentSessionID sql.NullString) ([]Session, error)
 	ListFilesByPath(ctx context.Context, path string) ([]File, error)
 	ListFilesBySession(ctx context.Context, sessionID string) ([]File, error)
 	ListLatestSessionFiles(ctx context.Context, sessionID string) ([]File, error)
 	ListMessagesBySession(ctx context.Context, sessionID string) ([]Message, error)
 	ListNewFiles(ctx context.Context) ([]File, error)
+	ListQueuedPromptsBySession(ctx context.Context, sessionID string) ([]QueuedPrompt, error)
 	ListSessions(ctx context.Context) ([]Session, error)
 	UpdateMessage(ctx context.Context, arg UpdateMessageParams) error
 	UpdateSession(ctx context.Context, arg UpdateSessionParams) (Session, error)
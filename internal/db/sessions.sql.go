@@ -10,6 +10,71 @@ import (
 	"database/sql"
 )
 
+const createForkSession = `-- name: CreateForkSession :one
+INSERT INTO sessions (
+    id,
+    parent_session_id,
+    forked_from_message_id,
+    title,
+    todos,
+    plan,
+    plan_mode,
+    updated_at,
+    created_at
+) VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    strftime('%s', 'now'),
+    strftime('%s', 'now')
+) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, plan, plan_mode, forked_from_message_id, context_files
+`
+
+type CreateForkSessionParams struct {
+	ID                  string         `json:"id"`
+	ParentSessionID     sql.NullString `json:"parent_session_id"`
+	ForkedFromMessageID sql.NullString `json:"forked_from_message_id"`
+	Title               string         `json:"title"`
+	Todos               sql.NullString `json:"todos"`
+	Plan                sql.NullString `json:"plan"`
+	PlanMode            int64          `json:"plan_mode"`
+}
+
+func (q *Queries) CreateForkSession(ctx context.Context, arg CreateForkSessionParams) (Session, error) {
+	row := q.queryRow(ctx, q.createForkSessionStmt, createForkSession,
+		arg.ID,
+		arg.ParentSessionID,
+		arg.ForkedFromMessageID,
+		arg.Title,
+		arg.Todos,
+		arg.Plan,
+		arg.PlanMode,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ParentSessionID,
+		&i.Title,
+		&i.MessageCount,
+		&i.PromptTokens,
+		&i.CompletionTokens,
+		&i.Cost,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.SummaryMessageID,
+		&i.Todos,
+		&i.Plan,
+		&i.PlanMode,
+		&i.ForkedFromMessageID,
+		&i.ContextFiles,
+	)
+	return i, err
+}
+
 const createSession = `-- name: CreateSession :one
 INSERT INTO sessions (
     id,
@@ -33,7 +98,7 @@ INSERT INTO sessions (
     null,
     strftime('%s', 'now'),
     strftime('%s', 'now')
-) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, plan, plan_mode, forked_from_message_id, context_files
 `
 
 type CreateSessionParams struct {
@@ -69,6 +134,10 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.Plan,
+		&i.PlanMode,
+		&i.ForkedFromMessageID,
+		&i.ContextFiles,
 	)
 	return i, err
 }
@@ -84,7 +153,7 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 }
 
 const getSessionByID = `-- name: GetSessionByID :one
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, plan, plan_mode, forked_from_message_id, context_files
 FROM sessions
 WHERE id = ? LIMIT 1
 `
@@ -104,14 +173,64 @@ func (q *Queries) GetSessionByID(ctx context.Context, id string) (Session, error
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.Plan,
+		&i.PlanMode,
+		&i.ForkedFromMessageID,
+		&i.ContextFiles,
 	)
 	return i, err
 }
 
+const listChildSessions = `-- name: ListChildSessions :many
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, plan, plan_mode, forked_from_message_id, context_files
+FROM sessions
+WHERE parent_session_id = ?
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListChildSessions(ctx context.Context, parentSessionID sql.NullString) ([]Session, error) {
+	rows, err := q.query(ctx, q.listChildSessionsStmt, listChildSessions, parentSessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentSessionID,
+			&i.Title,
+			&i.MessageCount,
+			&i.PromptTokens,
+			&i.CompletionTokens,
+			&i.Cost,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.SummaryMessageID,
+			&i.Todos,
+			&i.Plan,
+			&i.PlanMode,
+			&i.ForkedFromMessageID,
+			&i.ContextFiles,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listSessions = `-- name: ListSessions :many
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, plan, plan_mode, forked_from_message_id, context_files
 FROM sessions
-WHERE parent_session_id is NULL
+WHERE parent_session_id is NULL OR forked_from_message_id IS NOT NULL
 ORDER BY updated_at DESC
 `
 
@@ -136,6 +255,10 @@ func (q *Queries) ListSessions(ctx context.Context) ([]Session, error) {
 			&i.CreatedAt,
 			&i.SummaryMessageID,
 			&i.Todos,
+			&i.Plan,
+			&i.PlanMode,
+			&i.ForkedFromMessageID,
+			&i.ContextFiles,
 		); err != nil {
 			return nil, err
 		}
@@ -158,9 +281,12 @@ SET
     completion_tokens = ?,
     summary_message_id = ?,
     cost = ?,
-    todos = ?
+    todos = ?,
+    plan = ?,
+    plan_mode = ?,
+    context_files = ?
 WHERE id = ?
-RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, plan, plan_mode, forked_from_message_id, context_files
 `
 
 type UpdateSessionParams struct {
@@ -170,6 +296,9 @@ type UpdateSessionParams struct {
 	SummaryMessageID sql.NullString `json:"summary_message_id"`
 	Cost             float64        `json:"cost"`
 	Todos            sql.NullString `json:"todos"`
+	Plan             sql.NullString `json:"plan"`
+	PlanMode         int64          `json:"plan_mode"`
+	ContextFiles     sql.NullString `json:"context_files"`
 	ID               string         `json:"id"`
 }
 
@@ -181,6 +310,9 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		arg.SummaryMessageID,
 		arg.Cost,
 		arg.Todos,
+		arg.Plan,
+		arg.PlanMode,
+		arg.ContextFiles,
 		arg.ID,
 	)
 	var i Session
@@ -196,6 +328,10 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.Plan,
+		&i.PlanMode,
+		&i.ForkedFromMessageID,
+		&i.ContextFiles,
 	)
 	return i, err
 }
@@ -0,0 +1,21 @@
+-- name: CreateCheckpoint :one
+INSERT INTO checkpoints (
+    id,
+    session_id,
+    message_id,
+    created_at,
+    file_versions
+) VALUES (
+    ?, ?, ?, ?, ?
+)
+RETURNING *;
+
+-- name: ListCheckpointsBySession :many
+SELECT *
+FROM checkpoints
+WHERE session_id = ?
+ORDER BY created_at ASC, rowid ASC;
+
+-- name: DeleteCheckpoint :exec
+DELETE FROM checkpoints
+WHERE id = ?;
@@ -36,6 +36,19 @@ INSERT INTO files (
 )
 RETURNING *;
 
+-- name: CopyFile :exec
+INSERT INTO files (
+    id,
+    session_id,
+    path,
+    content,
+    version,
+    created_at,
+    updated_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?
+);
+
 -- name: DeleteFile :exec
 DELETE FROM files
 WHERE id = ?;
@@ -6,7 +6,7 @@ WHERE id = ? LIMIT 1;
 -- name: ListMessagesBySession :many
 SELECT *
 FROM messages
-WHERE session_id = ?
+WHERE session_id = ? AND branch_id IS NULL
 ORDER BY created_at ASC;
 
 -- name: CreateMessage :one
@@ -25,6 +25,22 @@ INSERT INTO messages (
 )
 RETURNING *;
 
+-- name: CopyMessage :exec
+INSERT INTO messages (
+    id,
+    session_id,
+    role,
+    parts,
+    model,
+    provider,
+    is_summary_message,
+    created_at,
+    updated_at,
+    finished_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+);
+
 -- name: UpdateMessage :exec
 UPDATE messages
 SET
@@ -33,6 +49,10 @@ SET
     updated_at = strftime('%s', 'now')
 WHERE id = ?;
 
+-- name: HideMessage :exec
+UPDATE messages
+SET branch_id = ?
+WHERE id = ?;
 
 -- name: DeleteMessage :exec
 DELETE FROM messages
@@ -41,3 +61,4 @@ WHERE id = ?;
 -- name: DeleteSessionMessages :exec
 DELETE FROM messages
 WHERE session_id = ?;
+
@@ -0,0 +1,22 @@
+-- name: CreateQueuedPrompt :exec
+INSERT INTO queued_prompts (
+    id,
+    session_id,
+    position,
+    prompt,
+    attachments,
+    steer,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?
+);
+
+-- name: ListQueuedPromptsBySession :many
+SELECT *
+FROM queued_prompts
+WHERE session_id = ?
+ORDER BY position ASC;
+
+-- name: DeleteQueuedPromptsBySession :exec
+DELETE FROM queued_prompts
+WHERE session_id = ?;
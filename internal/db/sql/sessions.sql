@@ -23,6 +23,29 @@ INSERT INTO sessions (
     strftime('%s', 'now')
 ) RETURNING *;
 
+-- name: CreateForkSession :one
+INSERT INTO sessions (
+    id,
+    parent_session_id,
+    forked_from_message_id,
+    title,
+    todos,
+    plan,
+    plan_mode,
+    updated_at,
+    created_at
+) VALUES (
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    ?,
+    strftime('%s', 'now'),
+    strftime('%s', 'now')
+) RETURNING *;
+
 -- name: GetSessionByID :one
 SELECT *
 FROM sessions
@@ -31,9 +54,15 @@ WHERE id = ? LIMIT 1;
 -- name: ListSessions :many
 SELECT *
 FROM sessions
-WHERE parent_session_id is NULL
+WHERE parent_session_id is NULL OR forked_from_message_id IS NOT NULL
 ORDER BY updated_at DESC;
 
+-- name: ListChildSessions :many
+SELECT *
+FROM sessions
+WHERE parent_session_id = ?
+ORDER BY created_at ASC;
+
 -- name: UpdateSession :one
 UPDATE sessions
 SET
@@ -42,7 +71,10 @@ SET
     completion_tokens = ?,
     summary_message_id = ?,
     cost = ?,
-    todos = ?
+    todos = ?,
+    plan = ?,
+    plan_mode = ?,
+    context_files = ?
 WHERE id = ?
 RETURNING *;
 
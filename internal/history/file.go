@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/crush/internal/db"
@@ -35,6 +38,59 @@ type Service interface {
 	ListLatestSessionFiles(ctx context.Context, sessionID string) ([]File, error)
 	Delete(ctx context.Context, id string) error
 	DeleteSessionFiles(ctx context.Context, sessionID string) error
+	// Versions returns the latest version of each file of the session, to
+	// later list the changes made since.
+	Versions(ctx context.Context, sessionID string) (Versions, error)
+	// Changes lists the files the session changed after the given versions,
+	// along with what they looked like before and what is on disk now.
+	Changes(ctx context.Context, sessionID string, since Versions) ([]Change, error)
+	// Revert puts the given files back on disk as they were before the
+	// change, recording the restored content as a new version. Files that
+	// did not exist before are removed.
+	Revert(ctx context.Context, sessionID string, changes ...Change) ([]File, error)
+	// Restore reverts every file the session changed after the given
+	// versions. Files that were modified outside of the session since are
+	// left untouched and returned as conflicts.
+	Restore(ctx context.Context, sessionID string, since Versions) ([]Change, error)
+}
+
+// Versions maps the path of each file of a session to its latest version at
+// some point. Versions only grow, unlike timestamps they tell apart changes
+// made within the same second.
+type Versions map[string]int64
+
+// Change describes a file the session changed since some point in time.
+type Change struct {
+	Path string
+	// Before is the version the file had at that point.
+	Before File
+	// After is the latest version the session recorded.
+	After File
+	// Current is the content on disk, if the file Exists.
+	Current string
+	Exists  bool
+}
+
+// Created reports whether the file did not exist at that point.
+func (c Change) Created() bool {
+	return c.Before.Version == InitialVersion && c.Before.Content == ""
+}
+
+// reverted reports whether the file on disk already is as it was before.
+func (c Change) reverted() bool {
+	if c.Created() {
+		return !c.Exists
+	}
+	return c.Exists && c.Current == c.Before.Content
+}
+
+// Conflict reports whether the file was modified outside of the session
+// after its latest recorded version.
+func (c Change) Conflict() bool {
+	if !c.Exists {
+		return c.After.Content != ""
+	}
+	return c.Current != c.After.Content
 }
 
 type service struct {
@@ -197,6 +253,117 @@ func (s *service) DeleteSessionFiles(ctx context.Context, sessionID string) erro
 	return nil
 }
 
+func (s *service) Versions(ctx context.Context, sessionID string) (Versions, error) {
+	files, err := s.ListBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	versions := make(Versions)
+	for _, file := range files {
+		versions[file.Path] = max(versions[file.Path], file.Version)
+	}
+	return versions, nil
+}
+
+func (s *service) Changes(ctx context.Context, sessionID string, since Versions) ([]Change, error) {
+	files, err := s.ListBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Files are ordered by version, so each path's versions stay in order.
+	var paths []string
+	versions := make(map[string][]File)
+	for _, file := range files {
+		if _, ok := versions[file.Path]; !ok {
+			paths = append(paths, file.Path)
+		}
+		versions[file.Path] = append(versions[file.Path], file)
+	}
+
+	var changes []Change
+	for _, path := range paths {
+		fileVersions := versions[path]
+		// Files the session had not touched yet changed from their first
+		// version on.
+		changed := 0
+		if version, ok := since[path]; ok {
+			changed = slices.IndexFunc(fileVersions, func(f File) bool {
+				return f.Version > version
+			})
+		}
+		if changed == -1 {
+			continue
+		}
+		change := Change{
+			Path: path,
+			// The version right before the first change is what the file
+			// looked like at that point. Without one, the first version
+			// recorded holds the content from before the session touched
+			// the file.
+			Before: fileVersions[max(changed-1, 0)],
+			After:  fileVersions[len(fileVersions)-1],
+		}
+		content, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			change.Current = string(content)
+			change.Exists = true
+		case !os.IsNotExist(err):
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if change.reverted() {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func (s *service) Revert(ctx context.Context, sessionID string, changes ...Change) ([]File, error) {
+	var reverted []File
+	for _, change := range changes {
+		if change.Created() {
+			if err := os.Remove(change.Path); err != nil && !os.IsNotExist(err) {
+				return reverted, fmt.Errorf("failed to remove %s: %w", change.Path, err)
+			}
+		} else {
+			if err := os.MkdirAll(filepath.Dir(change.Path), 0o755); err != nil {
+				return reverted, fmt.Errorf("failed to create directory for %s: %w", change.Path, err)
+			}
+			if err := os.WriteFile(change.Path, []byte(change.Before.Content), 0o644); err != nil {
+				return reverted, fmt.Errorf("failed to restore %s: %w", change.Path, err)
+			}
+		}
+
+		file, err := s.CreateVersion(ctx, sessionID, change.Path, change.Before.Content)
+		if err != nil {
+			return reverted, err
+		}
+		reverted = append(reverted, file)
+	}
+	return reverted, nil
+}
+
+func (s *service) Restore(ctx context.Context, sessionID string, since Versions) ([]Change, error) {
+	changes, err := s.Changes(ctx, sessionID, since)
+	if err != nil {
+		return nil, err
+	}
+	var revert, conflicts []Change
+	for _, change := range changes {
+		if change.Conflict() {
+			conflicts = append(conflicts, change)
+			continue
+		}
+		revert = append(revert, change)
+	}
+	if _, err := s.Revert(ctx, sessionID, revert...); err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
+
 func (s *service) fromDBItem(item db.File) File {
 	return File{
 		ID:        item.ID,
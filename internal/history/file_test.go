@@ -0,0 +1,90 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestore(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	files := NewService(q, conn)
+	sess, err := session.NewService(q, conn).Create(t.Context(), "Session")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	write := func(path string, contents ...string) {
+		_, err := files.Create(t.Context(), sess.ID, path, contents[0])
+		require.NoError(t, err)
+		for _, content := range contents[1:] {
+			_, err := files.CreateVersion(t.Context(), sess.ID, path, content)
+			require.NoError(t, err)
+		}
+		require.NoError(t, os.WriteFile(path, []byte(contents[len(contents)-1]), 0o644))
+	}
+
+	// Everything happens within the same second, only the versions tell
+	// apart the changes made before and after the rewind point.
+	edited := filepath.Join(dir, "edited.go")
+	write(edited, "original", "first turn")
+	untouched := filepath.Join(dir, "untouched.go")
+	write(untouched, "before", "after first turn")
+	conflicting := filepath.Join(dir, "conflicting.go")
+	write(conflicting, "original")
+
+	since, err := files.Versions(t.Context(), sess.ID)
+	require.NoError(t, err)
+
+	// Edited before and after the rewind point.
+	_, err = files.CreateVersion(t.Context(), sess.ID, edited, "second turn")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(edited, []byte("second turn"), 0o644))
+
+	// Created by the agent after the rewind point.
+	created := filepath.Join(dir, "created.go")
+	write(created, "", "new file")
+
+	// Edited by the agent, then by someone else.
+	_, err = files.CreateVersion(t.Context(), sess.ID, conflicting, "agent")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(conflicting, []byte("external"), 0o644))
+
+	changes, err := files.Changes(t.Context(), sess.ID, since)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+
+	conflicts, err := files.Restore(t.Context(), sess.ID, since)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	require.Equal(t, conflicting, conflicts[0].Path)
+
+	content, err := os.ReadFile(edited)
+	require.NoError(t, err)
+	require.Equal(t, "first turn", string(content))
+
+	_, err = os.Stat(created)
+	require.True(t, os.IsNotExist(err))
+
+	content, err = os.ReadFile(untouched)
+	require.NoError(t, err)
+	require.Equal(t, "after first turn", string(content))
+
+	content, err = os.ReadFile(conflicting)
+	require.NoError(t, err)
+	require.Equal(t, "external", string(content))
+
+	latest, err := files.GetByPathAndSession(t.Context(), edited, sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, "first turn", latest.Content)
+	require.Equal(t, int64(3), latest.Version)
+}
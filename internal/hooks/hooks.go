@@ -0,0 +1,187 @@
+// Package hooks runs the user commands configured to run at defined points
+// of an agent run, such as before and after tool calls.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+// Event is a point of an agent run at which hooks run.
+type Event string
+
+const (
+	EventPreToolUse       Event = "pre_tool_use"
+	EventPostToolUse      Event = "post_tool_use"
+	EventUserPromptSubmit Event = "user_prompt_submit"
+	EventStop             Event = "stop"
+	EventSessionStart     Event = "session_start"
+)
+
+// Decision is what a hook decided about a tool call or a prompt.
+type Decision string
+
+const (
+	// DecisionApprove runs the tool call without asking for permission.
+	DecisionApprove Decision = "approve"
+	// DecisionDeny blocks the tool call or the prompt.
+	DecisionDeny Decision = "deny"
+)
+
+// exitCodeDeny is the exit code with which a hook denies the tool call or
+// the prompt, using its standard error as the reason.
+const exitCodeDeny = 2
+
+const defaultTimeout = 60 * time.Second
+
+// Input is written as JSON to the standard input of the hooks.
+type Input struct {
+	Event      Event           `json:"event"`
+	SessionID  string          `json:"session_id"`
+	WorkingDir string          `json:"working_dir"`
+	ToolName   string          `json:"tool_name,omitempty"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+	ToolInput  json.RawMessage `json:"tool_input,omitempty"`
+	ToolResult *ToolResult     `json:"tool_result,omitempty"`
+	Prompt     string          `json:"prompt,omitempty"`
+	Response   string          `json:"response,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+type ToolResult struct {
+	Content string `json:"content"`
+	IsError bool   `json:"is_error"`
+}
+
+// Output is what the hooks can write as JSON to their standard output.
+// Outputs that are empty or not JSON are ignored.
+type Output struct {
+	Decision Decision `json:"decision,omitempty"`
+	// Reason is shown to the model when a tool call is denied, and to the
+	// user when a prompt is denied.
+	Reason string `json:"reason,omitempty"`
+	// ToolInput replaces the input of the tool call.
+	ToolInput json.RawMessage `json:"tool_input,omitempty"`
+	// Feedback is appended to the result of the tool call.
+	Feedback string `json:"feedback,omitempty"`
+}
+
+// Runner runs the configured hooks.
+type Runner struct {
+	hooks      config.Hooks
+	workingDir string
+}
+
+func NewRunner(hooks config.Hooks, workingDir string) *Runner {
+	// The matchers are compiled when the config loads, this only compiles
+	// the hooks that did not come from it.
+	hooks.CompileMatchers()
+	return &Runner{
+		hooks:      hooks,
+		workingDir: workingDir,
+	}
+}
+
+// Has reports whether there are hooks for the given event.
+func (r *Runner) Has(event Event) bool {
+	return len(r.eventHooks(event)) > 0
+}
+
+func (r *Runner) eventHooks(event Event) []config.Hook {
+	switch event {
+	case EventPreToolUse:
+		return r.hooks.PreToolUse
+	case EventPostToolUse:
+		return r.hooks.PostToolUse
+	case EventUserPromptSubmit:
+		return r.hooks.UserPromptSubmit
+	case EventStop:
+		return r.hooks.Stop
+	case EventSessionStart:
+		return r.hooks.SessionStart
+	default:
+		return nil
+	}
+}
+
+// Run runs the hooks of the event one after the other and combines their
+// outputs. The first hook to deny stops the others. A tool input rewritten
+// by a hook is passed on to the next ones, and the feedback of all of them
+// is joined. Hooks that fail are logged and otherwise ignored.
+func (r *Runner) Run(ctx context.Context, input Input) Output {
+	var result Output
+	var feedback []string
+	for _, hook := range r.eventHooks(input.Event) {
+		if !hook.MatchesTool(input.ToolName) {
+			continue
+		}
+		output, err := r.run(ctx, hook, input)
+		if err != nil {
+			slog.Warn("Hook failed", "event", input.Event, "command", hook.Command, "error", err)
+			continue
+		}
+		if output.Feedback != "" {
+			feedback = append(feedback, output.Feedback)
+		}
+		if len(output.ToolInput) > 0 {
+			input.ToolInput = output.ToolInput
+			result.ToolInput = output.ToolInput
+		}
+		switch output.Decision {
+		case DecisionDeny:
+			result.Decision = DecisionDeny
+			result.Reason = output.Reason
+			result.Feedback = strings.Join(feedback, "\n")
+			return result
+		case DecisionApprove:
+			result.Decision = DecisionApprove
+		}
+	}
+	result.Feedback = strings.Join(feedback, "\n")
+	return result
+}
+
+func (r *Runner) run(ctx context.Context, hook config.Hook, input Input) (Output, error) {
+	input.WorkingDir = r.workingDir
+	data, err := json.Marshal(input)
+	if err != nil {
+		return Output{}, err
+	}
+
+	timeout := defaultTimeout
+	if hook.Timeout > 0 {
+		timeout = time.Duration(hook.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	sh := shell.NewShell(&shell.Options{WorkingDir: r.workingDir})
+	stdout, stderr, err := sh.ExecInput(ctx, hook.Command, bytes.NewReader(data))
+	switch code := shell.ExitCode(err); {
+	case shell.IsInterrupt(err):
+		return Output{}, fmt.Errorf("hook timed out or was canceled: %w", err)
+	case code == exitCodeDeny:
+		return Output{
+			Decision: DecisionDeny,
+			Reason:   strings.TrimSpace(stderr),
+		}, nil
+	case code != 0:
+		return Output{}, fmt.Errorf("hook exited with code %d: %s", code, strings.TrimSpace(stderr))
+	}
+
+	var output Output
+	if stdout = strings.TrimSpace(stdout); strings.HasPrefix(stdout, "{") {
+		if err := json.Unmarshal([]byte(stdout), &output); err != nil {
+			return Output{}, fmt.Errorf("invalid hook output: %w", err)
+		}
+	}
+	return output, nil
+}
@@ -0,0 +1,91 @@
+package hooks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner(t *testing.T) {
+	t.Parallel()
+
+	input := Input{
+		Event:     EventPreToolUse,
+		SessionID: "session",
+		ToolName:  "bash",
+		ToolInput: json.RawMessage(`{"command":"ls"}`),
+	}
+
+	tests := []struct {
+		name     string
+		hooks    []config.Hook
+		expected Output
+	}{
+		{
+			name:  "no output",
+			hooks: []config.Hook{{Command: "true"}},
+		},
+		{
+			name:     "approve",
+			hooks:    []config.Hook{{Command: `echo '{"decision":"approve"}'`}},
+			expected: Output{Decision: DecisionApprove},
+		},
+		{
+			name: "deny with exit code",
+			hooks: []config.Hook{
+				{Command: "echo 'not allowed' >&2; exit 2"},
+				{Command: `echo '{"decision":"approve"}'`},
+			},
+			expected: Output{Decision: DecisionDeny, Reason: "not allowed"},
+		},
+		{
+			name:     "reads the input",
+			hooks:    []config.Hook{{Command: `grep -q '"tool_name":"bash"' && echo '{"feedback":"seen"}'`}},
+			expected: Output{Feedback: "seen"},
+		},
+		{
+			name: "matcher",
+			hooks: []config.Hook{
+				{Matcher: "^edit$", Command: `echo '{"decision":"deny"}'`},
+				{Matcher: "^(bash|view)$", Command: `echo '{"feedback":"matched"}'`},
+			},
+			expected: Output{Feedback: "matched"},
+		},
+		{
+			name: "invalid matcher",
+			hooks: []config.Hook{
+				{Matcher: "(bash", Command: `echo '{"decision":"deny"}'`},
+				{Command: `echo '{"feedback":"valid"}'`},
+			},
+			expected: Output{Feedback: "valid"},
+		},
+		{
+			name: "rewrites chain",
+			hooks: []config.Hook{
+				{Command: `echo '{"tool_input":{"command":"ls -la"}}'`},
+				{Command: `grep -q 'ls -la' && echo '{"feedback":"rewritten"}'`},
+			},
+			expected: Output{ToolInput: json.RawMessage(`{"command":"ls -la"}`), Feedback: "rewritten"},
+		},
+		{
+			name: "failing hooks are ignored",
+			hooks: []config.Hook{
+				{Command: "exit 1"},
+				{Command: `echo '{"feedback":"one"}'`},
+				{Command: `echo '{"feedback":"two"}'`},
+			},
+			expected: Output{Feedback: "one\ntwo"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			runner := NewRunner(config.Hooks{PreToolUse: tt.hooks}, t.TempDir())
+			require.True(t, runner.Has(EventPreToolUse))
+			require.False(t, runner.Has(EventStop))
+			require.Equal(t, tt.expected, runner.Run(t.Context(), input))
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -23,7 +24,9 @@ import (
 
 type Client struct {
 	client *powernap.Client
-	name   string
+	// conn sends the requests powernap does not wrap.
+	conn *transport.Connection
+	name string
 
 	// File types this LSP server handles (e.g., .go, .rs, .py)
 	fileTypes []string
@@ -42,6 +45,13 @@ type Client struct {
 
 	// Server state
 	serverState atomic.Value
+
+	// applyEdit applies the edits the server requests while running a
+	// command, see ExecuteCommand.
+	applyEdit atomic.Pointer[ApplyEditFunc]
+	// commandMu serializes the commands, so that the edits the server
+	// requests go to the command that caused them.
+	commandMu sync.Mutex
 }
 
 // New creates a new LSP client using the powernap implementation.
@@ -87,6 +97,7 @@ func New(ctx context.Context, name string, config config.LSPConfig, resolver con
 
 	client := &Client{
 		client:      powernapClient,
+		conn:        serverConnection(powernapClient),
 		name:        name,
 		fileTypes:   config.FileTypes,
 		diagnostics: csync.NewVersionedMap[protocol.DocumentURI, []protocol.Diagnostic](),
@@ -126,7 +137,9 @@ func (c *Client) Initialize(ctx context.Context, workspaceDir string) (*protocol
 		Capabilities: protocolCaps,
 	}
 
-	c.RegisterServerRequestHandler("workspace/applyEdit", HandleApplyEdit)
+	c.RegisterServerRequestHandler("workspace/applyEdit", func(_ context.Context, _ string, params json.RawMessage) (any, error) {
+		return HandleApplyEdit(c, params)
+	})
 	c.RegisterServerRequestHandler("workspace/configuration", HandleWorkspaceConfiguration)
 	c.RegisterServerRequestHandler("client/registerCapability", HandleRegisterCapability)
 	c.RegisterNotificationHandler("window/showMessage", HandleServerMessage)
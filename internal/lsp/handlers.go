@@ -6,7 +6,6 @@ import (
 	"log/slog"
 
 	"github.com/charmbracelet/crush/internal/config"
-	"github.com/charmbracelet/crush/internal/lsp/util"
 	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
 )
 
@@ -44,15 +43,21 @@ func HandleRegisterCapability(_ context.Context, _ string, params json.RawMessag
 	return nil, nil
 }
 
-// HandleApplyEdit handles workspace edit requests
-func HandleApplyEdit(_ context.Context, _ string, params json.RawMessage) (any, error) {
+// HandleApplyEdit handles workspace edit requests. The edits go through the
+// function given to ExecuteCommand, and are rejected when no command is
+// running, as nothing asks for permission to apply them then.
+func HandleApplyEdit(client *Client, params json.RawMessage) (any, error) {
 	var edit protocol.ApplyWorkspaceEditParams
 	if err := json.Unmarshal(params, &edit); err != nil {
 		return nil, err
 	}
 
-	err := util.ApplyWorkspaceEdit(edit.Edit)
-	if err != nil {
+	applyEdit := client.applyEdit.Load()
+	if applyEdit == nil {
+		slog.Warn("Rejecting workspace edit not requested by a command", "server", client.name, "label", edit.Label)
+		return protocol.ApplyWorkspaceEditResult{Applied: false, FailureReason: "workspace edits are only applied while running a command"}, nil
+	}
+	if err := (*applyEdit)(edit.Edit); err != nil {
 		slog.Error("Error applying workspace edit", "error", err)
 		return protocol.ApplyWorkspaceEditResult{Applied: false, FailureReason: err.Error()}, nil
 	}
@@ -0,0 +1,410 @@
+package lsp
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	powernap "github.com/charmbracelet/x/powernap/pkg/lsp"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/charmbracelet/x/powernap/pkg/transport"
+)
+
+// serverConnection returns the JSON-RPC connection of a powernap client.
+//
+// powernap only wraps a few requests, which are sent through it, and keeps
+// its connection unexported. Until it can send any request, the requests it
+// does not wrap are sent over its connection directly. If a powernap update
+// moves the connection, this returns nil and these requests fail as not
+// supported, while the wrapped ones keep working.
+func serverConnection(client *powernap.Client) *transport.Connection {
+	field := reflect.ValueOf(client).Elem().FieldByName("conn")
+	if !field.IsValid() || field.Type() != reflect.TypeFor[*transport.Connection]() {
+		slog.Warn("LSP client connection not found, only the requests powernap supports are available")
+		return nil
+	}
+	return reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().Interface().(*transport.Connection)
+}
+
+// call sends a request to the server and decodes its result.
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	if c.conn == nil {
+		return fmt.Errorf("%s is not supported by the LSP client", method)
+	}
+	if err := c.conn.Call(ctx, method, params, result); err != nil {
+		return fmt.Errorf("%s request failed: %w", method, err)
+	}
+	return nil
+}
+
+// positionParams returns the parameters of a request about the given
+// 1-based position of a file, opening the file first if needed.
+func (c *Client) positionParams(ctx context.Context, filepath string, line, character int) (protocol.TextDocumentPositionParams, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return protocol.TextDocumentPositionParams{}, err
+	}
+	return protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{
+			URI: protocol.URIFromPath(filepath),
+		},
+		Position: protocol.Position{
+			Line:      uint32(max(line-1, 0)),
+			Character: uint32(max(character-1, 0)),
+		},
+	}, nil
+}
+
+// Definition returns the locations where the symbol at the given 1-based
+// position is defined.
+func (c *Client) Definition(ctx context.Context, filepath string, line, character int) ([]protocol.Location, error) {
+	params, err := c.positionParams(ctx, filepath, line, character)
+	if err != nil {
+		return nil, err
+	}
+	var result json.RawMessage
+	if err := c.call(ctx, "textDocument/definition", params, &result); err != nil {
+		return nil, err
+	}
+	return parseLocations(result)
+}
+
+// Hover returns the hover information, usually the signature and the
+// documentation, of the symbol at the given 1-based position.
+func (c *Client) Hover(ctx context.Context, filepath string, line, character int) (string, error) {
+	params, err := c.positionParams(ctx, filepath, line, character)
+	if err != nil {
+		return "", err
+	}
+	hover, err := c.client.RequestHover(ctx, string(params.TextDocument.URI), params.Position)
+	if err == nil {
+		return strings.TrimSpace(hover.Contents.Value), nil
+	}
+	if c.conn == nil {
+		return "", err
+	}
+	// powernap only decodes markup contents, servers answering with the
+	// older marked strings are asked again for the raw contents.
+	slog.Debug("Hover request failed, retrying with the raw contents", "server", c.name, "error", err)
+	var result struct {
+		Contents json.RawMessage `json:"contents"`
+	}
+	if err := c.call(ctx, "textDocument/hover", params, &result); err != nil {
+		return "", err
+	}
+	return hoverText(result.Contents)
+}
+
+// Rename returns the edit renaming the symbol at the given 1-based position
+// to newName across the workspace. The edit is not applied.
+func (c *Client) Rename(ctx context.Context, filepath string, line, character int, newName string) (protocol.WorkspaceEdit, error) {
+	position, err := c.positionParams(ctx, filepath, line, character)
+	if err != nil {
+		return protocol.WorkspaceEdit{}, err
+	}
+	params := protocol.RenameParams{
+		TextDocument: position.TextDocument,
+		Position:     position.Position,
+		NewName:      newName,
+	}
+	var result protocol.WorkspaceEdit
+	if err := c.call(ctx, "textDocument/rename", params, &result); err != nil {
+		return protocol.WorkspaceEdit{}, err
+	}
+	return result, nil
+}
+
+// CodeActions returns the code actions available for a range of a file, given
+// with 1-based lines and columns. The diagnostics of the file overlapping the
+// range are sent along, for the server to offer their fixes. Bare commands
+// are returned as actions running them.
+func (c *Client) CodeActions(ctx context.Context, filepath string, startLine, startColumn, endLine, endColumn int) ([]protocol.CodeAction, error) {
+	start, err := c.positionParams(ctx, filepath, startLine, startColumn)
+	if err != nil {
+		return nil, err
+	}
+	end, err := c.positionParams(ctx, filepath, endLine, endColumn)
+	if err != nil {
+		return nil, err
+	}
+	rng := protocol.Range{Start: start.Position, End: end.Position}
+
+	diagnostics := []protocol.Diagnostic{}
+	fileDiagnostics, _ := c.diagnostics.Get(start.TextDocument.URI)
+	for _, diagnostic := range fileDiagnostics {
+		if rangesIntersect(diagnostic.Range, rng) {
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+
+	params := protocol.CodeActionParams{
+		TextDocument: start.TextDocument,
+		Range:        rng,
+		Context:      protocol.CodeActionContext{Diagnostics: diagnostics},
+	}
+	var result []json.RawMessage
+	if err := c.call(ctx, "textDocument/codeAction", params, &result); err != nil {
+		return nil, err
+	}
+	return parseCodeActions(result)
+}
+
+// ResolveCodeAction fills in the edit of a code action the server left out
+// to be computed on demand.
+func (c *Client) ResolveCodeAction(ctx context.Context, action protocol.CodeAction) (protocol.CodeAction, error) {
+	var result protocol.CodeAction
+	if err := c.call(ctx, "codeAction/resolve", action, &result); err != nil {
+		return action, err
+	}
+	return result, nil
+}
+
+// ApplyEditFunc applies a workspace edit requested by the server.
+type ApplyEditFunc func(edit protocol.WorkspaceEdit) error
+
+// ExecuteCommand runs a command on the server. The edits the server requests
+// while running it are applied through applyEdit.
+func (c *Client) ExecuteCommand(ctx context.Context, command protocol.Command, applyEdit ApplyEditFunc) error {
+	c.commandMu.Lock()
+	defer c.commandMu.Unlock()
+	c.applyEdit.Store(&applyEdit)
+	defer c.applyEdit.Store(nil)
+
+	params := protocol.ExecuteCommandParams{
+		Command:   command.Command,
+		Arguments: command.Arguments,
+	}
+	var result json.RawMessage
+	return c.call(ctx, "workspace/executeCommand", params, &result)
+}
+
+// parseCodeActions decodes a list of code actions and commands.
+func parseCodeActions(items []json.RawMessage) ([]protocol.CodeAction, error) {
+	actions := make([]protocol.CodeAction, 0, len(items))
+	for _, item := range items {
+		var probe struct {
+			Command json.RawMessage `json:"command"`
+		}
+		if err := json.Unmarshal(item, &probe); err != nil {
+			return nil, err
+		}
+		// The command of a code action is an object, a bare command has the
+		// name of the command instead.
+		if len(probe.Command) > 0 && probe.Command[0] == '"' {
+			var command protocol.Command
+			if err := json.Unmarshal(item, &command); err != nil {
+				return nil, err
+			}
+			actions = append(actions, protocol.CodeAction{Title: command.Title, Command: &command})
+			continue
+		}
+		var action protocol.CodeAction
+		if err := json.Unmarshal(item, &action); err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// rangesIntersect reports whether two ranges overlap or touch, so that an
+// empty range at a diagnostic matches it.
+func rangesIntersect(a, b protocol.Range) bool {
+	return !positionBefore(a.End, b.Start) && !positionBefore(b.End, a.Start)
+}
+
+func positionBefore(a, b protocol.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
+
+// SupportsFormatting reports whether the server can format whole files.
+func (c *Client) SupportsFormatting() bool {
+	provider := c.client.GetCapabilities().DocumentFormattingProvider
+	return provider != nil && provider.Value != nil && provider.Value != false
+}
+
+// Format returns the edits formatting a file. The server formats the content
+// it was last notified of.
+func (c *Client) Format(ctx context.Context, filepath string, content string) ([]protocol.TextEdit, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	insertSpaces, tabSize := indentation(content)
+	params := protocol.DocumentFormattingParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+		Options: protocol.FormattingOptions{
+			TabSize:      tabSize,
+			InsertSpaces: insertSpaces,
+		},
+	}
+	var result []protocol.TextEdit
+	if err := c.call(ctx, "textDocument/formatting", params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// indentation guesses how content is indented: with tabs, or with spaces and
+// how many per level. Formatters of languages with a standard style ignore
+// it, the others keep the style of the file with it.
+func indentation(content string) (insertSpaces bool, tabSize uint32) {
+	for line := range strings.Lines(content) {
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			return false, 4
+		case strings.HasPrefix(line, " "):
+			width := uint32(len(line) - len(strings.TrimLeft(line, " ")))
+			if strings.TrimSpace(line) != "" && (tabSize == 0 || width < tabSize) {
+				tabSize = width
+			}
+		}
+	}
+	if tabSize == 0 {
+		return false, 4
+	}
+	return true, tabSize
+}
+
+// DocumentSymbols returns the symbols of a file, in order, with nested
+// symbols flattened and named by their container.
+func (c *Client) DocumentSymbols(ctx context.Context, filepath string) ([]protocol.SymbolInformation, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	uri := protocol.URIFromPath(filepath)
+	params := protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	}
+	var result []symbolResult
+	if err := c.call(ctx, "textDocument/documentSymbol", params, &result); err != nil {
+		return nil, err
+	}
+	return flattenSymbols(nil, result, uri, ""), nil
+}
+
+// WorkspaceSymbols returns the symbols of the workspace matching the query,
+// which servers usually match fuzzily.
+func (c *Client) WorkspaceSymbols(ctx context.Context, query string) ([]protocol.SymbolInformation, error) {
+	params := protocol.WorkspaceSymbolParams{Query: query}
+	var result []symbolResult
+	if err := c.call(ctx, "workspace/symbol", params, &result); err != nil {
+		return nil, err
+	}
+	return flattenSymbols(nil, result, "", ""), nil
+}
+
+// symbolResult holds any of the forms of symbols servers answer with: a
+// DocumentSymbol, a SymbolInformation or a WorkspaceSymbol, whose location
+// may have no range.
+type symbolResult struct {
+	Name          string              `json:"name"`
+	Kind          protocol.SymbolKind `json:"kind"`
+	ContainerName string              `json:"containerName,omitempty"`
+	Location      *struct {
+		URI   protocol.DocumentURI `json:"uri"`
+		Range protocol.Range       `json:"range"`
+	} `json:"location,omitempty"`
+	SelectionRange *protocol.Range `json:"selectionRange,omitempty"`
+	Children       []symbolResult  `json:"children,omitempty"`
+}
+
+// flattenSymbols appends the symbols and their children to out. uri and
+// container are used for the symbols that do not have their own.
+func flattenSymbols(out []protocol.SymbolInformation, symbols []symbolResult, uri protocol.DocumentURI, container string) []protocol.SymbolInformation {
+	for _, symbol := range symbols {
+		info := protocol.SymbolInformation{
+			Name:          symbol.Name,
+			Kind:          symbol.Kind,
+			ContainerName: cmp.Or(symbol.ContainerName, container),
+			Location:      protocol.Location{URI: uri},
+		}
+		if symbol.Location != nil {
+			info.Location = protocol.Location{URI: symbol.Location.URI, Range: symbol.Location.Range}
+		}
+		if symbol.SelectionRange != nil {
+			info.Location.Range = *symbol.SelectionRange
+		}
+		out = append(out, info)
+		out = flattenSymbols(out, symbol.Children, info.Location.URI, symbol.Name)
+	}
+	return out
+}
+
+// parseLocations decodes a location, a list of locations or a list of
+// location links, the forms servers answer definition requests with.
+func parseLocations(raw json.RawMessage) ([]protocol.Location, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var items []json.RawMessage
+	if raw[0] == '[' {
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, err
+		}
+	} else {
+		items = []json.RawMessage{raw}
+	}
+	locations := make([]protocol.Location, 0, len(items))
+	for _, item := range items {
+		var link struct {
+			protocol.Location
+			TargetURI            protocol.DocumentURI `json:"targetUri"`
+			TargetSelectionRange *protocol.Range      `json:"targetSelectionRange"`
+		}
+		if err := json.Unmarshal(item, &link); err != nil {
+			return nil, err
+		}
+		if link.TargetURI != "" {
+			link.URI = link.TargetURI
+			if link.TargetSelectionRange != nil {
+				link.Range = *link.TargetSelectionRange
+			}
+		}
+		if link.URI == "" {
+			return nil, errors.New("location without uri")
+		}
+		locations = append(locations, link.Location)
+	}
+	return locations, nil
+}
+
+// hoverText returns the text of hover contents, which can be markup, a
+// marked string or a list of marked strings.
+func hoverText(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	var items []json.RawMessage
+	if raw[0] == '[' {
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return "", err
+		}
+	} else {
+		items = []json.RawMessage{raw}
+	}
+	var parts []string
+	for _, item := range items {
+		var text string
+		if err := json.Unmarshal(item, &text); err == nil {
+			parts = append(parts, text)
+			continue
+		}
+		var content struct {
+			Language string `json:"language"`
+			Value    string `json:"value"`
+		}
+		if err := json.Unmarshal(item, &content); err != nil {
+			return "", err
+		}
+		if content.Language != "" {
+			content.Value = fmt.Sprintf("```%s\n%s\n```", content.Language, content.Value)
+		}
+		parts = append(parts, content.Value)
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n\n")), nil
+}
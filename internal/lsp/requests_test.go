@@ -0,0 +1,192 @@
+package lsp
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	powernap "github.com/charmbracelet/x/powernap/pkg/lsp"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/charmbracelet/x/powernap/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerConnection(t *testing.T) {
+	t.Parallel()
+
+	client, err := powernap.NewClient(powernap.ClientConfig{Command: "cat"})
+	if err != nil {
+		t.Skipf("cat is not available: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Exit() })
+
+	require.NotNil(t, serverConnection(client))
+	// The requests powernap does not wrap depend on its connection field,
+	// this fails when an update moves it.
+	field, ok := reflect.TypeFor[powernap.Client]().FieldByName("conn")
+	require.True(t, ok)
+	require.Equal(t, reflect.TypeFor[*transport.Connection](), field.Type)
+}
+
+func TestParseLocations(t *testing.T) {
+	t.Parallel()
+
+	location := protocol.Location{
+		URI: "file:///src/main.go",
+		Range: protocol.Range{
+			Start: protocol.Position{Line: 4, Character: 5},
+			End:   protocol.Position{Line: 4, Character: 9},
+		},
+	}
+
+	for _, raw := range []string{
+		`{"uri":"file:///src/main.go","range":{"start":{"line":4,"character":5},"end":{"line":4,"character":9}}}`,
+		`[{"uri":"file:///src/main.go","range":{"start":{"line":4,"character":5},"end":{"line":4,"character":9}}}]`,
+		`[{"targetUri":"file:///src/main.go","targetRange":{"start":{"line":3,"character":0},"end":{"line":6,"character":1}},"targetSelectionRange":{"start":{"line":4,"character":5},"end":{"line":4,"character":9}}}]`,
+	} {
+		locations, err := parseLocations(json.RawMessage(raw))
+		require.NoError(t, err)
+		require.Equal(t, []protocol.Location{location}, locations)
+	}
+
+	locations, err := parseLocations(json.RawMessage(`null`))
+	require.NoError(t, err)
+	require.Empty(t, locations)
+}
+
+func TestHoverText(t *testing.T) {
+	t.Parallel()
+
+	for raw, want := range map[string]string{
+		`{"kind":"markdown","value":"func Foo() error\n\nFoo does things."}`: "func Foo() error\n\nFoo does things.",
+		`"plain text"`: "plain text",
+		`[{"language":"go","value":"func Foo() error"},"Foo does things."]`: "```go\nfunc Foo() error\n```\n\nFoo does things.",
+		`null`: "",
+	} {
+		text, err := hoverText(json.RawMessage(raw))
+		require.NoError(t, err)
+		require.Equal(t, want, text)
+	}
+}
+
+func TestFlattenSymbols(t *testing.T) {
+	t.Parallel()
+
+	at := func(line uint32) protocol.Range {
+		return protocol.Range{Start: protocol.Position{Line: line}, End: protocol.Position{Line: line}}
+	}
+
+	var document []symbolResult
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"name":"Server","kind":23,"range":{"start":{"line":2},"end":{"line":5}},"selectionRange":{"start":{"line":2},"end":{"line":2}},"children":[
+			{"name":"addr","kind":8,"range":{"start":{"line":3},"end":{"line":3}},"selectionRange":{"start":{"line":3},"end":{"line":3}}}
+		]}
+	]`), &document))
+	require.Equal(t, []protocol.SymbolInformation{
+		{Name: "Server", Kind: protocol.Struct, Location: protocol.Location{URI: "file:///src/main.go", Range: at(2)}},
+		{Name: "addr", Kind: protocol.Field, ContainerName: "Server", Location: protocol.Location{URI: "file:///src/main.go", Range: at(3)}},
+	}, flattenSymbols(nil, document, "file:///src/main.go", ""))
+
+	var workspace []symbolResult
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"name":"Serve","kind":6,"containerName":"Server","location":{"uri":"file:///src/server.go","range":{"start":{"line":7},"end":{"line":7}}}},
+		{"name":"main","kind":12,"location":{"uri":"file:///src/main.go"}}
+	]`), &workspace))
+	require.Equal(t, []protocol.SymbolInformation{
+		{Name: "Serve", Kind: protocol.Method, ContainerName: "Server", Location: protocol.Location{URI: "file:///src/server.go", Range: at(7)}},
+		{Name: "main", Kind: protocol.Function, Location: protocol.Location{URI: "file:///src/main.go"}},
+	}, flattenSymbols(nil, workspace, "", ""))
+}
+
+func TestParseCodeActions(t *testing.T) {
+	t.Parallel()
+
+	var items []json.RawMessage
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"title":"Add import: \"fmt\"","kind":"quickfix","isPreferred":true,"edit":{"changes":{"file:///src/main.go":[{"range":{"start":{"line":2,"character":0},"end":{"line":2,"character":0}},"newText":"import \"fmt\"\n"}]}}},
+		{"title":"Fill Server","kind":"refactor.rewrite","command":{"title":"Fill Server","command":"gopls.apply_fix","arguments":["fill_struct"]}},
+		{"title":"Run tests","command":"gopls.run_tests","arguments":[{"URI":"file:///src/main_test.go"}]}
+	]`), &items))
+
+	actions, err := parseCodeActions(items)
+	require.NoError(t, err)
+	require.Len(t, actions, 3)
+
+	require.Equal(t, protocol.CodeActionKind("quickfix"), actions[0].Kind)
+	require.True(t, actions[0].IsPreferred)
+	require.NotNil(t, actions[0].Edit)
+	require.Len(t, actions[0].Edit.Changes["file:///src/main.go"], 1)
+
+	require.Equal(t, "Fill Server", actions[1].Title)
+	require.Equal(t, "gopls.apply_fix", actions[1].Command.Command)
+
+	require.Equal(t, "Run tests", actions[2].Title)
+	require.Empty(t, actions[2].Kind)
+	require.Equal(t, "gopls.run_tests", actions[2].Command.Command)
+	require.Len(t, actions[2].Command.Arguments, 1)
+}
+
+func TestRangesIntersect(t *testing.T) {
+	t.Parallel()
+
+	at := func(startLine, startChar, endLine, endChar uint32) protocol.Range {
+		return protocol.Range{
+			Start: protocol.Position{Line: startLine, Character: startChar},
+			End:   protocol.Position{Line: endLine, Character: endChar},
+		}
+	}
+
+	require.True(t, rangesIntersect(at(4, 2, 4, 10), at(4, 5, 4, 5)))
+	require.True(t, rangesIntersect(at(4, 2, 4, 10), at(4, 10, 4, 10)))
+	require.True(t, rangesIntersect(at(1, 0, 9, 0), at(4, 2, 4, 10)))
+	require.False(t, rangesIntersect(at(4, 2, 4, 10), at(4, 11, 4, 11)))
+	require.False(t, rangesIntersect(at(4, 2, 4, 10), at(5, 0, 5, 3)))
+}
+
+func TestIndentation(t *testing.T) {
+	t.Parallel()
+
+	for content, want := range map[string]struct {
+		insertSpaces bool
+		tabSize      uint32
+	}{
+		"package main\n\nfunc main() {\n\tprintln()\n}\n":  {false, 4},
+		"function main() {\n  if (x) {\n    y()\n  }\n}\n": {true, 2},
+		"def main():\n    pass\n":                          {true, 4},
+		"no indentation\n":                                 {false, 4},
+	} {
+		insertSpaces, tabSize := indentation(content)
+		require.Equal(t, want.insertSpaces, insertSpaces, content)
+		require.Equal(t, want.tabSize, tabSize, content)
+	}
+}
+
+func TestHandleApplyEdit(t *testing.T) {
+	t.Parallel()
+
+	params, err := json.Marshal(protocol.ApplyWorkspaceEditParams{
+		Label: "Organize Imports",
+		Edit: protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				"file:///tmp/main.go": {{NewText: "package main\n"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	client := &Client{name: "test"}
+	result, err := HandleApplyEdit(client, params)
+	require.NoError(t, err)
+	require.False(t, result.(protocol.ApplyWorkspaceEditResult).Applied)
+
+	var applied []protocol.WorkspaceEdit
+	applyEdit := ApplyEditFunc(func(edit protocol.WorkspaceEdit) error {
+		applied = append(applied, edit)
+		return nil
+	})
+	client.applyEdit.Store(&applyEdit)
+	result, err = HandleApplyEdit(client, params)
+	require.NoError(t, err)
+	require.True(t, result.(protocol.ApplyWorkspaceEditResult).Applied)
+	require.Len(t, applied, 1)
+}
@@ -1,9 +1,9 @@
 package util
 
 import (
-	"bytes"
 	"fmt"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 
@@ -22,25 +22,39 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
+	newContent, err := editContent(string(content), edits)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, []byte(newContent), 0o644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// editContent returns the content with the edits applied.
+func editContent(content string, edits []protocol.TextEdit) (string, error) {
 	// Detect line ending style
 	var lineEnding string
-	if bytes.Contains(content, []byte("\r\n")) {
+	if strings.Contains(content, "\r\n") {
 		lineEnding = "\r\n"
 	} else {
 		lineEnding = "\n"
 	}
 
 	// Track if file ends with a newline
-	endsWithNewline := len(content) > 0 && bytes.HasSuffix(content, []byte(lineEnding))
+	endsWithNewline := len(content) > 0 && strings.HasSuffix(content, lineEnding)
 
 	// Split into lines without the endings
-	lines := strings.Split(string(content), lineEnding)
+	lines := strings.Split(content, lineEnding)
 
 	// Check for overlapping edits
 	for i, edit1 := range edits {
 		for j := i + 1; j < len(edits); j++ {
 			if rangesOverlap(edit1.Range, edits[j].Range) {
-				return fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
+				return "", fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
 			}
 		}
 	}
@@ -59,7 +73,7 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 	for _, edit := range sortedEdits {
 		newLines, err := applyTextEdit(lines, edit)
 		if err != nil {
-			return fmt.Errorf("failed to apply edit: %w", err)
+			return "", fmt.Errorf("failed to apply edit: %w", err)
 		}
 		lines = newLines
 	}
@@ -78,11 +92,7 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 		newContent.WriteString(lineEnding)
 	}
 
-	if err := os.WriteFile(path, []byte(newContent.String()), 0o644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return nil
+	return newContent.String(), nil
 }
 
 func applyTextEdit(lines []string, edit protocol.TextEdit) ([]string, error) {
@@ -247,6 +257,158 @@ func ApplyWorkspaceEdit(edit protocol.WorkspaceEdit) error {
 	return nil
 }
 
+// FileChange is the change of a file by a WorkspaceEdit. Files that do not
+// exist have an empty content.
+type FileChange struct {
+	Path       string
+	OldContent string
+	NewContent string
+	// Created and Deleted are set when a file operation of the edit creates
+	// or deletes the file, or renames it from or to its path.
+	Created bool
+	Deleted bool
+}
+
+// PreviewWorkspaceEdit returns the changes the given WorkspaceEdit makes to
+// each file, sorted by path, without applying them. The file operations are
+// previewed along with the text edits, except the ones on directories.
+func PreviewWorkspaceEdit(edit protocol.WorkspaceEdit) ([]FileChange, error) {
+	type file struct {
+		change          FileChange
+		existed, exists bool
+	}
+	files := make(map[string]*file)
+	// get returns the state of the file at the given URI, or nil if it is a
+	// directory.
+	get := func(uri protocol.DocumentURI) (*file, error) {
+		path, err := uri.Path()
+		if err != nil {
+			return nil, fmt.Errorf("invalid URI: %w", err)
+		}
+		if f, ok := files[path]; ok {
+			return f, nil
+		}
+		f := &file{change: FileChange{Path: path}}
+		info, err := os.Stat(path)
+		switch {
+		case err == nil && info.IsDir():
+			return nil, nil
+		case err == nil:
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read file: %w", err)
+			}
+			f.existed, f.exists = true, true
+			f.change.OldContent = string(content)
+			f.change.NewContent = string(content)
+		case !os.IsNotExist(err):
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		files[path] = f
+		return f, nil
+	}
+	apply := func(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
+		f, err := get(uri)
+		if err != nil {
+			return err
+		}
+		if f == nil || !f.exists {
+			return fmt.Errorf("failed to read file: %s is not a file", uri)
+		}
+		f.change.NewContent, err = editContent(f.change.NewContent, edits)
+		return err
+	}
+
+	for uri, textEdits := range edit.Changes {
+		if err := apply(uri, textEdits); err != nil {
+			return nil, fmt.Errorf("failed to preview text edits: %w", err)
+		}
+	}
+	for _, change := range edit.DocumentChanges {
+		switch {
+		case change.CreateFile != nil:
+			f, err := get(change.CreateFile.URI)
+			if err != nil {
+				return nil, err
+			}
+			options := change.CreateFile.Options
+			if f == nil || f.exists && options != nil && !options.Overwrite && options.IgnoreIfExists {
+				continue
+			}
+			f.exists = true
+			f.change.NewContent = ""
+		case change.DeleteFile != nil:
+			f, err := get(change.DeleteFile.URI)
+			if err != nil {
+				return nil, err
+			}
+			if f == nil {
+				continue
+			}
+			f.exists = false
+			f.change.NewContent = ""
+		case change.RenameFile != nil:
+			from, err := get(change.RenameFile.OldURI)
+			if err != nil {
+				return nil, err
+			}
+			to, err := get(change.RenameFile.NewURI)
+			if err != nil {
+				return nil, err
+			}
+			if from == nil || to == nil {
+				continue
+			}
+			if options := change.RenameFile.Options; to.exists && options != nil && !options.Overwrite {
+				return nil, fmt.Errorf("target file already exists and overwrite is not allowed: %s", to.change.Path)
+			}
+			to.exists, to.change.NewContent = from.exists, from.change.NewContent
+			from.exists, from.change.NewContent = false, ""
+		case change.TextDocumentEdit != nil:
+			textEdits := make([]protocol.TextEdit, len(change.TextDocumentEdit.Edits))
+			for i, edit := range change.TextDocumentEdit.Edits {
+				var err error
+				textEdits[i], err = edit.AsTextEdit()
+				if err != nil {
+					return nil, fmt.Errorf("invalid edit type: %w", err)
+				}
+			}
+			if err := apply(change.TextDocumentEdit.TextDocument.URI, textEdits); err != nil {
+				return nil, fmt.Errorf("failed to preview document change: %w", err)
+			}
+		}
+	}
+
+	result := make([]FileChange, 0, len(files))
+	for _, f := range files {
+		f.change.Created = !f.existed && f.exists
+		f.change.Deleted = f.existed && !f.exists
+		if f.change.OldContent != f.change.NewContent || f.change.Created || f.change.Deleted {
+			result = append(result, f.change)
+		}
+	}
+	slices.SortFunc(result, func(a, b FileChange) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return result, nil
+}
+
+// CheckFileChanges returns an error if a file no longer is as it was when the
+// changes were previewed, so that an edit is not applied over changes made
+// in the meantime.
+func CheckFileChanges(changes []FileChange) error {
+	for _, change := range changes {
+		content, err := os.ReadFile(change.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+		if exists := err == nil; exists == change.Created || string(content) != change.OldContent {
+			return fmt.Errorf("%s was modified since the edit was prepared", change.Path)
+		}
+	}
+	return nil
+}
+
 func rangesOverlap(r1, r2 protocol.Range) bool {
 	if r1.Start.Line > r2.End.Line || r2.Start.Line > r1.End.Line {
 		return false
@@ -0,0 +1,120 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewWorkspaceEdit(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.go")
+	b := filepath.Join(dir, "b.go")
+	require.NoError(t, os.WriteFile(a, []byte("package a\n\nfunc Old() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(b, []byte("package a\n\nvar x = Old\n"), 0o644))
+
+	rename := func(line, start uint32) protocol.TextEdit {
+		return protocol.TextEdit{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: line, Character: start},
+				End:   protocol.Position{Line: line, Character: start + 3},
+			},
+			NewText: "New",
+		}
+	}
+	edit := protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			protocol.URIFromPath(b): {rename(2, 8)},
+		},
+		DocumentChanges: []protocol.DocumentChange{{
+			TextDocumentEdit: &protocol.TextDocumentEdit{
+				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+					TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(a)},
+				},
+				Edits: []protocol.Or_TextDocumentEdit_edits_Elem{{Value: rename(2, 5)}},
+			},
+		}},
+	}
+
+	changes, err := PreviewWorkspaceEdit(edit)
+	require.NoError(t, err)
+	require.Equal(t, []FileChange{
+		{Path: a, OldContent: "package a\n\nfunc Old() {}\n", NewContent: "package a\n\nfunc New() {}\n"},
+		{Path: b, OldContent: "package a\n\nvar x = Old\n", NewContent: "package a\n\nvar x = New\n"},
+	}, changes)
+
+	// The preview leaves the files untouched.
+	content, err := os.ReadFile(a)
+	require.NoError(t, err)
+	require.Equal(t, "package a\n\nfunc Old() {}\n", string(content))
+
+	require.NoError(t, ApplyWorkspaceEdit(edit))
+	for _, change := range changes {
+		content, err := os.ReadFile(change.Path)
+		require.NoError(t, err)
+		require.Equal(t, change.NewContent, string(content))
+	}
+}
+
+func TestPreviewWorkspaceEditFileOperations(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old.go")
+	renamed := filepath.Join(dir, "new.go")
+	deleted := filepath.Join(dir, "deleted.go")
+	created := filepath.Join(dir, "created.go")
+	require.NoError(t, os.WriteFile(old, []byte("package a\n"), 0o644))
+	require.NoError(t, os.WriteFile(deleted, []byte("package b\n"), 0o644))
+
+	edit := protocol.WorkspaceEdit{
+		DocumentChanges: []protocol.DocumentChange{
+			{RenameFile: &protocol.RenameFile{OldURI: protocol.URIFromPath(old), NewURI: protocol.URIFromPath(renamed)}},
+			{TextDocumentEdit: &protocol.TextDocumentEdit{
+				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+					TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(renamed)},
+				},
+				Edits: []protocol.Or_TextDocumentEdit_edits_Elem{{Value: protocol.TextEdit{
+					Range: protocol.Range{
+						Start: protocol.Position{Line: 0, Character: 8},
+						End:   protocol.Position{Line: 0, Character: 9},
+					},
+					NewText: "c",
+				}}},
+			}},
+			{DeleteFile: &protocol.DeleteFile{URI: protocol.URIFromPath(deleted)}},
+			{CreateFile: &protocol.CreateFile{URI: protocol.URIFromPath(created)}},
+		},
+	}
+
+	changes, err := PreviewWorkspaceEdit(edit)
+	require.NoError(t, err)
+	require.Equal(t, []FileChange{
+		{Path: created, Created: true},
+		{Path: deleted, OldContent: "package b\n", Deleted: true},
+		{Path: renamed, NewContent: "package c\n", Created: true},
+		{Path: old, OldContent: "package a\n", Deleted: true},
+	}, changes)
+
+	// Files changed after the preview are caught before the edit is applied.
+	require.NoError(t, CheckFileChanges(changes))
+	require.NoError(t, os.WriteFile(deleted, []byte("package b\n\nvar x = 1\n"), 0o644))
+	require.Error(t, CheckFileChanges(changes))
+	require.NoError(t, os.WriteFile(deleted, []byte("package b\n"), 0o644))
+
+	require.NoError(t, ApplyWorkspaceEdit(edit))
+	for _, change := range changes {
+		content, err := os.ReadFile(change.Path)
+		if change.Deleted {
+			require.True(t, os.IsNotExist(err))
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, change.NewContent, string(content))
+	}
+}
@@ -0,0 +1,194 @@
+// Package memory stores the durable facts about a project the agent saved
+// with the memory tool, such as build quirks, conventions and decisions.
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileName is the name of the file in the data directory holding the
+// memories of the project.
+const FileName = "memories.json"
+
+var ErrNotFound = errors.New("memory not found")
+
+// Memory is a fact about the project.
+type Memory struct {
+	ID        string    `json:"id"`
+	Content   string    `json:"content"`
+	SessionID string    `json:"session_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// mu serializes the changes to the memory files, which the tool, the prompt
+// and the TUI access through their own stores.
+var mu sync.Mutex
+
+// Store reads and writes the memories of a project.
+type Store struct {
+	path string
+	now  func() time.Time
+}
+
+// NewStore returns the store of the memories kept in the given data
+// directory.
+func NewStore(dataDir string) *Store {
+	return &Store{
+		path: filepath.Join(dataDir, FileName),
+		now:  time.Now,
+	}
+}
+
+// List returns the memories, oldest first.
+func (s *Store) List() ([]Memory, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return s.load()
+}
+
+// Add saves a new memory created in the given session.
+func (s *Store) Add(content, sessionID string) (Memory, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return Memory{}, errors.New("memory content is empty")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	memories, err := s.load()
+	if err != nil {
+		return Memory{}, err
+	}
+	now := s.now()
+	memory := Memory{
+		ID:        newID(memories),
+		Content:   content,
+		SessionID: sessionID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	return memory, s.save(append(memories, memory))
+}
+
+// Update replaces the content of a memory.
+func (s *Store) Update(id, content string) (Memory, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return Memory{}, errors.New("memory content is empty")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	memories, err := s.load()
+	if err != nil {
+		return Memory{}, err
+	}
+	i := slices.IndexFunc(memories, func(m Memory) bool { return m.ID == id })
+	if i < 0 {
+		return Memory{}, ErrNotFound
+	}
+	memories[i].Content = content
+	memories[i].UpdatedAt = s.now()
+	return memories[i], s.save(memories)
+}
+
+// Delete removes a memory.
+func (s *Store) Delete(id string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	memories, err := s.load()
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(memories, func(m Memory) bool { return m.ID == id })
+	if i < 0 {
+		return ErrNotFound
+	}
+	return s.save(slices.Delete(memories, i, i+1))
+}
+
+func (s *Store) load() ([]Memory, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memories: %w", err)
+	}
+	var memories []Memory
+	if err := json.Unmarshal(data, &memories); err != nil {
+		return nil, fmt.Errorf("failed to parse memories: %w", err)
+	}
+	return memories, nil
+}
+
+// save writes the memories to a temporary file first, so a crash never
+// leaves a truncated file behind.
+func (s *Store) save(memories []Memory) error {
+	if memories == nil {
+		memories = []Memory{}
+	}
+	data, err := json.MarshalIndent(memories, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode memories: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write memories: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write memories: %w", err)
+	}
+	return nil
+}
+
+// newID returns a short ID, easy for the model to repeat, that no other
+// memory uses.
+func newID(memories []Memory) string {
+	for {
+		id := uuid.NewString()[:8]
+		if !slices.ContainsFunc(memories, func(m Memory) bool { return m.ID == id }) {
+			return id
+		}
+	}
+}
+
+// WithinBudget returns the most recently updated memories whose content fits
+// in the given number of tokens, in the order of the list. Tokens are
+// estimated at four bytes each.
+func WithinBudget(memories []Memory, maxTokens int) []Memory {
+	recent := slices.Clone(memories)
+	slices.SortStableFunc(recent, func(a, b Memory) int {
+		return b.UpdatedAt.Compare(a.UpdatedAt)
+	})
+	kept := make(map[string]bool)
+	var tokens int
+	for _, m := range recent {
+		tokens += len(m.Content) / 4
+		if tokens > maxTokens {
+			break
+		}
+		kept[m.ID] = true
+	}
+	var result []Memory
+	for _, m := range memories {
+		if kept[m.ID] {
+			result = append(result, m)
+		}
+	}
+	return result
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	store := NewStore(t.TempDir())
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	memories, err := store.List()
+	require.NoError(t, err)
+	require.Empty(t, memories)
+
+	build, err := store.Add("  Run `task test` before committing.\n", "session-1")
+	require.NoError(t, err)
+	require.Equal(t, "Run `task test` before committing.", build.Content)
+	require.Equal(t, "session-1", build.SessionID)
+	require.Len(t, build.ID, 8)
+
+	style, err := store.Add("Errors are wrapped with fmt.Errorf.", "session-2")
+	require.NoError(t, err)
+	require.NotEqual(t, build.ID, style.ID)
+
+	_, err = store.Add(" ", "session-2")
+	require.Error(t, err)
+
+	now = now.Add(time.Hour)
+	updated, err := store.Update(build.ID, "Run `task test:race` before committing.")
+	require.NoError(t, err)
+	require.Equal(t, "session-1", updated.SessionID)
+	require.Equal(t, build.CreatedAt, updated.CreatedAt)
+	require.Equal(t, now, updated.UpdatedAt)
+
+	_, err = store.Update("missing", "content")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, store.Delete(style.ID))
+	require.ErrorIs(t, store.Delete(style.ID), ErrNotFound)
+
+	memories, err = NewStore(t.TempDir()).List()
+	require.NoError(t, err)
+	require.Empty(t, memories)
+
+	memories, err = store.List()
+	require.NoError(t, err)
+	require.Equal(t, []Memory{updated}, memories)
+}
+
+func TestWithinBudget(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	memories := []Memory{
+		{ID: "old", Content: strings.Repeat("a", 40), UpdatedAt: day},
+		{ID: "edited", Content: strings.Repeat("b", 40), UpdatedAt: day.Add(2 * time.Hour)},
+		{ID: "new", Content: strings.Repeat("c", 40), UpdatedAt: day.Add(time.Hour)},
+	}
+
+	ids := func(memories []Memory) []string {
+		var result []string
+		for _, m := range memories {
+			result = append(result, m.ID)
+		}
+		return result
+	}
+	require.Equal(t, []string{"old", "edited", "new"}, ids(WithinBudget(memories, 30)))
+	require.Equal(t, []string{"edited", "new"}, ids(WithinBudget(memories, 25)))
+	require.Equal(t, []string{"edited"}, ids(WithinBudget(memories, 10)))
+	require.Empty(t, WithinBudget(memories, 5))
+}
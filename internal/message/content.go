@@ -33,6 +33,10 @@ const (
 	FinishReasonCanceled         FinishReason = "canceled"
 	FinishReasonError            FinishReason = "error"
 	FinishReasonPermissionDenied FinishReason = "permission_denied"
+	FinishReasonBudgetExceeded   FinishReason = "budget_exceeded"
+	// The turn was cut short by crush stopping, and recovered on the next
+	// start.
+	FinishReasonInterrupted FinishReason = "interrupted"
 
 	// Should never happen
 	FinishReasonUnknown FinishReason = "unknown"
@@ -242,6 +246,17 @@ func (m *Message) AppendContent(delta string) {
 	}
 }
 
+// SetContent replaces the text of the message.
+func (m *Message) SetContent(text string) {
+	for i, part := range m.Parts {
+		if _, ok := part.(TextContent); ok {
+			m.Parts[i] = TextContent{Text: text}
+			return
+		}
+	}
+	m.Parts = append(m.Parts, TextContent{Text: text})
+}
+
 func (m *Message) AppendReasoningContent(delta string) {
 	found := false
 	for i, part := range m.Parts {
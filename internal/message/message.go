@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/charmbracelet/crush/internal/db"
@@ -28,6 +29,16 @@ type Service interface {
 	List(ctx context.Context, sessionID string) ([]Message, error)
 	Delete(ctx context.Context, id string) error
 	DeleteSessionMessages(ctx context.Context, sessionID string) error
+	// Rewind removes the given message and everything after it from the
+	// session. When keepBranch is true the messages are kept in the database
+	// as a hidden branch instead of being deleted. It returns the removed
+	// messages.
+	Rewind(ctx context.Context, sessionID, messageID string, keepBranch bool) ([]Message, error)
+	// Recover finishes the last agent turn of the session if crush stopped
+	// in the middle of it, so the history of the session is valid again. It
+	// must only be called when no agent is running in the session. It
+	// reports whether the turn was interrupted.
+	Recover(ctx context.Context, sessionID string) (bool, error)
 }
 
 type service struct {
@@ -109,6 +120,41 @@ func (s *service) DeleteSessionMessages(ctx context.Context, sessionID string) e
 	return nil
 }
 
+func (s *service) Rewind(ctx context.Context, sessionID, messageID string, keepBranch bool) ([]Message, error) {
+	messages, err := s.List(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(messages, func(m Message) bool {
+		return m.ID == messageID
+	})
+	if idx == -1 {
+		return nil, fmt.Errorf("message %s not found in session %s", messageID, sessionID)
+	}
+	removed := messages[idx:]
+	if !keepBranch {
+		for _, message := range removed {
+			if err := s.Delete(ctx, message.ID); err != nil {
+				return nil, err
+			}
+		}
+		return removed, nil
+	}
+
+	branchID := sql.NullString{String: uuid.New().String(), Valid: true}
+	for _, message := range removed {
+		if err := s.q.HideMessage(ctx, db.HideMessageParams{
+			BranchID: branchID,
+			ID:       message.ID,
+		}); err != nil {
+			return nil, err
+		}
+		// Hidden messages are gone as far as subscribers are concerned.
+		s.Publish(pubsub.DeletedEvent, message.Clone())
+	}
+	return removed, nil
+}
+
 func (s *service) Update(ctx context.Context, message Message) error {
 	parts, err := marshallParts(message.Parts)
 	if err != nil {
@@ -0,0 +1,153 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewind(t *testing.T) {
+	t.Parallel()
+
+	for _, keepBranch := range []bool{false, true} {
+		conn, err := db.Connect(t.Context(), t.TempDir())
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		q := db.New(conn)
+		messages := NewService(q)
+		sess, err := q.CreateSession(t.Context(), db.CreateSessionParams{
+			ID:    uuid.NewString(),
+			Title: "Session",
+		})
+		require.NoError(t, err)
+
+		create := func(role MessageRole, text string) Message {
+			msg, err := messages.Create(t.Context(), sess.ID, CreateMessageParams{
+				Role:  role,
+				Parts: []ContentPart{TextContent{Text: text}},
+			})
+			require.NoError(t, err)
+			return msg
+		}
+		first := create(User, "first")
+		create(Assistant, "first answer")
+		second := create(User, "second")
+		secondAnswer := create(Assistant, "second answer")
+
+		removed, err := messages.Rewind(t.Context(), sess.ID, second.ID, keepBranch)
+		require.NoError(t, err)
+		require.Len(t, removed, 2)
+		require.Equal(t, second.ID, removed[0].ID)
+
+		remaining, err := messages.List(t.Context(), sess.ID)
+		require.NoError(t, err)
+		require.Len(t, remaining, 2)
+		require.Equal(t, first.ID, remaining[0].ID)
+
+		_, err = messages.Get(t.Context(), secondAnswer.ID)
+		if keepBranch {
+			require.NoError(t, err)
+		} else {
+			require.Error(t, err)
+		}
+
+		_, err = messages.Rewind(t.Context(), sess.ID, second.ID, keepBranch)
+		require.Error(t, err)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	messages := NewService(q)
+	createSession := func() string {
+		sess, err := q.CreateSession(t.Context(), db.CreateSessionParams{
+			ID:    uuid.NewString(),
+			Title: "Session",
+		})
+		require.NoError(t, err)
+		return sess.ID
+	}
+	create := func(sessionID string, role MessageRole, parts ...ContentPart) Message {
+		msg, err := messages.Create(t.Context(), sessionID, CreateMessageParams{
+			Role:  role,
+			Parts: parts,
+		})
+		require.NoError(t, err)
+		return msg
+	}
+	finish := func(msg Message, reason FinishReason) {
+		msg.AddFinish(reason, "", "")
+		require.NoError(t, messages.Update(t.Context(), msg))
+	}
+
+	// Finished turn.
+	done := createSession()
+	create(done, User, TextContent{Text: "hello"})
+	finish(create(done, Assistant, TextContent{Text: "hi"}), FinishReasonEndTurn)
+
+	// Stopped while streaming a tool call.
+	streaming := createSession()
+	create(streaming, User, TextContent{Text: "list files"})
+	streamingMsg := create(streaming, Assistant,
+		TextContent{Text: "Let me look"},
+		ToolCall{ID: "call-1", Name: "ls", Input: `{"pa`},
+	)
+
+	// Stopped while running tools, after one of them returned.
+	running := createSession()
+	create(running, User, TextContent{Text: "read files"})
+	runningMsg := create(running, Assistant,
+		ToolCall{ID: "call-2", Name: "view", Input: `{}`, Finished: true},
+		ToolCall{ID: "call-3", Name: "view", Input: `{}`, Finished: true},
+	)
+	finish(runningMsg, FinishReasonToolUse)
+	create(running, Tool, ToolResult{ToolCallID: "call-2", Name: "view", Content: "file"})
+
+	recoverSession := func(sessionID string) bool {
+		interrupted, err := messages.Recover(t.Context(), sessionID)
+		require.NoError(t, err)
+		return interrupted
+	}
+	require.False(t, recoverSession(done))
+	require.True(t, recoverSession(streaming))
+	require.True(t, recoverSession(running))
+
+	results := func(sessionID string) map[string]ToolResult {
+		msgs, err := messages.List(t.Context(), sessionID)
+		require.NoError(t, err)
+		found := make(map[string]ToolResult)
+		for _, msg := range msgs {
+			for _, result := range msg.ToolResults() {
+				found[result.ToolCallID] = result
+			}
+		}
+		return found
+	}
+
+	recovered, err := messages.Get(t.Context(), streamingMsg.ID)
+	require.NoError(t, err)
+	require.Equal(t, FinishReasonInterrupted, recovered.FinishReason())
+	require.True(t, recovered.ToolCalls()[0].Finished)
+	require.Equal(t, "{}", recovered.ToolCalls()[0].Input)
+	require.True(t, results(streaming)["call-1"].IsError)
+
+	recovered, err = messages.Get(t.Context(), runningMsg.ID)
+	require.NoError(t, err)
+	require.Equal(t, FinishReasonInterrupted, recovered.FinishReason())
+	require.False(t, results(running)["call-2"].IsError)
+	require.True(t, results(running)["call-3"].IsError)
+	require.Empty(t, results(done))
+
+	// Recovered turns are finished.
+	require.False(t, recoverSession(streaming))
+	require.False(t, recoverSession(running))
+}
@@ -0,0 +1,77 @@
+package message
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
+
+const interruptedToolResult = "Tool execution was interrupted"
+
+func (s *service) Recover(ctx context.Context, sessionID string) (bool, error) {
+	messages, err := s.List(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+	var last *Message
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == Assistant {
+			last = &messages[i]
+			break
+		}
+	}
+	// A turn ends with a finish other than a tool use, the agent would have
+	// taken another step otherwise.
+	if last == nil || last.IsFinished() && last.FinishReason() != FinishReasonToolUse {
+		return false, nil
+	}
+	if err := s.recoverMessage(ctx, *last, messages); err != nil {
+		return false, fmt.Errorf("failed to recover message %s: %w", last.ID, err)
+	}
+	slog.Info("Recovered interrupted message", "session_id", sessionID, "message_id", last.ID)
+	return true, nil
+}
+
+// recoverMessage marks the message as interrupted and answers the tool calls
+// left without a result with an error, as providers reject tool calls
+// without results.
+func (s *service) recoverMessage(ctx context.Context, msg Message, messages []Message) error {
+	answered := make(map[string]bool)
+	for _, m := range messages {
+		for _, result := range m.ToolResults() {
+			answered[result.ToolCallID] = true
+		}
+	}
+
+	msg.FinishThinking()
+	var results []ContentPart
+	for _, call := range msg.ToolCalls() {
+		if !call.Finished || !json.Valid([]byte(call.Input)) {
+			call.Finished = true
+			call.Input = "{}"
+			msg.AddToolCall(call)
+		}
+		if answered[call.ID] {
+			continue
+		}
+		results = append(results, ToolResult{
+			ToolCallID: call.ID,
+			Name:       call.Name,
+			Content:    interruptedToolResult,
+			IsError:    true,
+		})
+	}
+	msg.AddFinish(FinishReasonInterrupted, "Interrupted", "Crush stopped before the agent finished its turn.")
+	if err := s.Update(ctx, msg); err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	_, err := s.Create(ctx, msg.SessionID, CreateMessageParams{
+		Role:  Tool,
+		Parts: results,
+	})
+	return err
+}
@@ -26,6 +26,7 @@ type CreatePermissionRequest struct {
 }
 
 type PermissionNotification struct {
+	SessionID  string `json:"session_id"`
 	ToolCallID string `json:"tool_call_id"`
 	Granted    bool   `json:"granted"`
 	Denied     bool   `json:"denied"`
@@ -49,6 +50,12 @@ type Service interface {
 	Deny(permission PermissionRequest)
 	Request(opts CreatePermissionRequest) bool
 	AutoApproveSession(sessionID string)
+	// ApproveToolCall grants the next permission request of the given tool
+	// call without asking.
+	ApproveToolCall(toolCallID string)
+	// ForgetToolCall drops the approval of a tool call that finished without
+	// requesting permission.
+	ForgetToolCall(toolCallID string)
 	SetSkipRequests(skip bool)
 	SkipRequests() bool
 	SubscribeNotifications(ctx context.Context) <-chan pubsub.Event[PermissionNotification]
@@ -64,6 +71,7 @@ type permissionService struct {
 	pendingRequests       *csync.Map[string, chan bool]
 	autoApproveSessions   map[string]bool
 	autoApproveSessionsMu sync.RWMutex
+	approvedToolCalls     *csync.Map[string, bool]
 	skip                  bool
 	allowedTools          []string
 
@@ -74,6 +82,7 @@ type permissionService struct {
 
 func (s *permissionService) GrantPersistent(permission PermissionRequest) {
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+		SessionID:  permission.SessionID,
 		ToolCallID: permission.ToolCallID,
 		Granted:    true,
 	})
@@ -93,6 +102,7 @@ func (s *permissionService) GrantPersistent(permission PermissionRequest) {
 
 func (s *permissionService) Grant(permission PermissionRequest) {
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+		SessionID:  permission.SessionID,
 		ToolCallID: permission.ToolCallID,
 		Granted:    true,
 	})
@@ -108,6 +118,7 @@ func (s *permissionService) Grant(permission PermissionRequest) {
 
 func (s *permissionService) Deny(permission PermissionRequest) {
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+		SessionID:  permission.SessionID,
 		ToolCallID: permission.ToolCallID,
 		Granted:    false,
 		Denied:     true,
@@ -124,11 +135,15 @@ func (s *permissionService) Deny(permission PermissionRequest) {
 
 func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	if s.skip {
-		return true
+		return s.autoGrant(opts)
+	}
+	if _, ok := s.approvedToolCalls.Take(opts.ToolCallID); ok {
+		return s.autoGrant(opts)
 	}
 
 	// tell the UI that a permission was requested
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+		SessionID:  opts.SessionID,
 		ToolCallID: opts.ToolCallID,
 	})
 	s.requestMu.Lock()
@@ -137,7 +152,7 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	// Check if the tool/action combination is in the allowlist
 	commandKey := opts.ToolName + ":" + opts.Action
 	if slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName) {
-		return true
+		return s.autoGrant(opts)
 	}
 
 	s.autoApproveSessionsMu.RLock()
@@ -145,7 +160,7 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	s.autoApproveSessionsMu.RUnlock()
 
 	if autoApprove {
-		return true
+		return s.autoGrant(opts)
 	}
 
 	fileInfo, err := os.Stat(opts.Path)
@@ -176,7 +191,7 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	for _, p := range s.sessionPermissions {
 		if p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path {
 			s.sessionPermissionsMu.RUnlock()
-			return true
+			return s.autoGrant(opts)
 		}
 	}
 	s.sessionPermissionsMu.RUnlock()
@@ -185,7 +200,7 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	for _, p := range s.sessionPermissions {
 		if p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path {
 			s.sessionPermissionsMu.RUnlock()
-			return true
+			return s.autoGrant(opts)
 		}
 	}
 	s.sessionPermissionsMu.RUnlock()
@@ -202,12 +217,31 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	return <-respCh
 }
 
+// autoGrant tells the subscribers that the request was granted without
+// asking, so that every request ends with a decision.
+func (s *permissionService) autoGrant(opts CreatePermissionRequest) bool {
+	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+		SessionID:  opts.SessionID,
+		ToolCallID: opts.ToolCallID,
+		Granted:    true,
+	})
+	return true
+}
+
 func (s *permissionService) AutoApproveSession(sessionID string) {
 	s.autoApproveSessionsMu.Lock()
 	s.autoApproveSessions[sessionID] = true
 	s.autoApproveSessionsMu.Unlock()
 }
 
+func (s *permissionService) ApproveToolCall(toolCallID string) {
+	s.approvedToolCalls.Set(toolCallID, true)
+}
+
+func (s *permissionService) ForgetToolCall(toolCallID string) {
+	s.approvedToolCalls.Del(toolCallID)
+}
+
 func (s *permissionService) SubscribeNotifications(ctx context.Context) <-chan pubsub.Event[PermissionNotification] {
 	return s.notificationBroker.Subscribe(ctx)
 }
@@ -230,5 +264,6 @@ func NewPermissionService(workingDir string, skip bool, allowedTools []string) S
 		skip:                skip,
 		allowedTools:        allowedTools,
 		pendingRequests:     csync.NewMap[string, chan bool](),
+		approvedToolCalls:   csync.NewMap[string, bool](),
 	}
 }
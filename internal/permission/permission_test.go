@@ -1,6 +1,7 @@
 package permission
 
 import (
+	"context"
 	"sync"
 	"testing"
 
@@ -94,6 +95,53 @@ func TestPermissionService_SkipMode(t *testing.T) {
 	}
 }
 
+func TestPermissionService_ApproveToolCall(t *testing.T) {
+	service := NewPermissionService("/tmp", false, []string{})
+	service.ApproveToolCall("call-1")
+
+	result := service.Request(CreatePermissionRequest{
+		SessionID:   "test-session",
+		ToolCallID:  "call-1",
+		ToolName:    "bash",
+		Action:      "execute",
+		Description: "test command",
+		Path:        "/tmp",
+	})
+
+	if !result {
+		t.Error("expected permission to be granted for the approved tool call")
+	}
+
+	service.ApproveToolCall("call-2")
+	service.ForgetToolCall("call-2")
+	if _, ok := service.(*permissionService).approvedToolCalls.Get("call-2"); ok {
+		t.Error("expected the approval of the finished tool call to be dropped")
+	}
+}
+
+func TestPermissionService_AutoGrantNotifications(t *testing.T) {
+	service := NewPermissionService("/tmp", false, []string{"view"})
+	service.AutoApproveSession("auto-session")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	notifications := service.SubscribeNotifications(ctx)
+
+	for _, req := range []CreatePermissionRequest{
+		{SessionID: "test-session", ToolCallID: "call-1", ToolName: "view", Action: "read", Path: "/tmp"},
+		{SessionID: "auto-session", ToolCallID: "call-2", ToolName: "bash", Action: "execute", Path: "/tmp"},
+	} {
+		if !service.Request(req) {
+			t.Fatalf("expected permission to be granted for %s", req.ToolCallID)
+		}
+
+		pending := (<-notifications).Payload
+		granted := (<-notifications).Payload
+		assert.Equal(t, PermissionNotification{SessionID: req.SessionID, ToolCallID: req.ToolCallID}, pending)
+		assert.Equal(t, PermissionNotification{SessionID: req.SessionID, ToolCallID: req.ToolCallID, Granted: true}, granted)
+	}
+}
+
 func TestPermissionService_SequentialProperties(t *testing.T) {
 	t.Run("Sequential permission requests with persistent grants", func(t *testing.T) {
 		service := NewPermissionService("/tmp", false, []string{})
@@ -0,0 +1,95 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// QueuedPrompt is a prompt sent while the agent was busy with the session.
+type QueuedPrompt struct {
+	ID          string
+	Prompt      string
+	Attachments []message.Attachment
+	// Steer sends the prompt to the agent before its next step instead of
+	// waiting for the current run to finish.
+	Steer     bool
+	CreatedAt int64
+}
+
+type queuedAttachment struct {
+	FilePath string `json:"file_path"`
+	FileName string `json:"file_name"`
+	MimeType string `json:"mime_type"`
+	Content  []byte `json:"content"`
+}
+
+func (s *service) QueuedPrompts(ctx context.Context, sessionID string) ([]QueuedPrompt, error) {
+	dbPrompts, err := s.q.ListQueuedPromptsBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	prompts := make([]QueuedPrompt, len(dbPrompts))
+	for i, dbPrompt := range dbPrompts {
+		var attachments []queuedAttachment
+		if err := json.Unmarshal([]byte(dbPrompt.Attachments), &attachments); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal attachments of queued prompt %s: %w", dbPrompt.ID, err)
+		}
+		prompts[i] = QueuedPrompt{
+			ID:        dbPrompt.ID,
+			Prompt:    dbPrompt.Prompt,
+			Steer:     dbPrompt.Steer != 0,
+			CreatedAt: dbPrompt.CreatedAt,
+		}
+		for _, attachment := range attachments {
+			prompts[i].Attachments = append(prompts[i].Attachments, message.Attachment(attachment))
+		}
+	}
+	return prompts, nil
+}
+
+func (s *service) SaveQueuedPrompts(ctx context.Context, sessionID string, prompts []QueuedPrompt) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := s.q.WithTx(tx)
+
+	if err := qtx.DeleteQueuedPromptsBySession(ctx, sessionID); err != nil {
+		return err
+	}
+	for i, prompt := range prompts {
+		attachments := make([]queuedAttachment, len(prompt.Attachments))
+		for j, attachment := range prompt.Attachments {
+			attachments[j] = queuedAttachment(attachment)
+		}
+		attachmentsJSON, err := json.Marshal(attachments)
+		if err != nil {
+			return err
+		}
+		steer := int64(0)
+		if prompt.Steer {
+			steer = 1
+		}
+		if err := qtx.CreateQueuedPrompt(ctx, db.CreateQueuedPromptParams{
+			ID:          prompt.ID,
+			SessionID:   sessionID,
+			Position:    int64(i),
+			Prompt:      prompt.Prompt,
+			Attachments: string(attachmentsJSON),
+			Steer:       steer,
+			CreatedAt:   prompt.CreatedAt,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
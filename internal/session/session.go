@@ -6,10 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/crush/internal/db"
 	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/google/uuid"
 )
@@ -29,17 +31,26 @@ type Todo struct {
 }
 
 type Session struct {
-	ID               string
-	ParentSessionID  string
-	Title            string
-	MessageCount     int64
-	PromptTokens     int64
-	CompletionTokens int64
-	SummaryMessageID string
-	Cost             float64
-	Todos            []Todo
-	CreatedAt        int64
-	UpdatedAt        int64
+	ID                  string
+	ParentSessionID     string
+	ForkedFromMessageID string
+	Title               string
+	MessageCount        int64
+	PromptTokens        int64
+	CompletionTokens    int64
+	SummaryMessageID    string
+	Cost                float64
+	Todos               []Todo
+	Plan                string
+	PlanMode            bool
+	ContextFiles        []string
+	CreatedAt           int64
+	UpdatedAt           int64
+}
+
+// IsFork reports whether the session was forked from another one.
+func (s Session) IsFork() bool {
+	return s.ForkedFromMessageID != ""
 }
 
 type Service interface {
@@ -47,11 +58,21 @@ type Service interface {
 	Create(ctx context.Context, title string) (Session, error)
 	CreateTitleSession(ctx context.Context, parentSessionID string) (Session, error)
 	CreateTaskSession(ctx context.Context, toolCallID, parentSessionID, title string) (Session, error)
+	// Fork creates a new session with the conversation of the given session
+	// up to and including the given message.
+	Fork(ctx context.Context, sessionID, messageID string) (Session, error)
 	Get(ctx context.Context, id string) (Session, error)
 	List(ctx context.Context) ([]Session, error)
+	ListChildren(ctx context.Context, parentSessionID string) ([]Session, error)
 	Save(ctx context.Context, session Session) (Session, error)
 	UpdateTitleAndUsage(ctx context.Context, sessionID, title string, promptTokens, completionTokens int64, cost float64) error
 	Delete(ctx context.Context, id string) error
+	// QueuedPrompts returns the prompts waiting to be sent in the session,
+	// in order.
+	QueuedPrompts(ctx context.Context, sessionID string) ([]QueuedPrompt, error)
+	// SaveQueuedPrompts replaces the prompts waiting to be sent in the
+	// session.
+	SaveQueuedPrompts(ctx context.Context, sessionID string, prompts []QueuedPrompt) error
 
 	// Agent tool session management
 	CreateAgentToolSessionID(messageID, toolCallID string) string
@@ -61,7 +82,8 @@ type Service interface {
 
 type service struct {
 	*pubsub.Broker[Session]
-	q db.Querier
+	db *sql.DB
+	q  *db.Queries
 }
 
 func (s *service) Create(ctx context.Context, title string) (Session, error) {
@@ -106,6 +128,176 @@ func (s *service) CreateTitleSession(ctx context.Context, parentSessionID string
 	return session, nil
 }
 
+// Fork copies the messages of the session up to the given message, along
+// with the results of the tool calls it made, into a new child session. The
+// file history versions recorded by the end of that turn are copied as well,
+// so the fork can be continued independently of the original session.
+func (s *service) Fork(ctx context.Context, sessionID, messageID string) (Session, error) {
+	parent, err := s.Get(ctx, sessionID)
+	if err != nil {
+		return Session{}, err
+	}
+	dbMessages, err := s.q.ListMessagesBySession(ctx, sessionID)
+	if err != nil {
+		return Session{}, err
+	}
+	last := slices.IndexFunc(dbMessages, func(m db.Message) bool {
+		return m.ID == messageID
+	})
+	if last == -1 {
+		return Session{}, fmt.Errorf("message %s not found in session %s", messageID, sessionID)
+	}
+	// Keep the tool results of the message, otherwise the tool calls in the
+	// forked conversation would be left without an answer.
+	for last+1 < len(dbMessages) && dbMessages[last+1].Role == string(message.Tool) {
+		last++
+	}
+	keepFile, err := s.forkedFiles(ctx, sessionID, dbMessages, last)
+	if err != nil {
+		return Session{}, err
+	}
+	dbMessages = dbMessages[:last+1]
+
+	dbFiles, err := s.q.ListFilesBySession(ctx, sessionID)
+	if err != nil {
+		return Session{}, err
+	}
+
+	todosJSON, err := marshalTodos(parent.Todos)
+	if err != nil {
+		return Session{}, err
+	}
+	planMode := int64(0)
+	if parent.PlanMode {
+		planMode = 1
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+	qtx := s.q.WithTx(tx)
+
+	dbSession, err := qtx.CreateForkSession(ctx, db.CreateForkSessionParams{
+		ID:                  uuid.New().String(),
+		ParentSessionID:     sql.NullString{String: parent.ID, Valid: true},
+		ForkedFromMessageID: sql.NullString{String: messageID, Valid: true},
+		Title:               parent.Title,
+		Todos:               sql.NullString{String: todosJSON, Valid: todosJSON != ""},
+		Plan:                sql.NullString{String: parent.Plan, Valid: parent.Plan != ""},
+		PlanMode:            planMode,
+	})
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to create fork: %w", err)
+	}
+
+	var summaryMessageID string
+	for _, msg := range dbMessages {
+		id := uuid.New().String()
+		if msg.ID == parent.SummaryMessageID {
+			summaryMessageID = id
+		}
+		if err := qtx.CopyMessage(ctx, db.CopyMessageParams{
+			ID:               id,
+			SessionID:        dbSession.ID,
+			Role:             msg.Role,
+			Parts:            msg.Parts,
+			Model:            msg.Model,
+			Provider:         msg.Provider,
+			IsSummaryMessage: msg.IsSummaryMessage,
+			CreatedAt:        msg.CreatedAt,
+			UpdatedAt:        msg.UpdatedAt,
+			FinishedAt:       msg.FinishedAt,
+		}); err != nil {
+			return Session{}, fmt.Errorf("failed to copy message: %w", err)
+		}
+	}
+
+	for _, file := range dbFiles {
+		if !keepFile(file) {
+			continue
+		}
+		if err := qtx.CopyFile(ctx, db.CopyFileParams{
+			ID:        uuid.New().String(),
+			SessionID: dbSession.ID,
+			Path:      file.Path,
+			Content:   file.Content,
+			Version:   file.Version,
+			CreatedAt: file.CreatedAt,
+			UpdatedAt: file.UpdatedAt,
+		}); err != nil {
+			return Session{}, fmt.Errorf("failed to copy file history: %w", err)
+		}
+	}
+
+	if summaryMessageID != "" {
+		dbSession, err = qtx.UpdateSession(ctx, db.UpdateSessionParams{
+			ID:               dbSession.ID,
+			Title:            dbSession.Title,
+			SummaryMessageID: sql.NullString{String: summaryMessageID, Valid: true},
+			Todos:            dbSession.Todos,
+			Plan:             dbSession.Plan,
+			PlanMode:         dbSession.PlanMode,
+			ContextFiles:     dbSession.ContextFiles,
+		})
+		if err != nil {
+			return Session{}, fmt.Errorf("failed to update fork: %w", err)
+		}
+	} else if dbSession, err = qtx.GetSessionByID(ctx, dbSession.ID); err != nil {
+		return Session{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Session{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	session := s.fromDBItem(dbSession)
+	s.Publish(pubsub.CreatedEvent, session)
+	event.SessionCreated()
+	return session, nil
+}
+
+// forkedFiles returns whether a file version of the session existed when
+// the turn of dbMessages[last] ended. That is when the next prompt started,
+// as recorded by its checkpoint.
+func (s *service) forkedFiles(ctx context.Context, sessionID string, dbMessages []db.Message, last int) (func(db.File) bool, error) {
+	next := slices.IndexFunc(dbMessages[last+1:], func(m db.Message) bool {
+		return m.Role == string(message.User)
+	})
+	if next == -1 {
+		// The turn is the latest one, everything recorded so far belongs to
+		// it.
+		return func(db.File) bool { return true }, nil
+	}
+	prompt := dbMessages[last+1+next]
+
+	checkpoints, err := s.q.ListCheckpointsBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(checkpoints, func(c db.Checkpoint) bool {
+		return c.MessageID == prompt.ID
+	})
+	if idx == -1 {
+		// Prompts sent before checkpoints were recorded have none, fall
+		// back to the time of the prompt.
+		return func(file db.File) bool {
+			return file.CreatedAt < prompt.CreatedAt
+		}, nil
+	}
+	// The versions are a history.Versions, the history tests import this
+	// package so it cannot import history.
+	var versions map[string]int64
+	if err := json.Unmarshal([]byte(checkpoints[idx].FileVersions), &versions); err != nil {
+		return nil, fmt.Errorf("failed to read file versions of checkpoint %s: %w", checkpoints[idx].ID, err)
+	}
+	return func(file db.File) bool {
+		version, ok := versions[file.Path]
+		return ok && file.Version <= version
+	}, nil
+}
+
 func (s *service) Delete(ctx context.Context, id string) error {
 	session, err := s.Get(ctx, id)
 	if err != nil {
@@ -133,6 +325,14 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 	if err != nil {
 		return Session{}, err
 	}
+	planMode := int64(0)
+	if session.PlanMode {
+		planMode = 1
+	}
+	contextFilesJSON, err := marshalContextFiles(session.ContextFiles)
+	if err != nil {
+		return Session{}, err
+	}
 
 	dbSession, err := s.q.UpdateSession(ctx, db.UpdateSessionParams{
 		ID:               session.ID,
@@ -148,6 +348,15 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 			String: todosJSON,
 			Valid:  todosJSON != "",
 		},
+		Plan: sql.NullString{
+			String: session.Plan,
+			Valid:  session.Plan != "",
+		},
+		PlanMode: planMode,
+		ContextFiles: sql.NullString{
+			String: contextFilesJSON,
+			Valid:  contextFilesJSON != "",
+		},
 	})
 	if err != nil {
 		return Session{}, err
@@ -181,23 +390,43 @@ func (s *service) List(ctx context.Context) ([]Session, error) {
 	return sessions, nil
 }
 
+func (s *service) ListChildren(ctx context.Context, parentSessionID string) ([]Session, error) {
+	dbSessions, err := s.q.ListChildSessions(ctx, sql.NullString{String: parentSessionID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]Session, len(dbSessions))
+	for i, dbSession := range dbSessions {
+		sessions[i] = s.fromDBItem(dbSession)
+	}
+	return sessions, nil
+}
+
 func (s service) fromDBItem(item db.Session) Session {
 	todos, err := unmarshalTodos(item.Todos.String)
 	if err != nil {
 		slog.Error("failed to unmarshal todos", "session_id", item.ID, "error", err)
 	}
+	contextFiles, err := unmarshalContextFiles(item.ContextFiles.String)
+	if err != nil {
+		slog.Error("failed to unmarshal context files", "session_id", item.ID, "error", err)
+	}
 	return Session{
-		ID:               item.ID,
-		ParentSessionID:  item.ParentSessionID.String,
-		Title:            item.Title,
-		MessageCount:     item.MessageCount,
-		PromptTokens:     item.PromptTokens,
-		CompletionTokens: item.CompletionTokens,
-		SummaryMessageID: item.SummaryMessageID.String,
-		Cost:             item.Cost,
-		Todos:            todos,
-		CreatedAt:        item.CreatedAt,
-		UpdatedAt:        item.UpdatedAt,
+		ID:                  item.ID,
+		ParentSessionID:     item.ParentSessionID.String,
+		ForkedFromMessageID: item.ForkedFromMessageID.String,
+		Title:               item.Title,
+		MessageCount:        item.MessageCount,
+		PromptTokens:        item.PromptTokens,
+		CompletionTokens:    item.CompletionTokens,
+		SummaryMessageID:    item.SummaryMessageID.String,
+		Cost:                item.Cost,
+		Todos:               todos,
+		Plan:                item.Plan.String,
+		PlanMode:            item.PlanMode != 0,
+		ContextFiles:        contextFiles,
+		CreatedAt:           item.CreatedAt,
+		UpdatedAt:           item.UpdatedAt,
 	}
 }
 
@@ -223,10 +452,33 @@ func unmarshalTodos(data string) ([]Todo, error) {
 	return todos, nil
 }
 
-func NewService(q db.Querier) Service {
+func marshalContextFiles(paths []string) (string, error) {
+	if len(paths) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(paths)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func unmarshalContextFiles(data string) ([]string, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var paths []string
+	if err := json.Unmarshal([]byte(data), &paths); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+func NewService(q *db.Queries, db *sql.DB) Service {
 	broker := pubsub.NewBroker[Session]()
 	return &service{
 		broker,
+		db,
 		q,
 	}
 }
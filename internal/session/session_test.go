@@ -0,0 +1,87 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/checkpoint"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFork(t *testing.T) {
+	t.Parallel()
+
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	sessions := NewService(q, conn)
+	messages := message.NewService(q)
+	files := history.NewService(q, conn)
+	checkpoints := checkpoint.NewService(q, files)
+
+	parent, err := sessions.Create(t.Context(), "Parent")
+	require.NoError(t, err)
+
+	create := func(role message.MessageRole, parts ...message.ContentPart) message.Message {
+		msg, err := messages.Create(t.Context(), parent.ID, message.CreateMessageParams{
+			Role:  role,
+			Parts: parts,
+		})
+		require.NoError(t, err)
+		return msg
+	}
+	prompt := func(text string) {
+		msg := create(message.User, message.TextContent{Text: text})
+		_, err := checkpoints.Create(t.Context(), parent.ID, msg.ID, msg.CreatedAt)
+		require.NoError(t, err)
+	}
+	// Both turns edit the file within the same second.
+	prompt("first")
+	_, err = files.Create(t.Context(), parent.ID, "main.go", "package main")
+	require.NoError(t, err)
+	forkPoint := create(message.Assistant, message.ToolCall{ID: "call-1", Name: "view", Finished: true})
+	create(message.Tool, message.ToolResult{ToolCallID: "call-1", Name: "view", Content: "file"})
+	prompt("second")
+	_, err = files.CreateVersion(t.Context(), parent.ID, "main.go", "package main\n\nfunc main() {}")
+	require.NoError(t, err)
+	latest := create(message.Assistant, message.TextContent{Text: "answer"})
+
+	fork, err := sessions.Fork(t.Context(), parent.ID, forkPoint.ID)
+	require.NoError(t, err)
+	require.True(t, fork.IsFork())
+	require.Equal(t, parent.ID, fork.ParentSessionID)
+	require.Equal(t, forkPoint.ID, fork.ForkedFromMessageID)
+
+	forkMessages, err := messages.List(t.Context(), fork.ID)
+	require.NoError(t, err)
+	require.Len(t, forkMessages, 3)
+	require.Equal(t, message.User, forkMessages[0].Role)
+	require.Equal(t, "first", forkMessages[0].Content().Text)
+	require.Equal(t, message.Assistant, forkMessages[1].Role)
+	require.NotEqual(t, forkPoint.ID, forkMessages[1].ID)
+	require.Equal(t, message.Tool, forkMessages[2].Role)
+	require.Equal(t, "call-1", forkMessages[2].ToolResults()[0].ToolCallID)
+
+	forkFiles, err := files.ListBySession(t.Context(), fork.ID)
+	require.NoError(t, err)
+	require.Len(t, forkFiles, 1)
+	require.Equal(t, "package main", forkFiles[0].Content)
+
+	// The latest turn keeps every version.
+	latestFork, err := sessions.Fork(t.Context(), parent.ID, latest.ID)
+	require.NoError(t, err)
+	forkFiles, err = files.ListBySession(t.Context(), latestFork.ID)
+	require.NoError(t, err)
+	require.Len(t, forkFiles, 2)
+
+	all, err := sessions.List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+
+	_, err = sessions.Fork(t.Context(), parent.ID, "missing")
+	require.Error(t, err)
+}
@@ -0,0 +1,101 @@
+package symbols
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+)
+
+func isGoFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".go")
+}
+
+// outlineGo returns the declarations of a Go file, using the same kinds as
+// gopls. The symbols get displayPath as their path.
+func outlineGo(path, displayPath string) ([]Symbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var symbols []Symbol
+	add := func(ident *ast.Ident, kind, container string) {
+		if ident == nil || ident.Name == "_" {
+			return
+		}
+		symbols = append(symbols, Symbol{
+			Name:      ident.Name,
+			Kind:      kind,
+			Container: container,
+			Path:      displayPath,
+			Line:      fset.Position(ident.Pos()).Line,
+		})
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				add(decl.Name, "method", receiverName(decl.Recv.List[0].Type))
+			} else {
+				add(decl.Name, "function", "")
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					addType(spec, add)
+				case *ast.ValueSpec:
+					kind := "variable"
+					if decl.Tok == token.CONST {
+						kind = "constant"
+					}
+					for _, name := range spec.Names {
+						add(name, kind, "")
+					}
+				}
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// addType adds a type along with its fields or methods.
+func addType(spec *ast.TypeSpec, add func(*ast.Ident, string, string)) {
+	switch typ := spec.Type.(type) {
+	case *ast.StructType:
+		add(spec.Name, "struct", "")
+		for _, field := range typ.Fields.List {
+			for _, name := range field.Names {
+				add(name, "field", spec.Name.Name)
+			}
+		}
+	case *ast.InterfaceType:
+		add(spec.Name, "interface", "")
+		for _, method := range typ.Methods.List {
+			for _, name := range method.Names {
+				add(name, "method", spec.Name.Name)
+			}
+		}
+	default:
+		add(spec.Name, "class", "")
+	}
+}
+
+// receiverName returns the name of the type of a method receiver.
+func receiverName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(expr.X)
+	case *ast.IndexExpr:
+		return receiverName(expr.X)
+	case *ast.IndexListExpr:
+		return receiverName(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	}
+	return ""
+}
@@ -0,0 +1,323 @@
+// Package symbols finds the symbols, such as functions, types and fields,
+// of a file or of the whole workspace. It asks the LSP servers and falls
+// back to a built-in outline of Go files when no server is configured for
+// them.
+package symbols
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/sahilm/fuzzy"
+)
+
+// maxOutlineFiles is the number of files outlined to search the workspace
+// without an LSP server.
+const maxOutlineFiles = 1000
+
+// outlineRefreshInterval is how often the workspace is checked for changed
+// Go files when it is searched without an LSP server.
+const outlineRefreshInterval = 5 * time.Second
+
+var ErrUnsupported = errors.New("no LSP server handles the file and it has no built-in outline")
+
+// Symbol is a named element of a file.
+type Symbol struct {
+	Name string
+	// Kind is the kind of the symbol as named by LSP, such as "function" or
+	// "struct".
+	Kind string
+	// Container is the name of the symbol holding this one, if any, such as
+	// the type of a method.
+	Container string
+	// Path is the file of the symbol, relative to the working directory when
+	// it is inside it.
+	Path string
+	// Line is the 1-based line of the symbol, 0 if unknown.
+	Line int
+}
+
+// QualifiedName returns the name of the symbol prefixed by its container.
+func (s Symbol) QualifiedName() string {
+	if s.Container == "" {
+		return s.Name
+	}
+	return s.Container + "." + s.Name
+}
+
+// Location returns the "file:line" of the symbol.
+func (s Symbol) Location() string {
+	if s.Line == 0 {
+		return s.Path
+	}
+	return fmt.Sprintf("%s:%d", s.Path, s.Line)
+}
+
+// String returns the symbol as "kind name file:line".
+func (s Symbol) String() string {
+	return fmt.Sprintf("%s %s %s", s.Kind, s.QualifiedName(), s.Location())
+}
+
+// Index finds the symbols of the workspace. The Go files are outlined once
+// and outlined again when they change, so searching them stays cheap.
+type Index struct {
+	clients    *csync.Map[string, *lsp.Client]
+	workingDir string
+	// outlineGo is set when no LSP server is configured for Go.
+	outlineGo bool
+
+	mu        sync.Mutex
+	outlines  map[string]outline
+	symbols   []Symbol
+	refreshed time.Time
+}
+
+type outline struct {
+	modTime time.Time
+	symbols []Symbol
+}
+
+// NewIndex returns an index of the workspace in workingDir. The configured
+// LSP servers decide whether Go files are outlined by the index itself.
+func NewIndex(clients *csync.Map[string, *lsp.Client], workingDir string, lspConfigs map[string]config.LSPConfig) *Index {
+	return &Index{
+		clients:    clients,
+		workingDir: workingDir,
+		outlineGo:  !handlesGo(lspConfigs),
+		outlines:   make(map[string]outline),
+	}
+}
+
+// Document returns the symbols of a file in order.
+func (i *Index) Document(ctx context.Context, path string) ([]Symbol, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if client := clientFor(i.clients, absPath); client != nil {
+		infos, err := client.DocumentSymbols(ctx, absPath)
+		if err != nil {
+			return nil, err
+		}
+		return fromLSP(infos, i.workingDir), nil
+	}
+	if isGoFile(absPath) {
+		return outlineGo(absPath, relativePath(i.workingDir, absPath))
+	}
+	return nil, ErrUnsupported
+}
+
+// Workspace returns at most limit symbols of the workspace matching the
+// query, or all of them when limit is 0. Every LSP server is asked, and the
+// Go files are searched by the index when no server is configured for them.
+func (i *Index) Workspace(ctx context.Context, query string, limit int) ([]Symbol, error) {
+	var (
+		result []Symbol
+		errs   error
+	)
+	for name, client := range i.clients.Seq2() {
+		infos, err := client.WorkspaceSymbols(ctx, query)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		result = append(result, fromLSP(infos, i.workingDir)...)
+	}
+
+	if i.outlineGo {
+		symbols, err := i.searchGo(ctx, query)
+		if err != nil {
+			errs = errors.Join(errs, err)
+		}
+		result = append(result, symbols...)
+	}
+
+	if len(result) == 0 {
+		return nil, errs
+	}
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// searchGo returns the symbols of the Go files of the working directory
+// fuzzily matching the query, best matches first.
+func (i *Index) searchGo(ctx context.Context, query string) ([]Symbol, error) {
+	all, err := i.goSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if query == "" {
+		return all, nil
+	}
+
+	names := make([]string, len(all))
+	for n, symbol := range all {
+		names[n] = symbol.QualifiedName()
+	}
+	matches := fuzzy.Find(query, names)
+	result := make([]Symbol, 0, len(matches))
+	for _, match := range matches {
+		result = append(result, all[match.Index])
+	}
+	return result, nil
+}
+
+// goSymbols returns the symbols of the Go files of the working directory,
+// outlining the files added or changed since the last refresh.
+func (i *Index) goSymbols(ctx context.Context) ([]Symbol, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.refreshed.IsZero() && time.Since(i.refreshed) < outlineRefreshInterval {
+		return i.symbols, nil
+	}
+
+	files, _, err := fsext.GlobWithDoubleStar("**/*.go", i.workingDir, maxOutlineFiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Go files: %w", err)
+	}
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		// The files outlined so far are kept, so that a search cut short
+		// does not start over.
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		seen[file] = true
+		if cached, ok := i.outlines[file]; ok && cached.modTime.Equal(info.ModTime()) {
+			continue
+		}
+		// Files that do not parse are kept without symbols, the others are
+		// still worth searching.
+		symbols, _ := outlineGo(file, relativePath(i.workingDir, file))
+		i.outlines[file] = outline{modTime: info.ModTime(), symbols: symbols}
+	}
+
+	// The previous symbols may still be used by earlier searches.
+	symbols := make([]Symbol, 0, len(i.symbols))
+	for _, file := range files {
+		if seen[file] {
+			symbols = append(symbols, i.outlines[file].symbols...)
+		}
+	}
+	for file := range i.outlines {
+		if !seen[file] {
+			delete(i.outlines, file)
+		}
+	}
+	i.symbols = symbols
+	i.refreshed = time.Now()
+	return symbols, nil
+}
+
+// handlesGo reports whether one of the enabled LSP servers is configured for
+// Go files.
+func handlesGo(lspConfigs map[string]config.LSPConfig) bool {
+	for _, cfg := range lspConfigs {
+		if cfg.Disabled {
+			continue
+		}
+		// Servers without file types handle every file.
+		if len(cfg.FileTypes) == 0 {
+			return true
+		}
+		for _, fileType := range cfg.FileTypes {
+			if strings.EqualFold(strings.TrimPrefix(fileType, "."), "go") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func clientFor(clients *csync.Map[string, *lsp.Client], absPath string) *lsp.Client {
+	for client := range clients.Seq() {
+		if client.HandlesFile(absPath) {
+			return client
+		}
+	}
+	return nil
+}
+
+func fromLSP(infos []protocol.SymbolInformation, workingDir string) []Symbol {
+	symbols := make([]Symbol, 0, len(infos))
+	for _, info := range infos {
+		path, err := info.Location.URI.Path()
+		if err != nil {
+			continue
+		}
+		line := 0
+		if info.Location.Range != (protocol.Range{}) {
+			line = int(info.Location.Range.Start.Line) + 1
+		}
+		symbols = append(symbols, Symbol{
+			Name:      info.Name,
+			Kind:      kindName(info.Kind),
+			Container: info.ContainerName,
+			Path:      relativePath(workingDir, path),
+			Line:      line,
+		})
+	}
+	return symbols
+}
+
+func relativePath(workingDir, path string) string {
+	rel, err := filepath.Rel(workingDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+var kindNames = map[protocol.SymbolKind]string{
+	protocol.File:          "file",
+	protocol.Module:        "module",
+	protocol.Namespace:     "namespace",
+	protocol.Package:       "package",
+	protocol.Class:         "class",
+	protocol.Method:        "method",
+	protocol.Property:      "property",
+	protocol.Field:         "field",
+	protocol.Constructor:   "constructor",
+	protocol.Enum:          "enum",
+	protocol.Interface:     "interface",
+	protocol.Function:      "function",
+	protocol.Variable:      "variable",
+	protocol.Constant:      "constant",
+	protocol.String:        "string",
+	protocol.Number:        "number",
+	protocol.Boolean:       "boolean",
+	protocol.Array:         "array",
+	protocol.Object:        "object",
+	protocol.Key:           "key",
+	protocol.Null:          "null",
+	protocol.EnumMember:    "enum-member",
+	protocol.Struct:        "struct",
+	protocol.Event:         "event",
+	protocol.Operator:      "operator",
+	protocol.TypeParameter: "type-parameter",
+}
+
+func kindName(kind protocol.SymbolKind) string {
+	if name, ok := kindNames[kind]; ok {
+		return name
+	}
+	return "symbol"
+}
@@ -0,0 +1,176 @@
+package symbols
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/stretchr/testify/require"
+)
+
+const source = `package server
+
+const DefaultAddr = ":8080"
+
+type Server struct {
+	Addr string
+}
+
+type Handler interface {
+	Handle() error
+}
+
+type Option func(*Server)
+
+func New(opts ...Option) *Server {
+	return &Server{}
+}
+
+func (s *Server) Serve() error {
+	return nil
+}
+`
+
+func TestDocumentGoOutline(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.go")
+	require.NoError(t, os.WriteFile(path, []byte(source), 0o644))
+
+	symbols, err := newTestIndex(dir, nil).Document(t.Context(), path)
+	require.NoError(t, err)
+
+	var lines []string
+	for _, symbol := range symbols {
+		lines = append(lines, symbol.String())
+	}
+	require.Equal(t, []string{
+		"constant DefaultAddr server.go:3",
+		"struct Server server.go:5",
+		"field Server.Addr server.go:6",
+		"interface Handler server.go:9",
+		"method Handler.Handle server.go:10",
+		"class Option server.go:13",
+		"function New server.go:15",
+		"method Server.Serve server.go:19",
+	}, lines)
+}
+
+func TestDocumentUnsupported(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.py")
+	require.NoError(t, os.WriteFile(path, []byte("def main():\n    pass\n"), 0o644))
+
+	_, err := newTestIndex(dir, nil).Document(t.Context(), path)
+	require.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestWorkspaceGoFallback(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "server"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "server", "server.go"), []byte(source), 0o644))
+
+	symbols, err := newTestIndex(dir, nil).Workspace(t.Context(), "srvserve", 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, symbols)
+	require.Equal(t, "method Server.Serve server/server.go:19", symbols[0].String())
+}
+
+func TestWorkspaceLimit(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "server.go"), []byte(source), 0o644))
+
+	symbols, err := newTestIndex(dir, nil).Workspace(t.Context(), "", 3)
+	require.NoError(t, err)
+	require.Len(t, symbols, 3)
+}
+
+func TestWorkspaceConfiguredGoServer(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "server.go"), []byte(source), 0o644))
+
+	// The configured server is not running, and the index leaves Go files
+	// to it anyway.
+	index := newTestIndex(dir, map[string]config.LSPConfig{
+		"gopls": {Command: "gopls", FileTypes: []string{"go", "mod"}},
+	})
+	symbols, err := index.Workspace(t.Context(), "Server", 0)
+	require.NoError(t, err)
+	require.Empty(t, symbols)
+
+	index = newTestIndex(dir, map[string]config.LSPConfig{
+		"gopls":   {Command: "gopls", FileTypes: []string{"go"}, Disabled: true},
+		"pyright": {Command: "pyright", FileTypes: []string{"py"}},
+	})
+	symbols, err = index.Workspace(t.Context(), "Server", 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, symbols)
+}
+
+func TestWorkspaceRefresh(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.go")
+	require.NoError(t, os.WriteFile(path, []byte(source), 0o644))
+
+	index := newTestIndex(dir, nil)
+	symbols, err := index.Workspace(t.Context(), "Serve", 0)
+	require.NoError(t, err)
+	require.Contains(t, symbolStrings(symbols), "method Server.Serve server.go:19")
+
+	changed := strings.Replace(source, "Serve() error", "ListenAndServe() error", 1)
+	require.NoError(t, os.WriteFile(path, []byte(changed), 0o644))
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+
+	// Changes are only looked for once the refresh interval has passed.
+	symbols, err = index.Workspace(t.Context(), "Serve", 0)
+	require.NoError(t, err)
+	require.Contains(t, symbolStrings(symbols), "method Server.Serve server.go:19")
+
+	index.refreshed = time.Time{}
+	symbols, err = index.Workspace(t.Context(), "Serve", 0)
+	require.NoError(t, err)
+	require.Contains(t, symbolStrings(symbols), "method Server.ListenAndServe server.go:19")
+	require.NotContains(t, symbolStrings(symbols), "method Server.Serve server.go:19")
+}
+
+func TestWorkspaceCanceled(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "server.go"), []byte(source), 0o644))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err := newTestIndex(dir, nil).Workspace(ctx, "Server", 0)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func symbolStrings(symbols []Symbol) []string {
+	lines := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		lines = append(lines, symbol.String())
+	}
+	return lines
+}
+
+func newTestIndex(dir string, lspConfigs map[string]config.LSPConfig) *Index {
+	return NewIndex(csync.NewMap[string, *lsp.Client](), dir, lspConfigs)
+}
@@ -28,6 +28,24 @@ type SendMsg struct {
 
 type SessionSelectedMsg = session.Session
 
+// EditMessageMsg requests editing an earlier user message so it can be sent
+// again.
+type EditMessageMsg struct {
+	Message message.Message
+}
+
+// EditSummaryMsg requests editing the summary the agent continues the
+// conversation from.
+type EditSummaryMsg struct {
+	Message message.Message
+}
+
+// ForkSessionMsg requests a new session branched off the given message.
+type ForkSessionMsg struct {
+	SessionID string
+	MessageID string
+}
+
 type SessionClearedMsg struct{}
 
 type SelectionCopyMsg struct {
@@ -104,6 +122,12 @@ func (m *messageListCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyPressMsg:
+		if m.listCmp.IsFocused() && key.Matches(msg, messages.ForkKey) {
+			return m, m.forkFromSelected()
+		}
+		if m.listCmp.IsFocused() && key.Matches(msg, messages.EditKey) {
+			return m, m.editSelected()
+		}
 		if m.listCmp.IsFocused() && m.listCmp.HasSelection() {
 			switch {
 			case key.Matches(msg, messages.CopyKey):
@@ -344,13 +368,24 @@ func (m *messageListCmp) messageExists(messageID string) bool {
 	return false
 }
 
-// handleDeleteMessage removes a message from the list.
+// handleDeleteMessage removes a message from the list, along with its tool
+// calls and section header.
 func (m *messageListCmp) handleDeleteMessage(msg message.Message) tea.Cmd {
 	items := m.listCmp.Items()
 	for i := len(items) - 1; i >= 0; i-- {
-		if msgCmp, ok := items[i].(messages.MessageCmp); ok && msgCmp.GetMessage().ID == msg.ID {
-			m.listCmp.DeleteItem(items[i].ID())
-			return nil
+		switch item := items[i].(type) {
+		case messages.MessageCmp:
+			if item.GetMessage().ID == msg.ID {
+				m.listCmp.DeleteItem(item.ID())
+			}
+		case messages.ToolCallCmp:
+			if item.ParentMessageID() == msg.ID {
+				m.listCmp.DeleteItem(item.ID())
+			}
+		case messages.AssistantSection:
+			if item.MessageID() == msg.ID {
+				m.listCmp.DeleteItem(item.ID())
+			}
 		}
 	}
 	return nil
@@ -447,10 +482,15 @@ func (m *messageListCmp) findAssistantMessageAndToolCalls(items []list.Item, mes
 // updateAssistantMessageContent updates or removes the assistant message based on content.
 func (m *messageListCmp) updateAssistantMessageContent(msg message.Message, assistantIndex int) tea.Cmd {
 	if assistantIndex == NotFound {
+		// Messages with only tool calls are hidden, but the notice of a
+		// budget stopping the agent goes after them.
+		if isBudgetExceeded(msg) {
+			return m.listCmp.AppendItem(messages.NewMessageCmp(msg))
+		}
 		return nil
 	}
 
-	shouldShowMessage := m.shouldShowAssistantMessage(msg)
+	shouldShowMessage := m.shouldShowAssistantMessage(msg) || isBudgetExceeded(msg)
 	hasToolCallsOnly := len(msg.ToolCalls()) > 0 && msg.Content().Text == ""
 
 	var cmd tea.Cmd
@@ -483,6 +523,11 @@ func (m *messageListCmp) shouldShowAssistantMessage(msg message.Message) bool {
 	return len(msg.ToolCalls()) == 0 || msg.Content().Text != "" || msg.ReasoningContent().Thinking != "" || msg.IsThinking()
 }
 
+// isBudgetExceeded reports whether a budget stopped the agent at this message.
+func isBudgetExceeded(msg message.Message) bool {
+	return msg.FinishPart() != nil && msg.FinishPart().Reason == message.FinishReasonBudgetExceeded
+}
+
 // updateToolCalls handles updates to tool calls, updating existing ones and adding new ones.
 func (m *messageListCmp) updateToolCalls(msg message.Message, existingToolCalls map[int]messages.ToolCallCmp) tea.Cmd {
 	var cmds []tea.Cmd
@@ -537,6 +582,53 @@ func (m *messageListCmp) handleNewAssistantMessage(msg message.Message) tea.Cmd
 	return tea.Batch(cmds...)
 }
 
+// forkFromSelected requests a fork of the session at the focused message.
+// Tool calls fork from the assistant message that issued them.
+func (m *messageListCmp) forkFromSelected() tea.Cmd {
+	if m.session.ID == "" {
+		return nil
+	}
+	selected := m.listCmp.SelectedItem()
+	if selected == nil {
+		return util.ReportWarn("Select a message to fork from")
+	}
+	var messageID string
+	switch item := (*selected).(type) {
+	case messages.MessageCmp:
+		messageID = item.GetMessage().ID
+	case messages.ToolCallCmp:
+		messageID = item.ParentMessageID()
+	default:
+		return util.ReportWarn("Select a message to fork from")
+	}
+	return util.CmdHandler(ForkSessionMsg{
+		SessionID: m.session.ID,
+		MessageID: messageID,
+	})
+}
+
+// editSelected requests editing the focused message, which must be a prompt
+// or a summary.
+func (m *messageListCmp) editSelected() tea.Cmd {
+	selected := m.listCmp.SelectedItem()
+	if selected == nil {
+		return nil
+	}
+	item, ok := (*selected).(messages.MessageCmp)
+	if !ok {
+		return util.ReportWarn("Only prompts and summaries can be edited")
+	}
+	msg := item.GetMessage()
+	switch {
+	case msg.Role == message.User:
+		return util.CmdHandler(EditMessageMsg{Message: msg})
+	case msg.IsSummaryMessage:
+		return util.CmdHandler(EditSummaryMsg{Message: msg})
+	default:
+		return util.ReportWarn("Only prompts and summaries can be edited")
+	}
+}
+
 // SetSession loads and displays messages for a new session.
 func (m *messageListCmp) SetSession(session session.Session) tea.Cmd {
 	if m.session.ID == session.ID {
@@ -631,6 +723,10 @@ func (m *messageListCmp) convertAssistantMessage(msg message.Message, toolResult
 		}
 	}
 
+	if !m.shouldShowAssistantMessage(msg) && isBudgetExceeded(msg) {
+		uiMessages = append(uiMessages, messages.NewMessageCmp(msg))
+	}
+
 	return uiMessages
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"net/http"
 	"os"
@@ -11,6 +12,7 @@ import (
 	"runtime"
 	"slices"
 	"strings"
+	"time"
 	"unicode"
 
 	"charm.land/bubbles/v2/key"
@@ -21,6 +23,7 @@ import (
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/symbols"
 	"github.com/charmbracelet/crush/internal/tui/components/chat"
 	"github.com/charmbracelet/crush/internal/tui/components/completions"
 	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
@@ -51,6 +54,16 @@ type FileCompletionItem struct {
 	Path string // The file path
 }
 
+type SymbolCompletionItem struct {
+	Symbol symbols.Symbol
+}
+
+// SymbolCompletionsMsg carries the symbols found for a completions query.
+type SymbolCompletionsMsg struct {
+	Query   string
+	Symbols []symbols.Symbol
+}
+
 type editorCmp struct {
 	width              int
 	height             int
@@ -88,6 +101,13 @@ var DeleteKeyMaps = DeleteAttachmentKeyMaps{
 
 const maxFileResults = 25
 
+// maxSymbolResults is the number of symbols offered as completions at most.
+const maxSymbolResults = 25
+
+// symbolCompletionsTimeout bounds the time spent looking up the symbols
+// offered as completions, so that slow LSP servers do not delay them.
+const symbolCompletionsTimeout = 2 * time.Second
+
 type OpenEditorMsg struct {
 	Text string
 }
@@ -183,25 +203,38 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		m.isCompletionsOpen = false
 		m.currentQuery = ""
 		m.completionsStartIndex = 0
+	case SymbolCompletionsMsg:
+		// Symbols found for a query the user has since changed are dropped.
+		word := m.textarea.Word()
+		if !strings.HasPrefix(word, "@") || word[1:] != msg.Query {
+			return m, nil
+		}
+		m.completionsStartIndex = strings.LastIndex(m.textarea.Value(), word)
+		m.currentQuery = msg.Query
+		items := make([]completions.Completion, 0, len(msg.Symbols))
+		for _, symbol := range msg.Symbols {
+			items = append(items, completions.Completion{
+				Title: symbol.String(),
+				Value: SymbolCompletionItem{
+					Symbol: symbol,
+				},
+			})
+		}
+		x, y := m.completionsPosition()
+		return m, util.CmdHandler(completions.ExtendCompletionsMsg{
+			Query:       msg.Query,
+			Completions: items,
+			X:           x - len(msg.Query),
+			Y:           y,
+		})
 	case completions.SelectCompletionMsg:
 		if !m.isCompletionsOpen {
 			return m, nil
 		}
-		if item, ok := msg.Value.(FileCompletionItem); ok {
-			word := m.textarea.Word()
+		switch item := msg.Value.(type) {
+		case FileCompletionItem:
 			// If the selected item is a file, insert its path into the textarea
-			value := m.textarea.Value()
-			value = value[:m.completionsStartIndex] + // Remove the current query
-				item.Path + // Insert the file path
-				value[m.completionsStartIndex+len(word):] // Append the rest of the value
-			// XXX: This will always move the cursor to the end of the textarea.
-			m.textarea.SetValue(value)
-			m.textarea.MoveToEnd()
-			if !msg.Insert {
-				m.isCompletionsOpen = false
-				m.currentQuery = ""
-				m.completionsStartIndex = 0
-			}
+			m.insertCompletion(item.Path, msg.Insert)
 			content, err := os.ReadFile(item.Path)
 			if err != nil {
 				// if it fails, let the LLM handle it later.
@@ -213,6 +246,10 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 				MimeType: mimeOf(content),
 				Content:  content,
 			})
+		case SymbolCompletionItem:
+			// Symbols are inserted along with their location, for the agent
+			// to find them.
+			m.insertCompletion(fmt.Sprintf("%s (%s)", item.Symbol.QualifiedName(), item.Symbol.Location()), msg.Insert)
 		}
 
 	case commands.OpenExternalEditorMsg:
@@ -339,6 +376,7 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 				if strings.HasPrefix(word, "@") {
 					// XXX: wont' work if editing in the middle of the field.
 					m.completionsStartIndex = strings.LastIndex(m.textarea.Value(), word)
+					queryChanged := m.currentQuery != word[1:]
 					m.currentQuery = word[1:]
 					x, y := m.completionsPosition()
 					x -= len(m.currentQuery)
@@ -351,6 +389,9 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 							Y:      y,
 						}),
 					)
+					if queryChanged && m.currentQuery != "" {
+						cmds = append(cmds, m.symbolCompletions(m.currentQuery))
+					}
 				} else if m.isCompletionsOpen {
 					m.isCompletionsOpen = false
 					m.currentQuery = ""
@@ -493,6 +534,23 @@ func (m *editorCmp) attachmentsContent() string {
 	return lipgloss.JoinHorizontal(lipgloss.Left, styledAttachments...)
 }
 
+// insertCompletion replaces the query of the completions with text.
+func (m *editorCmp) insertCompletion(text string, keepOpen bool) {
+	word := m.textarea.Word()
+	value := m.textarea.Value()
+	value = value[:m.completionsStartIndex] + // Remove the current query
+		text + // Insert the completion
+		value[m.completionsStartIndex+len(word):] // Append the rest of the value
+	// XXX: This will always move the cursor to the end of the textarea.
+	m.textarea.SetValue(value)
+	m.textarea.MoveToEnd()
+	if !keepOpen {
+		m.isCompletionsOpen = false
+		m.currentQuery = ""
+		m.completionsStartIndex = 0
+	}
+}
+
 func (m *editorCmp) SetPosition(x, y int) tea.Cmd {
 	m.x = x
 	m.y = y
@@ -524,6 +582,23 @@ func (m *editorCmp) startCompletions() tea.Msg {
 	}
 }
 
+// symbolCompletions looks up the symbols of the workspace matching the query
+// of the completions.
+func (m *editorCmp) symbolCompletions(query string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), symbolCompletionsTimeout)
+		defer cancel()
+		found, err := m.app.Symbols.Workspace(ctx, query, maxSymbolResults)
+		if err != nil {
+			slog.Debug("Failed to look up symbol completions", "query", query, "error", err)
+		}
+		if len(found) == 0 {
+			return nil
+		}
+		return SymbolCompletionsMsg{Query: query, Symbols: found}
+	}
+}
+
 // Blur implements Container.
 func (c *editorCmp) Blur() tea.Cmd {
 	c.textarea.Blur()
@@ -30,12 +30,14 @@ type header struct {
 	width       int
 	session     session.Session
 	lspClients  *csync.Map[string, *lsp.Client]
+	mainAgent   func() config.Agent
 	detailsOpen bool
 }
 
-func New(lspClients *csync.Map[string, *lsp.Client]) Header {
+func New(lspClients *csync.Map[string, *lsp.Client], mainAgent func() config.Agent) Header {
 	return &header{
 		lspClients: lspClients,
+		mainAgent:  mainAgent,
 		width:      0,
 	}
 }
@@ -100,10 +102,15 @@ func (h *header) View() string {
 }
 
 func (h *header) details(availWidth int) string {
-	s := styles.CurrentTheme().S()
+	t := styles.CurrentTheme()
+	s := t.S()
 
 	var parts []string
 
+	if h.session.PlanMode {
+		parts = append(parts, s.Base.Foreground(t.Secondary).Render("plan mode"))
+	}
+
 	errorCount := 0
 	for l := range h.lspClients.Seq() {
 		for _, diagnostics := range l.GetDiagnostics() {
@@ -119,11 +126,13 @@ func (h *header) details(availWidth int) string {
 		parts = append(parts, s.Error.Render(fmt.Sprintf("%s%d", styles.ErrorIcon, errorCount)))
 	}
 
-	agentCfg := config.Get().Agents[config.AgentCoder]
-	model := config.Get().GetModelByType(agentCfg.Model)
-	percentage := (float64(h.session.CompletionTokens+h.session.PromptTokens) / float64(model.ContextWindow)) * 100
-	formattedPercentage := s.Muted.Render(fmt.Sprintf("%d%%", int(percentage)))
-	parts = append(parts, formattedPercentage)
+	cfg := config.Get()
+	selectedModel := cfg.AgentModel(h.mainAgent())
+	if model := cfg.GetModel(selectedModel.Provider, selectedModel.Model); model != nil && model.ContextWindow > 0 {
+		percentage := (float64(h.session.CompletionTokens+h.session.PromptTokens) / float64(model.ContextWindow)) * 100
+		formattedPercentage := s.Muted.Render(fmt.Sprintf("%d%%", int(percentage)))
+		parts = append(parts, formattedPercentage)
+	}
 
 	const keystroke = "ctrl+d"
 	if h.detailsOpen {
@@ -32,6 +32,12 @@ var CopyKey = key.NewBinding(key.WithKeys("c", "y", "C", "Y"), key.WithHelp("c/y
 // ClearSelectionKey is the key binding for clearing the current selection in the chat interface.
 var ClearSelectionKey = key.NewBinding(key.WithKeys("esc", "alt+esc"), key.WithHelp("esc", "clear selection"))
 
+// EditKey is the key binding for editing the focused prompt or summary.
+var EditKey = key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit"))
+
+// ForkKey is the key binding for forking the session from the focused message.
+var ForkKey = key.NewBinding(key.WithKeys("F"), key.WithHelp("F", "fork from here"))
+
 // MessageCmp defines the interface for message components in the chat interface.
 // It combines standard UI model interfaces with message-specific functionality.
 type MessageCmp interface {
@@ -204,6 +210,12 @@ func (m *messageCmp) renderAssistantMessage() string {
 		return m.style().Render(errorContent)
 	}
 
+	if m.message.IsSummaryMessage && finished && content != "" {
+		summaryTag := t.S().Base.Padding(0, 1).Background(t.Primary).Foreground(t.White).Render("SUMMARY")
+		title := fmt.Sprintf("%s %s", summaryTag, t.S().Base.Foreground(t.FgHalfMuted).Render("The conversation continues from here. Press e to edit."))
+		parts = append(parts, title, "")
+	}
+
 	if thinkingContent != "" {
 		parts = append(parts, thinkingContent)
 	}
@@ -215,6 +227,26 @@ func (m *messageCmp) renderAssistantMessage() string {
 		parts = append(parts, m.toMarkdown(content))
 	}
 
+	if finished && finishedData.Reason == message.FinishReasonBudgetExceeded {
+		if len(parts) > 0 {
+			parts = append(parts, "")
+		}
+		budgetTag := t.S().Base.Padding(0, 1).Background(t.Warning).Foreground(t.White).Render("BUDGET")
+		title := fmt.Sprintf("%s %s", budgetTag, t.S().Base.Foreground(t.FgHalfMuted).Render(finishedData.Message))
+		details := t.S().Base.Foreground(t.FgSubtle).Width(m.textWidth() - 2).Render(finishedData.Details)
+		parts = append(parts, title, "", details)
+	}
+
+	if finished && finishedData.Reason == message.FinishReasonInterrupted {
+		if len(parts) > 0 {
+			parts = append(parts, "")
+		}
+		interruptedTag := t.S().Base.Padding(0, 1).Background(t.Warning).Foreground(t.White).Render("INTERRUPTED")
+		title := fmt.Sprintf("%s %s", interruptedTag, t.S().Base.Foreground(t.FgHalfMuted).Render(finishedData.Details))
+		hint := t.S().Base.Foreground(t.FgSubtle).Width(m.textWidth() - 2).Render("Run Continue from the commands (ctrl+p) to pick up from here.")
+		parts = append(parts, title, "", hint)
+	}
+
 	joined := lipgloss.JoinVertical(lipgloss.Left, parts...)
 	return m.style().Render(joined)
 }
@@ -390,6 +422,7 @@ func (m *messageCmp) Spinning() bool {
 type AssistantSection interface {
 	list.Item
 	layout.Sizeable
+	MessageID() string
 }
 type assistantSectionModel struct {
 	width               int
@@ -412,6 +445,11 @@ func NewAssistantSection(message message.Message, lastUserMessageTime time.Time)
 	}
 }
 
+// MessageID returns the ID of the assistant message the section closes.
+func (m *assistantSectionModel) MessageID() string {
+	return m.message.ID
+}
+
 func (m *assistantSectionModel) Init() tea.Cmd {
 	return nil
 }
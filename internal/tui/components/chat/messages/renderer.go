@@ -199,6 +199,9 @@ func init() {
 	registry.register(tools.SourcegraphToolName, func() renderer { return sourcegraphRenderer{} })
 	registry.register(tools.DiagnosticsToolName, func() renderer { return diagnosticsRenderer{} })
 	registry.register(tools.TodosToolName, func() renderer { return todosRenderer{} })
+	registry.register(tools.PlanToolName, func() renderer { return planRenderer{} })
+	registry.register(tools.MemoryToolName, func() renderer { return memoryRenderer{} })
+	registry.register(tools.WorktreeToolName, func() renderer { return worktreeRenderer{} })
 	registry.register(agent.AgentToolName, func() renderer { return agentRenderer{} })
 }
 
@@ -1302,10 +1305,16 @@ func prettifyToolName(name string) string {
 		return "Sourcegraph"
 	case tools.TodosToolName:
 		return "To-Do"
+	case tools.PlanToolName:
+		return "Plan"
+	case tools.MemoryToolName:
+		return "Memory"
 	case tools.ViewToolName:
 		return "View"
 	case tools.WriteToolName:
 		return "Write"
+	case tools.WorktreeToolName:
+		return "Worktree"
 	default:
 		return name
 	}
@@ -1401,3 +1410,82 @@ func (tr todosRenderer) Render(v *toolCallCmp) string {
 		return body
 	})
 }
+
+// -----------------------------------------------------------------------------
+//  Plan renderer
+// -----------------------------------------------------------------------------
+
+// planRenderer shows the plan written by the agent in plan mode.
+type planRenderer struct {
+	baseRenderer
+}
+
+func (pr planRenderer) Render(v *toolCallCmp) string {
+	var params tools.PlanParams
+	var args []string
+	if err := pr.unmarshalParams(v.call.Input, &params); err == nil {
+		args = newParamBuilder().
+			addMain(fmt.Sprintf("%d steps", len(params.Steps))).
+			build()
+	}
+
+	return pr.renderWithParams(v, "Plan", args, func() string {
+		var meta tools.PlanResponseMetadata
+		if err := pr.unmarshalParams(v.result.Metadata, &meta); err != nil || meta.Plan == "" {
+			return renderPlainContent(v, v.result.Content)
+		}
+		return renderMarkdownContent(v, meta.Plan)
+	})
+}
+
+// -----------------------------------------------------------------------------
+//  Memory renderer
+// -----------------------------------------------------------------------------
+
+// memoryRenderer shows the memories the agent saved, changed or listed.
+type memoryRenderer struct {
+	baseRenderer
+}
+
+func (mr memoryRenderer) Render(v *toolCallCmp) string {
+	var params tools.MemoryParams
+	var args []string
+	if err := mr.unmarshalParams(v.call.Input, &params); err == nil {
+		args = newParamBuilder().
+			addMain(params.Action).
+			addKeyValue("id", params.ID).
+			build()
+	}
+
+	return mr.renderWithParams(v, "Memory", args, func() string {
+		var meta tools.MemoryResponseMetadata
+		if err := mr.unmarshalParams(v.result.Metadata, &meta); err != nil || meta.Action == tools.MemoryActionList || len(meta.Memories) == 0 {
+			return renderPlainContent(v, v.result.Content)
+		}
+		return renderPlainContent(v, meta.Memories[0].Content)
+	})
+}
+
+// -----------------------------------------------------------------------------
+//  Worktree renderer
+// -----------------------------------------------------------------------------
+
+// worktreeRenderer shows the merge or discard of a sub-agent worktree.
+type worktreeRenderer struct {
+	baseRenderer
+}
+
+func (wr worktreeRenderer) Render(v *toolCallCmp) string {
+	var params tools.WorktreeParams
+	var args []string
+	if err := wr.unmarshalParams(v.call.Input, &params); err == nil {
+		args = newParamBuilder().
+			addMain(params.Action).
+			addKeyValue("id", params.ID).
+			build()
+	}
+
+	return wr.renderWithParams(v, "Worktree", args, func() string {
+		return renderPlainContent(v, v.result.Content)
+	})
+}
@@ -3,6 +3,8 @@ package sidebar
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 
@@ -16,6 +18,7 @@ import (
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/home"
 	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/tui/components/chat"
@@ -47,6 +50,10 @@ const (
 	MinItemsPerSection   = 2 // Minimum items to show per section
 )
 
+// DefaultMaxContextFilesShown is the maximum number of context files listed,
+// the ones loaded last in the session are left out first.
+const DefaultMaxContextFilesShown = 5
+
 type SessionFile struct {
 	History   FileHistory
 	FilePath  string
@@ -57,6 +64,20 @@ type SessionFilesMsg struct {
 	Files []SessionFile
 }
 
+// SubAgentUsage holds the usage of the sub-agents that ran with a given
+// model in the current session.
+type SubAgentUsage struct {
+	Provider string
+	Model    string
+	Runs     int
+	Cost     float64
+}
+
+type SubAgentUsageMsg struct {
+	SessionID string
+	Usage     []SubAgentUsage
+}
+
 type Sidebar interface {
 	util.Model
 	layout.Sizeable
@@ -72,13 +93,23 @@ type sidebarCmp struct {
 	lspClients    *csync.Map[string, *lsp.Client]
 	compactMode   bool
 	history       history.Service
+	sessions      session.Service
+	messages      message.Service
+	mainAgent     func() config.Agent
 	files         *csync.Map[string, SessionFile]
+	subAgents     []SubAgentUsage
+	// contextFiles are the context files of the working directory, which
+	// are in the system prompt of every session.
+	contextFiles []string
 }
 
-func New(history history.Service, lspClients *csync.Map[string, *lsp.Client], compact bool) Sidebar {
+func New(history history.Service, sessions session.Service, messages message.Service, lspClients *csync.Map[string, *lsp.Client], mainAgent func() config.Agent, compact bool) Sidebar {
 	return &sidebarCmp{
 		lspClients:  lspClients,
 		history:     history,
+		sessions:    sessions,
+		messages:    messages,
+		mainAgent:   mainAgent,
 		compactMode: compact,
 		files:       csync.NewMap[string, SessionFile](),
 	}
@@ -96,9 +127,15 @@ func (m *sidebarCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			m.files.Set(file.FilePath, file)
 		}
 		return m, nil
+	case SubAgentUsageMsg:
+		if msg.SessionID == m.session.ID {
+			m.subAgents = msg.Usage
+		}
+		return m, nil
 
 	case chat.SessionClearedMsg:
 		m.session = session.Session{}
+		m.subAgents = nil
 	case pubsub.Event[history.File]:
 		return m, m.handleFileHistoryEvent(msg)
 	case pubsub.Event[session.Session]:
@@ -106,6 +143,9 @@ func (m *sidebarCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			if m.session.ID == msg.Payload.ID {
 				m.session = msg.Payload
 			}
+			if m.session.ID != "" && m.session.ID == msg.Payload.ParentSessionID {
+				return m, m.loadSubAgentUsage
+			}
 		}
 	}
 	return m, nil
@@ -139,6 +179,9 @@ func (m *sidebarCmp) View() string {
 	} else if m.session.ID != "" {
 		parts = append(parts, t.S().Text.Render(m.session.Title), "")
 	}
+	if m.session.PlanMode {
+		parts = append(parts, t.S().Base.Foreground(t.Secondary).Render("Plan mode")+t.S().Subtle.Render(" · read-only tools"), "")
+	}
 
 	if !m.compactMode {
 		parts = append(parts,
@@ -161,6 +204,9 @@ func (m *sidebarCmp) View() string {
 		// Vertical layout (default)
 		if m.session.ID != "" {
 			parts = append(parts, "", m.filesBlock())
+			if block := m.contextFilesBlock(); block != "" {
+				parts = append(parts, "", block)
+			}
 		}
 		parts = append(parts,
 			"",
@@ -265,6 +311,55 @@ func (m *sidebarCmp) loadSessionFiles() tea.Msg {
 	}
 }
 
+// loadSubAgentUsage groups the cost of the sub-agent sessions of the current
+// session by the model they ran with.
+func (m *sidebarCmp) loadSubAgentUsage() tea.Msg {
+	ctx := context.Background()
+	sessionID := m.session.ID
+	children, err := m.sessions.ListChildren(ctx, sessionID)
+	if err != nil {
+		return util.InfoMsg{
+			Type: util.InfoTypeError,
+			Msg:  err.Error(),
+		}
+	}
+
+	var usage []SubAgentUsage
+	for _, child := range children {
+		if !m.sessions.IsAgentToolSession(child.ID) {
+			continue
+		}
+		msgs, err := m.messages.List(ctx, child.ID)
+		if err != nil {
+			continue
+		}
+		var provider, model string
+		for _, msg := range slices.Backward(msgs) {
+			if msg.Role == message.Assistant && msg.Model != "" {
+				provider, model = msg.Provider, msg.Model
+				break
+			}
+		}
+		if model == "" {
+			continue
+		}
+		idx := slices.IndexFunc(usage, func(u SubAgentUsage) bool {
+			return u.Provider == provider && u.Model == model
+		})
+		if idx == -1 {
+			usage = append(usage, SubAgentUsage{Provider: provider, Model: model})
+			idx = len(usage) - 1
+		}
+		usage[idx].Runs++
+		usage[idx].Cost += child.Cost
+	}
+
+	return SubAgentUsageMsg{
+		SessionID: sessionID,
+		Usage:     usage,
+	}
+}
+
 func (m *sidebarCmp) SetSize(width, height int) tea.Cmd {
 	m.logo = m.logoBlock()
 	m.cwd = cwd()
@@ -320,6 +415,10 @@ func (m *sidebarCmp) calculateAvailableHeight() int {
 
 	usedHeight += 6 // 3 sections × 2 lines each (header + empty line)
 
+	if n := len(m.contextFiles) + len(m.session.ContextFiles); m.session.ID != "" && n > 0 {
+		usedHeight += 3 + min(n, DefaultMaxContextFilesShown) // Context files section
+	}
+
 	// Base padding
 	usedHeight += 2 // Top and bottom padding
 
@@ -545,15 +644,17 @@ func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
 
 func (s *sidebarCmp) currentModelBlock() string {
 	cfg := config.Get()
-	agentCfg := cfg.Agents[config.AgentCoder]
-
-	selectedModel := cfg.Models[agentCfg.Model]
-
-	model := config.Get().GetModelByType(agentCfg.Model)
-	modelProvider := config.Get().GetProviderForModel(agentCfg.Model)
+	selectedModel := cfg.AgentModel(s.mainAgent())
 
 	t := styles.CurrentTheme()
 
+	model := cfg.GetModel(selectedModel.Provider, selectedModel.Model)
+	if model == nil {
+		// The provider or model is no longer configured.
+		return t.S().Subtle.Render(fmt.Sprintf("%s %s", styles.ModelIcon, selectedModel.Model))
+	}
+	modelProvider, _ := cfg.Providers.Get(selectedModel.Provider)
+
 	modelIcon := t.S().Base.Foreground(t.FgSubtle).Render(styles.ModelIcon)
 	modelName := t.S().Text.Render(model.Name)
 	modelInfo := fmt.Sprintf("%s %s", modelIcon, modelName)
@@ -588,6 +689,7 @@ func (s *sidebarCmp) currentModelBlock() string {
 				s.session.Cost,
 			),
 		)
+		parts = append(parts, s.subAgentsBlock()...)
 	}
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
@@ -595,10 +697,83 @@ func (s *sidebarCmp) currentModelBlock() string {
 	)
 }
 
+// subAgentsBlock renders one line per model used by the sub-agents of the
+// current session, with the number of runs and their cost.
+func (s *sidebarCmp) subAgentsBlock() []string {
+	t := styles.CurrentTheme()
+	lines := make([]string, 0, len(s.subAgents))
+	for _, usage := range s.subAgents {
+		name := usage.Model
+		if model := config.Get().GetModel(usage.Provider, usage.Model); model != nil {
+			name = model.Name
+		}
+		runs := "1 run"
+		if usage.Runs > 1 {
+			runs = fmt.Sprintf("%d runs", usage.Runs)
+		}
+		info := fmt.Sprintf("%s %s", runs, t.S().Muted.Render(fmt.Sprintf("$%.2f", usage.Cost)))
+		lines = append(lines,
+			t.S().Subtle.PaddingLeft(2).Render("↳ "+name),
+			t.S().Subtle.PaddingLeft(4).Render(info),
+		)
+	}
+	return lines
+}
+
+// contextFilesBlock lists the context files given to the agent: the ones of
+// the working directory and the ones of subdirectories it loaded during the
+// session.
+func (m *sidebarCmp) contextFilesBlock() string {
+	paths := append(slices.Clone(m.contextFiles), m.session.ContextFiles...)
+	if len(paths) == 0 {
+		return ""
+	}
+	t := styles.CurrentTheme()
+	maxWidth := m.getMaxWidth()
+	lines := []string{core.Section("Context Files", maxWidth), ""}
+	for i, path := range paths {
+		if i == DefaultMaxContextFilesShown {
+			lines = append(lines, t.S().Base.Foreground(t.FgSubtle).Render(fmt.Sprintf("…and %d more", len(paths)-i)))
+			break
+		}
+		opts := core.StatusOpts{Title: filepath.Base(path)}
+		if dir := filepath.Dir(path); dir != "." {
+			opts.Description = dir
+		}
+		lines = append(lines, core.Status(opts, maxWidth))
+	}
+	return lipgloss.NewStyle().Width(maxWidth).Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// loadContextFiles returns the context paths of the config that exist in the
+// working directory.
+func loadContextFiles() []string {
+	cfg := config.Get()
+	var paths []string
+	seen := make(map[string]bool)
+	for _, path := range cfg.Options.ContextPaths {
+		fullPath := home.Long(path)
+		if !filepath.IsAbs(fullPath) {
+			fullPath = filepath.Join(cfg.WorkingDir(), fullPath)
+		}
+		key := strings.ToLower(fullPath)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if _, err := os.Stat(fullPath); err == nil {
+			paths = append(paths, filepath.ToSlash(path))
+		}
+	}
+	return paths
+}
+
 // SetSession implements Sidebar.
 func (m *sidebarCmp) SetSession(session session.Session) tea.Cmd {
 	m.session = session
-	return m.loadSessionFiles
+	m.subAgents = nil
+	m.contextFiles = loadContextFiles()
+	return tea.Batch(m.loadSessionFiles, m.loadSubAgentUsage)
 }
 
 // SetCompactMode sets the compact mode for the sidebar.
@@ -1,6 +1,7 @@
 package completions
 
 import (
+	"slices"
 	"strings"
 
 	"charm.land/bubbles/v2/key"
@@ -32,6 +33,15 @@ type FilterCompletionsMsg struct {
 	Y      int // Y position for the completions popup
 }
 
+// ExtendCompletionsMsg adds completions found for a query to the opened ones,
+// opening them again if the query had filtered everything out.
+type ExtendCompletionsMsg struct {
+	Query       string
+	Completions []Completion
+	X           int // X position for the completions popup
+	Y           int // Y position for the completions popup
+}
+
 type RepositionCompletionsMsg struct {
 	X, Y int
 }
@@ -71,7 +81,8 @@ type completionsCmp struct {
 	keyMap    KeyMap
 
 	list  listModel
-	query string // The current filter query
+	items []list.CompletionItem[any] // The items the completions were opened with
+	query string                     // The current filter query
 }
 
 func New() Completions {
@@ -174,16 +185,8 @@ func (c *completionsCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		c.query = ""
 		c.x, c.xorig = msg.X, msg.X
 		c.y = msg.Y
-		items := []list.CompletionItem[any]{}
-		t := styles.CurrentTheme()
-		for _, completion := range msg.Completions {
-			item := list.NewCompletionItem(
-				completion.Title,
-				completion.Value,
-				list.WithCompletionBackgroundColor(t.BgSubtle),
-			)
-			items = append(items, item)
-		}
+		items := newItems(msg.Completions)
+		c.items = items
 		width := listWidth(items)
 		if len(items) == 0 {
 			width = listWidth(c.list.Items())
@@ -199,6 +202,21 @@ func (c *completionsCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			c.list.SetSize(c.width, c.height),
 			util.CmdHandler(CompletionsOpenedMsg{}),
 		)
+	case ExtendCompletionsMsg:
+		c.open = true
+		c.query = msg.Query
+		c.xorig = msg.X
+		c.x, c.y = msg.X, msg.Y
+		cmds := []tea.Cmd{
+			c.list.SetItems(append(slices.Clone(c.items), newItems(msg.Completions)...)),
+			c.list.Filter(msg.Query),
+		}
+		c.adjustPosition()
+		cmds = append(cmds,
+			c.list.SetSize(c.width, c.height),
+			util.CmdHandler(CompletionsOpenedMsg{}),
+		)
+		return c, tea.Batch(cmds...)
 	case FilterCompletionsMsg:
 		if !c.open && !msg.Reopen {
 			return c, nil
@@ -237,6 +255,20 @@ func (c *completionsCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	return c, nil
 }
 
+func newItems(completions []Completion) []list.CompletionItem[any] {
+	items := make([]list.CompletionItem[any], 0, len(completions))
+	t := styles.CurrentTheme()
+	for _, completion := range completions {
+		item := list.NewCompletionItem(
+			completion.Title,
+			completion.Value,
+			list.WithCompletionBackgroundColor(t.BgSubtle),
+		)
+		items = append(items, item)
+	}
+	return items
+}
+
 func (c *completionsCmp) adjustPosition() {
 	items := c.list.Items()
 	itemsLen := len(items)
@@ -0,0 +1,290 @@
+package checkpoints
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/checkpoint"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const CheckpointsDialogID dialogs.DialogID = "checkpoints"
+
+// CheckpointsDialog lets the user pick a checkpoint of the session and
+// preview the file changes that restoring it would revert.
+type CheckpointsDialog interface {
+	dialogs.DialogModel
+}
+
+// Item is a checkpoint along with what is needed to present it.
+type Item struct {
+	Checkpoint checkpoint.Checkpoint
+	// Prompt is the user message that started the turn.
+	Prompt  string
+	Changes []history.Change
+}
+
+// RestoreCheckpointMsg is sent when the user confirms restoring a
+// checkpoint.
+type RestoreCheckpointMsg struct {
+	Checkpoint checkpoint.Checkpoint
+}
+
+type listModel = list.FilterableList[list.CompletionItem[Item]]
+
+type checkpointsDialogCmp struct {
+	wWidth  int
+	wHeight int
+	width   int
+
+	checkpointsList listModel
+	keyMap          KeyMap
+	help            help.Model
+
+	// Set while previewing the changes of a checkpoint.
+	selected    *Item
+	fileIndex   int
+	diffYOffset int
+}
+
+// NewCheckpointsDialog creates a new dialog listing the given checkpoints,
+// newest first.
+func NewCheckpointsDialog(items []Item) CheckpointsDialog {
+	t := styles.CurrentTheme()
+	keyMap := DefaultKeyMap()
+	listKeyMap := list.DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	listItems := make([]list.CompletionItem[Item], 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		prompt, _, _ := strings.Cut(strings.TrimSpace(item.Prompt), "\n")
+		listItems = append(listItems, list.NewCompletionItem(
+			fmt.Sprintf("#%d %s %s", item.Checkpoint.Number, time.Unix(item.Checkpoint.CreatedAt, 0).Local().Format("15:04"), prompt),
+			item,
+			list.WithCompletionID(item.Checkpoint.ID),
+			list.WithCompletionShortcut(changesSummary(item.Changes)),
+		))
+	}
+
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	checkpointsList := list.NewFilterableList(
+		listItems,
+		list.WithFilterPlaceholder("Enter a prompt"),
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+	return &checkpointsDialogCmp{
+		checkpointsList: checkpointsList,
+		keyMap:          keyMap,
+		help:            help,
+	}
+}
+
+func changesSummary(changes []history.Change) string {
+	switch len(changes) {
+	case 0:
+		return "no changes"
+	case 1:
+		return "1 file"
+	default:
+		return fmt.Sprintf("%d files", len(changes))
+	}
+}
+
+func (c *checkpointsDialogCmp) Init() tea.Cmd {
+	return tea.Sequence(
+		c.checkpointsList.Init(),
+		c.checkpointsList.Focus(),
+	)
+}
+
+func (c *checkpointsDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		c.wWidth = msg.Width
+		c.wHeight = msg.Height
+		c.width = min(120, int(float64(c.wWidth)*0.8))
+		c.checkpointsList.SetInputWidth(c.width - 4)
+		return c, c.checkpointsList.SetSize(c.width-2, c.wHeight/2-6)
+	case tea.KeyPressMsg:
+		if c.selected != nil {
+			return c, c.updatePreview(msg)
+		}
+		switch {
+		case key.Matches(msg, c.keyMap.Select):
+			selectedItem := c.checkpointsList.SelectedItem()
+			if selectedItem == nil {
+				return c, nil
+			}
+			item := (*selectedItem).Value()
+			c.selected = &item
+			c.fileIndex = 0
+			c.diffYOffset = 0
+			return c, nil
+		case key.Matches(msg, c.keyMap.Close):
+			return c, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := c.checkpointsList.Update(msg)
+			c.checkpointsList = u.(listModel)
+			return c, cmd
+		}
+	}
+	return c, nil
+}
+
+func (c *checkpointsDialogCmp) updatePreview(msg tea.KeyPressMsg) tea.Cmd {
+	changes := c.selected.Changes
+	switch {
+	case key.Matches(msg, c.keyMap.Restore):
+		return tea.Sequence(
+			util.CmdHandler(dialogs.CloseDialogMsg{}),
+			util.CmdHandler(RestoreCheckpointMsg{Checkpoint: c.selected.Checkpoint}),
+		)
+	case key.Matches(msg, c.keyMap.Back):
+		c.selected = nil
+	case key.Matches(msg, c.keyMap.NextFile):
+		if len(changes) > 0 {
+			c.fileIndex = (c.fileIndex + 1) % len(changes)
+			c.diffYOffset = 0
+		}
+	case key.Matches(msg, c.keyMap.PreviousFile):
+		if len(changes) > 0 {
+			c.fileIndex = (c.fileIndex - 1 + len(changes)) % len(changes)
+			c.diffYOffset = 0
+		}
+	case key.Matches(msg, c.keyMap.ScrollDown):
+		c.diffYOffset++
+	case key.Matches(msg, c.keyMap.ScrollUp):
+		c.diffYOffset = max(0, c.diffYOffset-1)
+	}
+	return nil
+}
+
+func (c *checkpointsDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	if c.selected != nil {
+		return c.style().Render(c.previewView())
+	}
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Restore Checkpoint", c.width-4)),
+		c.checkpointsList.View(),
+		"",
+		t.S().Base.Width(c.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(c.help.View(listKeyMap(c.keyMap))),
+	)
+	return c.style().Render(content)
+}
+
+func (c *checkpointsDialogCmp) previewView() string {
+	t := styles.CurrentTheme()
+	title := fmt.Sprintf("Restore Checkpoint #%d", c.selected.Checkpoint.Number)
+	parts := []string{
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title(title, c.width-4)),
+	}
+
+	changes := c.selected.Changes
+	if len(changes) == 0 {
+		parts = append(parts, t.S().Muted.PaddingLeft(1).Render("No files were changed since this checkpoint."))
+	} else {
+		var files []string
+		for i, change := range changes {
+			name := fsext.PrettyPath(change.Path)
+			switch {
+			case change.Conflict():
+				name = t.S().Warning.Render(name + " (changed outside of crush, kept)")
+			case change.Created():
+				name = t.S().Error.Render(name + " (created, will be deleted)")
+			default:
+				name = t.S().Text.Render(name)
+			}
+			marker := "  "
+			if i == c.fileIndex {
+				marker = t.S().Base.Foreground(t.Primary).Render("> ")
+			}
+			files = append(files, marker+name)
+		}
+		parts = append(parts, t.S().Base.PaddingLeft(1).Render(strings.Join(files, "\n")), "")
+
+		change := changes[c.fileIndex]
+		before, after := diffContents(change)
+		diffHeight := max(5, c.wHeight/2-len(files)-6)
+		diff := core.DiffFormatter().
+			Before(fsext.PrettyPath(change.Path), before).
+			After(fsext.PrettyPath(change.Path), after).
+			Height(diffHeight).
+			Width(c.width - 4).
+			YOffset(c.diffYOffset).
+			Unified()
+		parts = append(parts, t.S().Base.PaddingLeft(1).Render(diff.String()))
+	}
+
+	parts = append(parts,
+		"",
+		t.S().Base.Width(c.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(c.help.View(previewKeyMap(c.keyMap))),
+	)
+	return lipgloss.JoinVertical(lipgloss.Left, parts...)
+}
+
+// diffContents returns what restoring does to the file. Nothing is written
+// for conflicting files, so for those it shows what happened to them outside
+// of crush instead.
+func diffContents(change history.Change) (before, after string) {
+	if change.Conflict() {
+		return change.After.Content, change.Current
+	}
+	return change.Current, change.Before.Content
+}
+
+func (c *checkpointsDialogCmp) Cursor() *tea.Cursor {
+	if c.selected != nil {
+		return nil
+	}
+	if cursor, ok := c.checkpointsList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			row, col := c.Position()
+			cursor.Y += row + 3 // Border + title
+			cursor.X += col + 2
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (c *checkpointsDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(c.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (c *checkpointsDialogCmp) Position() (int, int) {
+	row := c.wHeight/4 - 2 // just a bit above the center
+	col := c.wWidth/2 - c.width/2
+	return row, col
+}
+
+func (c *checkpointsDialogCmp) ID() dialogs.DialogID {
+	return CheckpointsDialogID
+}
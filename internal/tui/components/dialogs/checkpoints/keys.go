@@ -0,0 +1,109 @@
+package checkpoints
+
+import (
+	"charm.land/bubbles/v2/key"
+)
+
+// KeyMap defines the keyboard bindings for the checkpoints dialog.
+type KeyMap struct {
+	Select,
+	Next,
+	Previous,
+	NextFile,
+	PreviousFile,
+	ScrollDown,
+	ScrollUp,
+	Restore,
+	Back,
+	Close key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Select: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "preview"),
+		),
+		Next: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓", "next item"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑", "previous item"),
+		),
+		NextFile: key.NewBinding(
+			key.WithKeys("right", "tab"),
+			key.WithHelp("→", "next file"),
+		),
+		PreviousFile: key.NewBinding(
+			key.WithKeys("left", "shift+tab"),
+			key.WithHelp("←", "previous file"),
+		),
+		ScrollDown: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓", "scroll down"),
+		),
+		ScrollUp: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑", "scroll up"),
+		),
+		Restore: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "restore"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("esc", "alt+esc"),
+			key.WithHelp("esc", "back"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "alt+esc"),
+			key.WithHelp("esc", "exit"),
+		),
+	}
+}
+
+// listKeyMap is the help shown while picking a checkpoint.
+type listKeyMap KeyMap
+
+// ShortHelp implements help.KeyMap.
+func (k listKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		key.NewBinding(
+			key.WithKeys("down", "up"),
+			key.WithHelp("↑↓", "choose"),
+		),
+		k.Select,
+		k.Close,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k listKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// previewKeyMap is the help shown while previewing the changes of a
+// checkpoint.
+type previewKeyMap KeyMap
+
+// ShortHelp implements help.KeyMap.
+func (k previewKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		key.NewBinding(
+			key.WithKeys("left", "right"),
+			key.WithHelp("←→", "files"),
+		),
+		key.NewBinding(
+			key.WithKeys("up", "down"),
+			key.WithHelp("↑↓", "scroll"),
+		),
+		k.Restore,
+		k.Back,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k previewKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
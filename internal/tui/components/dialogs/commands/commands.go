@@ -73,6 +73,7 @@ type (
 	SwitchSessionsMsg      struct{}
 	NewSessionsMsg         struct{}
 	SwitchModelMsg         struct{}
+	SwitchAgentMsg         struct{}
 	QuitMsg                struct{}
 	OpenFilePickerMsg      struct{}
 	ToggleHelpMsg          struct{}
@@ -81,9 +82,24 @@ type (
 	OpenReasoningDialogMsg struct{}
 	OpenExternalEditorMsg  struct{}
 	ToggleYoloModeMsg      struct{}
+	TogglePlanModeMsg      struct{}
+	ReviewPlanMsg          struct{}
+	OpenMemoriesMsg        struct{}
 	CompactMsg             struct {
 		SessionID string
 	}
+	UndoMsg struct {
+		SessionID string
+	}
+	RestoreCheckpointMsg struct {
+		SessionID string
+	}
+	ManageQueueMsg struct {
+		SessionID string
+	}
+	ContinueMsg struct {
+		SessionID string
+	}
 )
 
 func NewCommandDialog(sessionID string) CommandsDialog {
@@ -341,6 +357,19 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 		},
 	}
 
+	// Only show the agent switcher if there is more than one agent to
+	// choose from.
+	if len(config.Get().SelectableAgents()) > 1 {
+		commands = append(commands, Command{
+			ID:          "switch_agent",
+			Title:       "Switch Agent",
+			Description: "Switch to a different agent",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(SwitchAgentMsg{})
+			},
+		})
+	}
+
 	// Only show compact command if there's an active session
 	if c.sessionID != "" {
 		commands = append(commands, Command{
@@ -419,6 +448,46 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 		}
 	}
 
+	if c.sessionID != "" {
+		commands = append(commands, Command{
+			ID:          "review_plan",
+			Title:       "Review Plan",
+			Description: "Review, edit or execute the plan of the current session",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(ReviewPlanMsg{})
+			},
+		}, Command{
+			ID:          "undo",
+			Title:       "Undo",
+			Description: "Revert the files changed since the last checkpoint",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(UndoMsg{SessionID: c.sessionID})
+			},
+		}, Command{
+			ID:          "restore_checkpoint",
+			Title:       "Restore Checkpoint",
+			Description: "Preview and restore the files to an earlier checkpoint",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(RestoreCheckpointMsg{SessionID: c.sessionID})
+			},
+		}, Command{
+			ID:          "continue",
+			Title:       "Continue",
+			Description: "Pick up a turn that was interrupted by crush stopping",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(ContinueMsg{SessionID: c.sessionID})
+			},
+		}, Command{
+			ID:          "manage_queue",
+			Title:       "Manage Queue",
+			Shortcut:    "ctrl+q",
+			Description: "Edit, reorder or remove the prompts waiting for the agent",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(ManageQueueMsg{SessionID: c.sessionID})
+			},
+		})
+	}
+
 	// Add external editor command if $EDITOR is available
 	if os.Getenv("EDITOR") != "" {
 		commands = append(commands, Command{
@@ -441,6 +510,14 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 				return util.CmdHandler(ToggleYoloModeMsg{})
 			},
 		},
+		{
+			ID:          "toggle_plan_mode",
+			Title:       "Toggle Plan Mode",
+			Description: "Let the agent plan with read-only tools before making changes",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(TogglePlanModeMsg{})
+			},
+		},
 		{
 			ID:          "toggle_help",
 			Title:       "Toggle Help",
@@ -464,6 +541,14 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 				})
 			},
 		},
+		{
+			ID:          "memories",
+			Title:       "Memories",
+			Description: "Review, edit or delete the facts the agent saved about the project",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenMemoriesMsg{})
+			},
+		},
 		{
 			ID:          "quit",
 			Title:       "Quit",
@@ -0,0 +1,92 @@
+package memories
+
+import (
+	"charm.land/bubbles/v2/key"
+)
+
+// KeyMap defines the keyboard bindings for the memories dialog.
+type KeyMap struct {
+	Next,
+	Previous,
+	Edit,
+	Delete,
+	Save,
+	Newline,
+	Cancel,
+	Close key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Next: key.NewBinding(
+			key.WithKeys("down", "ctrl+n", "j"),
+			key.WithHelp("↓", "next item"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "ctrl+p", "k"),
+			key.WithHelp("↑", "previous item"),
+		),
+		Edit: key.NewBinding(
+			key.WithKeys("enter", "e"),
+			key.WithHelp("enter", "edit"),
+		),
+		Delete: key.NewBinding(
+			key.WithKeys("d", "delete"),
+			key.WithHelp("d", "delete"),
+		),
+		Save: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "save"),
+		),
+		Newline: key.NewBinding(
+			key.WithKeys("shift+enter", "ctrl+j"),
+			key.WithHelp("ctrl+j", "newline"),
+		),
+		Cancel: key.NewBinding(
+			key.WithKeys("esc", "alt+esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "alt+esc"),
+			key.WithHelp("esc", "exit"),
+		),
+	}
+}
+
+// listKeyMap is the help shown while picking a memory.
+type listKeyMap KeyMap
+
+// ShortHelp implements help.KeyMap.
+func (k listKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		key.NewBinding(
+			key.WithKeys("down", "up"),
+			key.WithHelp("↑↓", "choose"),
+		),
+		k.Edit,
+		k.Delete,
+		k.Close,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k listKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// editKeyMap is the help shown while editing a memory.
+type editKeyMap KeyMap
+
+// ShortHelp implements help.KeyMap.
+func (k editKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		k.Save,
+		k.Newline,
+		k.Cancel,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k editKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
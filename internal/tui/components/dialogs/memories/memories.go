@@ -0,0 +1,218 @@
+package memories
+
+import (
+	"errors"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/memory"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const MemoriesDialogID dialogs.DialogID = "memories"
+
+// maxVisibleMemories is the number of memories listed at once.
+const maxVisibleMemories = 10
+
+// MemoriesDialog lets the user review, edit and delete the facts the agent
+// saved about the project.
+type MemoriesDialog interface {
+	dialogs.DialogModel
+}
+
+type memoriesDialogCmp struct {
+	wWidth  int
+	wHeight int
+	width   int
+
+	store    *memory.Store
+	memories []memory.Memory
+	selected int
+	err      error
+
+	// Set while editing the selected memory.
+	editing  string
+	textarea textarea.Model
+
+	keyMap KeyMap
+	help   help.Model
+}
+
+// NewMemoriesDialog creates a new dialog to manage the memories kept in the
+// given store.
+func NewMemoriesDialog(store *memory.Store) MemoriesDialog {
+	t := styles.CurrentTheme()
+	ta := textarea.New()
+	ta.SetStyles(t.S().TextArea)
+	ta.ShowLineNumbers = false
+	ta.CharLimit = -1
+	ta.SetVirtualCursor(true)
+	ta.SetHeight(6)
+
+	help := help.New()
+	help.Styles = t.S().Help
+	m := &memoriesDialogCmp{
+		store:    store,
+		textarea: ta,
+		keyMap:   DefaultKeyMap(),
+		help:     help,
+	}
+	m.refresh()
+	return m
+}
+
+func (m *memoriesDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+// refresh reloads the memories, which the agent may change while the dialog
+// is open.
+func (m *memoriesDialogCmp) refresh() {
+	m.memories, m.err = m.store.List()
+	m.selected = max(0, min(m.selected, len(m.memories)-1))
+}
+
+func (m *memoriesDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.wWidth = msg.Width
+		m.wHeight = msg.Height
+		m.width = min(100, int(float64(m.wWidth)*0.8))
+		m.textarea.SetWidth(m.width - 4)
+	case tea.KeyPressMsg:
+		if m.editing != "" {
+			return m, m.updateEdit(msg)
+		}
+		if key.Matches(msg, m.keyMap.Close) {
+			return m, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+		if len(m.memories) == 0 {
+			return m, nil
+		}
+		selected := m.memories[m.selected]
+		switch {
+		case key.Matches(msg, m.keyMap.Next):
+			m.selected = (m.selected + 1) % len(m.memories)
+		case key.Matches(msg, m.keyMap.Previous):
+			m.selected = (m.selected - 1 + len(m.memories)) % len(m.memories)
+		case key.Matches(msg, m.keyMap.Delete):
+			err := m.store.Delete(selected.ID)
+			m.refresh()
+			return m, reportMemoryError(err)
+		case key.Matches(msg, m.keyMap.Edit):
+			m.editing = selected.ID
+			m.textarea.SetValue(selected.Content)
+			return m, m.textarea.Focus()
+		}
+	}
+	return m, nil
+}
+
+func (m *memoriesDialogCmp) updateEdit(msg tea.KeyPressMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, m.keyMap.Cancel):
+		m.stopEditing()
+		return nil
+	case key.Matches(msg, m.keyMap.Newline):
+		m.textarea.InsertRune('\n')
+		return nil
+	case key.Matches(msg, m.keyMap.Save):
+		text := strings.TrimSpace(m.textarea.Value())
+		if text == "" {
+			return util.ReportWarn("The memory is empty, delete it instead")
+		}
+		_, err := m.store.Update(m.editing, text)
+		m.stopEditing()
+		return reportMemoryError(err)
+	}
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+	return cmd
+}
+
+func (m *memoriesDialogCmp) stopEditing() {
+	m.editing = ""
+	m.textarea.Blur()
+	m.refresh()
+}
+
+func reportMemoryError(err error) tea.Cmd {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, memory.ErrNotFound):
+		return util.ReportWarn("The memory was already deleted")
+	default:
+		return util.ReportError(err)
+	}
+}
+
+func (m *memoriesDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	parts := []string{
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Memories", m.width-4)),
+	}
+	switch {
+	case m.editing != "":
+		parts = append(parts,
+			t.S().Base.PaddingLeft(1).Render(m.textarea.View()),
+			"",
+			t.S().Base.Width(m.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(m.help.View(editKeyMap(m.keyMap))),
+		)
+	case m.err != nil:
+		parts = append(parts,
+			t.S().Error.PaddingLeft(1).Width(m.width-4).Render(m.err.Error()),
+			"",
+			t.S().Base.Width(m.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(m.help.View(listKeyMap(m.keyMap))),
+		)
+	case len(m.memories) == 0:
+		parts = append(parts,
+			t.S().Muted.PaddingLeft(1).Render("The agent has not saved any memories about this project."),
+			"",
+			t.S().Base.Width(m.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(m.help.View(listKeyMap(m.keyMap))),
+		)
+	default:
+		start := max(0, min(m.selected-maxVisibleMemories/2, len(m.memories)-maxVisibleMemories))
+		end := min(len(m.memories), start+maxVisibleMemories)
+		var lines []string
+		for i := start; i < end; i++ {
+			memory := m.memories[i]
+			text, _, _ := strings.Cut(memory.Content, "\n")
+			line := t.S().Subtle.Render(memory.UpdatedAt.Format("2006-01-02")) + " " + t.S().Text.Render(text)
+			marker := "  "
+			if i == m.selected {
+				marker = t.S().Base.Foreground(t.Primary).Render("> ")
+			}
+			lines = append(lines, t.S().Base.MaxWidth(m.width-4).Render(marker+line))
+		}
+		parts = append(parts,
+			t.S().Base.PaddingLeft(1).Render(strings.Join(lines, "\n")),
+			"",
+			t.S().Muted.PaddingLeft(1).Width(m.width-4).Render(m.memories[m.selected].Content),
+			"",
+			t.S().Base.Width(m.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(m.help.View(listKeyMap(m.keyMap))),
+		)
+	}
+	return t.S().Base.
+		Width(m.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
+
+func (m *memoriesDialogCmp) Position() (int, int) {
+	row := m.wHeight/4 - 2 // just a bit above the center
+	col := m.wWidth/2 - m.width/2
+	return row, col
+}
+
+func (m *memoriesDialogCmp) ID() dialogs.DialogID {
+	return MemoriesDialogID
+}
@@ -92,6 +92,9 @@ func (p *permissionDialogCmp) Init() tea.Cmd {
 }
 
 func (p *permissionDialogCmp) supportsDiffView() bool {
+	if _, ok := p.permission.Params.(tools.WorkspaceEditPermissionsParams); ok {
+		return true
+	}
 	return p.permission.ToolName == tools.EditToolName || p.permission.ToolName == tools.WriteToolName || p.permission.ToolName == tools.MultiEditToolName
 }
 
@@ -381,6 +384,19 @@ func (p *permissionDialogCmp) renderHeader() string {
 			),
 			baseStyle.Render(strings.Repeat(" ", p.width)),
 		)
+	case tools.RenameToolName, tools.CodeActionsToolName:
+		descKey := t.S().Muted.Render("Desc")
+		descValue := t.S().Text.
+			Width(p.width - lipgloss.Width(descKey)).
+			Render(fmt.Sprintf(" %s", p.permission.Description))
+		headerParts = append(headerParts,
+			lipgloss.JoinHorizontal(
+				lipgloss.Left,
+				descKey,
+				descValue,
+			),
+			baseStyle.Render(strings.Repeat(" ", p.width)),
+		)
 	case tools.FetchToolName:
 		headerParts = append(headerParts,
 			baseStyle.Render(strings.Repeat(" ", p.width)),
@@ -443,6 +459,14 @@ func (p *permissionDialogCmp) getOrGenerateContent() string {
 		content = p.generateWriteContent()
 	case tools.MultiEditToolName:
 		content = p.generateMultiEditContent()
+	case tools.RenameToolName:
+		content = p.generateWorkspaceEditContent()
+	case tools.CodeActionsToolName:
+		if p.supportsDiffView() {
+			content = p.generateWorkspaceEditContent()
+		} else {
+			content = p.generateCodeActionsContent()
+		}
 	case tools.FetchToolName:
 		content = p.generateFetchContent()
 	case tools.AgenticFetchToolName:
@@ -588,6 +612,54 @@ func (p *permissionDialogCmp) generateMultiEditContent() string {
 	return ""
 }
 
+// generateWorkspaceEditContent renders the diffs of all the files touched by
+// an LSP workspace edit one after the other. They are rendered in full and
+// scrolled together, as a single diff view only scrolls within its own file.
+func (p *permissionDialogCmp) generateWorkspaceEditContent() string {
+	pr, ok := p.permission.Params.(tools.WorkspaceEditPermissionsParams)
+	if !ok {
+		return ""
+	}
+	var diffs []string
+	for _, file := range pr.Files {
+		formatter := core.DiffFormatter().
+			Before(fsext.PrettyPath(file.FilePath), file.OldContent).
+			After(fsext.PrettyPath(file.FilePath), file.NewContent).
+			Width(p.contentViewPort.Width()).
+			XOffset(p.diffXOffset)
+		if p.useDiffSplitMode() {
+			formatter = formatter.Split()
+		} else {
+			formatter = formatter.Unified()
+		}
+		diffs = append(diffs, formatter.String())
+	}
+
+	lines := strings.Split(strings.Join(diffs, "\n\n"), "\n")
+	height := p.contentViewPort.Height()
+	p.diffYOffset = min(p.diffYOffset, max(0, len(lines)-height))
+	lines = lines[p.diffYOffset:]
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (p *permissionDialogCmp) generateCodeActionsContent() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base.Background(t.BgSubtle)
+	if pr, ok := p.permission.Params.(tools.CodeActionsPermissionsParams); ok {
+		content := fmt.Sprintf("Action: %s\nCommand: %s\nFile: %s", pr.Title, pr.Command, fsext.PrettyPath(pr.FilePath))
+
+		finalContent := baseStyle.
+			Padding(1, 2).
+			Width(p.contentViewPort.Width()).
+			Render(content)
+		return finalContent
+	}
+	return ""
+}
+
 func (p *permissionDialogCmp) generateFetchContent() string {
 	t := styles.CurrentTheme()
 	baseStyle := t.S().Base.Background(t.BgSubtle)
@@ -823,6 +895,12 @@ func (p *permissionDialogCmp) SetSize() tea.Cmd {
 	case tools.MultiEditToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.8)
+	case tools.RenameToolName:
+		p.width = int(float64(p.wWidth) * 0.8)
+		p.height = int(float64(p.wHeight) * 0.8)
+	case tools.CodeActionsToolName:
+		p.width = int(float64(p.wWidth) * 0.8)
+		p.height = int(float64(p.wHeight) * 0.8)
 	case tools.FetchToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.3)
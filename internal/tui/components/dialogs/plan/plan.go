@@ -0,0 +1,187 @@
+package plan
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const PlanDialogID dialogs.DialogID = "plan"
+
+// PlanDialog shows the plan of a session so the user can review it before
+// switching the session to execution mode.
+type PlanDialog interface {
+	dialogs.DialogModel
+}
+
+// ExecutePlanMsg is sent when the user approves the plan.
+type ExecutePlanMsg struct {
+	SessionID string
+}
+
+// PlanEditedMsg is sent when the user is done editing the plan in the
+// external editor.
+type PlanEditedMsg struct {
+	SessionID string
+	Plan      string
+}
+
+type planDialogCmp struct {
+	wWidth  int
+	wHeight int
+	width   int
+
+	session  session.Session
+	viewport viewport.Model
+	keyMap   KeyMap
+	help     help.Model
+}
+
+// NewPlanDialog creates a new dialog to review the plan of the given session.
+func NewPlanDialog(s session.Session) PlanDialog {
+	t := styles.CurrentTheme()
+	help := help.New()
+	help.Styles = t.S().Help
+	return &planDialogCmp{
+		session:  s,
+		viewport: viewport.New(),
+		keyMap:   DefaultKeyMap(),
+		help:     help,
+	}
+}
+
+func (p *planDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (p *planDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.wWidth = msg.Width
+		p.wHeight = msg.Height
+		p.setSize()
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, p.keyMap.Execute):
+			if p.session.Plan == "" {
+				return p, util.ReportWarn("There is no plan to execute yet")
+			}
+			return p, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(ExecutePlanMsg{SessionID: p.session.ID}),
+			)
+		case key.Matches(msg, p.keyMap.Edit):
+			return p, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				p.openEditor(),
+			)
+		case key.Matches(msg, p.keyMap.Close):
+			return p, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			var cmd tea.Cmd
+			p.viewport, cmd = p.viewport.Update(msg)
+			return p, cmd
+		}
+	}
+	return p, nil
+}
+
+func (p *planDialogCmp) openEditor() tea.Cmd {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		} else {
+			editor = "nvim"
+		}
+	}
+
+	tmpfile, err := os.CreateTemp("", "plan_*.md")
+	if err != nil {
+		return util.ReportError(err)
+	}
+	defer tmpfile.Close() //nolint:errcheck
+	if _, err := tmpfile.WriteString(p.session.Plan); err != nil {
+		return util.ReportError(err)
+	}
+	sessionID := p.session.ID
+	return util.ExecShell(context.TODO(), editor+" "+tmpfile.Name(), func(err error) tea.Msg {
+		defer os.Remove(tmpfile.Name())
+		if err != nil {
+			return util.ReportError(err)
+		}
+		content, err := os.ReadFile(tmpfile.Name())
+		if err != nil {
+			return util.ReportError(err)
+		}
+		return PlanEditedMsg{
+			SessionID: sessionID,
+			Plan:      strings.TrimSpace(string(content)),
+		}
+	})
+}
+
+func (p *planDialogCmp) setSize() {
+	p.width = min(100, int(float64(p.wWidth)*0.8))
+	p.viewport.SetWidth(p.width - 4)
+
+	content := p.content()
+	maxHeight := max(5, int(float64(p.wHeight)*0.8)-6)
+	p.viewport.SetHeight(min(maxHeight, lipgloss.Height(content)))
+	p.viewport.SetContent(content)
+}
+
+func (p *planDialogCmp) content() string {
+	t := styles.CurrentTheme()
+	if p.session.Plan == "" {
+		return t.S().Muted.Render("There is no plan yet. Toggle plan mode and ask the agent to plan a task.")
+	}
+	rendered, err := styles.GetMarkdownRenderer(p.width - 4).Render(p.session.Plan)
+	if err != nil {
+		return p.session.Plan
+	}
+	return strings.TrimSpace(rendered)
+}
+
+func (p *planDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	title := "Plan"
+	if p.session.PlanMode {
+		title = "Plan (plan mode)"
+	}
+	header := t.S().Base.Padding(0, 1, 1, 1).Render(core.Title(title, p.width-4))
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		t.S().Base.PaddingLeft(1).Render(p.viewport.View()),
+		"",
+		t.S().Base.Width(p.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(p.help.View(p.keyMap)),
+	)
+	return t.S().Base.
+		Width(p.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (p *planDialogCmp) Position() (int, int) {
+	row := p.wHeight/2 - (p.viewport.Height()+6)/2
+	col := p.wWidth/2 - p.width/2
+	return row, col
+}
+
+func (p *planDialogCmp) ID() dialogs.DialogID {
+	return PlanDialogID
+}
@@ -0,0 +1,237 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const QueueDialogID dialogs.DialogID = "queue"
+
+// QueueDialog lets the user edit, reorder and remove the prompts queued in
+// a session, or send them to the agent at its next step.
+type QueueDialog interface {
+	dialogs.DialogModel
+}
+
+// Queue manages the prompts queued in the sessions.
+type Queue interface {
+	QueuedPromptsList(sessionID string) []session.QueuedPrompt
+	EditQueuedPrompt(ctx context.Context, sessionID, id, prompt string) error
+	MoveQueuedPrompt(ctx context.Context, sessionID, id string, offset int) error
+	RemoveQueuedPrompt(ctx context.Context, sessionID, id string) error
+	SteerQueuedPrompt(ctx context.Context, sessionID, id string) error
+}
+
+type queueDialogCmp struct {
+	wWidth  int
+	wHeight int
+	width   int
+
+	queue     Queue
+	sessionID string
+	prompts   []session.QueuedPrompt
+	selected  int
+
+	// Set while editing the selected prompt.
+	editing  string
+	textarea textarea.Model
+
+	keyMap KeyMap
+	help   help.Model
+}
+
+// NewQueueDialog creates a new dialog to manage the prompts queued in the
+// given session.
+func NewQueueDialog(queue Queue, sessionID string) QueueDialog {
+	t := styles.CurrentTheme()
+	ta := textarea.New()
+	ta.SetStyles(t.S().TextArea)
+	ta.ShowLineNumbers = false
+	ta.CharLimit = -1
+	ta.SetVirtualCursor(true)
+	ta.SetHeight(6)
+
+	help := help.New()
+	help.Styles = t.S().Help
+	return &queueDialogCmp{
+		queue:     queue,
+		sessionID: sessionID,
+		prompts:   queue.QueuedPromptsList(sessionID),
+		textarea:  ta,
+		keyMap:    DefaultKeyMap(),
+		help:      help,
+	}
+}
+
+func (q *queueDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+// refresh reloads the queue, which shrinks as the agent takes prompts from
+// it, keeping the same prompt selected if it is still there.
+func (q *queueDialogCmp) refresh() {
+	var selectedID string
+	if q.selected < len(q.prompts) {
+		selectedID = q.prompts[q.selected].ID
+	}
+	q.prompts = q.queue.QueuedPromptsList(q.sessionID)
+	if i := slices.IndexFunc(q.prompts, func(p session.QueuedPrompt) bool {
+		return p.ID == selectedID
+	}); i != -1 {
+		q.selected = i
+	}
+	q.selected = max(0, min(q.selected, len(q.prompts)-1))
+}
+
+func (q *queueDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		q.wWidth = msg.Width
+		q.wHeight = msg.Height
+		q.width = min(100, int(float64(q.wWidth)*0.8))
+		q.textarea.SetWidth(q.width - 4)
+	case tea.KeyPressMsg:
+		if q.editing != "" {
+			return q, q.updateEdit(msg)
+		}
+		q.refresh()
+		if key.Matches(msg, q.keyMap.Close) {
+			return q, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+		if len(q.prompts) == 0 {
+			return q, nil
+		}
+		prompt := q.prompts[q.selected]
+		ctx := context.Background()
+		var err error
+		switch {
+		case key.Matches(msg, q.keyMap.Next):
+			q.selected = (q.selected + 1) % len(q.prompts)
+		case key.Matches(msg, q.keyMap.Previous):
+			q.selected = (q.selected - 1 + len(q.prompts)) % len(q.prompts)
+		case key.Matches(msg, q.keyMap.MoveDown):
+			err = q.queue.MoveQueuedPrompt(ctx, q.sessionID, prompt.ID, 1)
+		case key.Matches(msg, q.keyMap.MoveUp):
+			err = q.queue.MoveQueuedPrompt(ctx, q.sessionID, prompt.ID, -1)
+		case key.Matches(msg, q.keyMap.Remove):
+			err = q.queue.RemoveQueuedPrompt(ctx, q.sessionID, prompt.ID)
+		case key.Matches(msg, q.keyMap.Steer):
+			err = q.queue.SteerQueuedPrompt(ctx, q.sessionID, prompt.ID)
+		case key.Matches(msg, q.keyMap.Edit):
+			q.editing = prompt.ID
+			q.textarea.SetValue(prompt.Prompt)
+			return q, q.textarea.Focus()
+		}
+		q.refresh()
+		return q, reportQueueError(err)
+	}
+	return q, nil
+}
+
+func (q *queueDialogCmp) updateEdit(msg tea.KeyPressMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, q.keyMap.Cancel):
+		q.stopEditing()
+		return nil
+	case key.Matches(msg, q.keyMap.Newline):
+		q.textarea.InsertRune('\n')
+		return nil
+	case key.Matches(msg, q.keyMap.Save):
+		text := strings.TrimSpace(q.textarea.Value())
+		if text == "" {
+			return util.ReportWarn("The prompt is empty")
+		}
+		err := q.queue.EditQueuedPrompt(context.Background(), q.sessionID, q.editing, text)
+		q.stopEditing()
+		return reportQueueError(err)
+	}
+	var cmd tea.Cmd
+	q.textarea, cmd = q.textarea.Update(msg)
+	return cmd
+}
+
+func (q *queueDialogCmp) stopEditing() {
+	q.editing = ""
+	q.textarea.Blur()
+	q.refresh()
+}
+
+func reportQueueError(err error) tea.Cmd {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, agent.ErrQueuedPromptNotFound):
+		return util.ReportWarn("The prompt was already sent")
+	default:
+		return util.ReportError(err)
+	}
+}
+
+func (q *queueDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	parts := []string{
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Queued Prompts", q.width-4)),
+	}
+	switch {
+	case q.editing != "":
+		parts = append(parts,
+			t.S().Base.PaddingLeft(1).Render(q.textarea.View()),
+			"",
+			t.S().Base.Width(q.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(q.help.View(editKeyMap(q.keyMap))),
+		)
+	case len(q.prompts) == 0:
+		parts = append(parts,
+			t.S().Muted.PaddingLeft(1).Render("There are no queued prompts."),
+			"",
+			t.S().Base.Width(q.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(q.help.View(listKeyMap(q.keyMap))),
+		)
+	default:
+		var lines []string
+		for i, prompt := range q.prompts {
+			text, _, _ := strings.Cut(strings.TrimSpace(prompt.Prompt), "\n")
+			line := t.S().Text.Render(text)
+			if prompt.Steer {
+				line += " " + t.S().Subtle.Render("(next step)")
+			}
+			marker := "  "
+			if i == q.selected {
+				marker = t.S().Base.Foreground(t.Primary).Render("> ")
+			}
+			lines = append(lines, t.S().Base.MaxWidth(q.width-4).Render(marker+line))
+		}
+		parts = append(parts,
+			t.S().Base.PaddingLeft(1).Render(strings.Join(lines, "\n")),
+			"",
+			t.S().Base.Width(q.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(q.help.View(listKeyMap(q.keyMap))),
+		)
+	}
+	return t.S().Base.
+		Width(q.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
+
+func (q *queueDialogCmp) Position() (int, int) {
+	row := q.wHeight/4 - 2 // just a bit above the center
+	col := q.wWidth/2 - q.width/2
+	return row, col
+}
+
+func (q *queueDialogCmp) ID() dialogs.DialogID {
+	return QueueDialogID
+}
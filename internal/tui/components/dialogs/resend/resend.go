@@ -0,0 +1,153 @@
+package resend
+
+import (
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const ResendDialogID dialogs.DialogID = "resend"
+
+// ResendDialog lets the user edit an earlier prompt and send it again,
+// rewinding the conversation to that point.
+type ResendDialog interface {
+	dialogs.DialogModel
+}
+
+// ResendMsg is sent when the user submits the edited prompt.
+type ResendMsg struct {
+	SessionID    string
+	MessageID    string
+	Text         string
+	KeepBranch   bool
+	RestoreFiles bool
+}
+
+type resendDialogCmp struct {
+	wWidth  int
+	wHeight int
+	width   int
+
+	message      message.Message
+	textarea     textarea.Model
+	keepBranch   bool
+	restoreFiles bool
+	keyMap       KeyMap
+	help         help.Model
+}
+
+// NewResendDialog creates a new dialog to edit and resend the given user
+// message.
+func NewResendDialog(msg message.Message) ResendDialog {
+	t := styles.CurrentTheme()
+	ta := textarea.New()
+	ta.SetStyles(t.S().TextArea)
+	ta.ShowLineNumbers = false
+	ta.CharLimit = -1
+	ta.SetVirtualCursor(true)
+	ta.SetHeight(6)
+	ta.SetValue(msg.Content().Text)
+	ta.Focus()
+
+	help := help.New()
+	help.Styles = t.S().Help
+	return &resendDialogCmp{
+		message:  msg,
+		textarea: ta,
+		keyMap:   DefaultKeyMap(),
+		help:     help,
+	}
+}
+
+func (r *resendDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (r *resendDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		r.wWidth = msg.Width
+		r.wHeight = msg.Height
+		r.width = min(100, int(float64(r.wWidth)*0.8))
+		r.textarea.SetWidth(r.width - 4)
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, r.keyMap.Close):
+			return r, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case key.Matches(msg, r.keyMap.ToggleBranch):
+			r.keepBranch = !r.keepBranch
+			return r, nil
+		case key.Matches(msg, r.keyMap.ToggleRestore):
+			r.restoreFiles = !r.restoreFiles
+			return r, nil
+		case key.Matches(msg, r.keyMap.Newline):
+			r.textarea.InsertRune('\n')
+			return r, nil
+		case key.Matches(msg, r.keyMap.Submit):
+			text := strings.TrimSpace(r.textarea.Value())
+			if text == "" {
+				return r, util.ReportWarn("The prompt is empty")
+			}
+			return r, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(ResendMsg{
+					SessionID:    r.message.SessionID,
+					MessageID:    r.message.ID,
+					Text:         text,
+					KeepBranch:   r.keepBranch,
+					RestoreFiles: r.restoreFiles,
+				}),
+			)
+		}
+	}
+	var cmd tea.Cmd
+	r.textarea, cmd = r.textarea.Update(msg)
+	return r, cmd
+}
+
+func (r *resendDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	option := func(enabled bool, label string) string {
+		box := "[ ]"
+		if enabled {
+			box = "[x]"
+		}
+		return t.S().Base.PaddingLeft(1).Render(t.S().Subtle.Render(box) + " " + label)
+	}
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Edit Prompt", r.width-4)),
+		t.S().Muted.PaddingLeft(1).Width(r.width-4).Render("Everything after this prompt will be discarded and the agent will run again from here."),
+		"",
+		t.S().Base.PaddingLeft(1).Render(r.textarea.View()),
+		"",
+		option(r.keepBranch, "Keep discarded messages as a hidden branch"),
+		option(r.restoreFiles, "Restore files changed since this prompt"),
+		"",
+		t.S().Base.Width(r.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(r.help.View(r.keyMap)),
+	)
+	return t.S().Base.
+		Width(r.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (r *resendDialogCmp) Position() (int, int) {
+	row := r.wHeight/2 - (r.textarea.Height()+12)/2
+	col := r.wWidth/2 - r.width/2
+	return row, col
+}
+
+func (r *resendDialogCmp) ID() dialogs.DialogID {
+	return ResendDialogID
+}
@@ -0,0 +1,132 @@
+package summary
+
+import (
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const SummaryDialogID dialogs.DialogID = "summary"
+
+// SummaryDialog lets the user review and edit the summary the agent carries
+// the conversation on from.
+type SummaryDialog interface {
+	dialogs.DialogModel
+}
+
+// SaveSummaryMsg is sent when the user saves the edited summary.
+type SaveSummaryMsg struct {
+	SessionID string
+	MessageID string
+	Text      string
+}
+
+type summaryDialogCmp struct {
+	wWidth  int
+	wHeight int
+	width   int
+
+	message  message.Message
+	textarea textarea.Model
+	keyMap   KeyMap
+	help     help.Model
+}
+
+// NewSummaryDialog creates a new dialog to edit the given summary message.
+func NewSummaryDialog(msg message.Message) SummaryDialog {
+	t := styles.CurrentTheme()
+	ta := textarea.New()
+	ta.SetStyles(t.S().TextArea)
+	ta.ShowLineNumbers = false
+	ta.CharLimit = -1
+	ta.SetVirtualCursor(true)
+	ta.SetHeight(15)
+	ta.SetValue(msg.Content().Text)
+	ta.MoveToBegin()
+	ta.Focus()
+
+	help := help.New()
+	help.Styles = t.S().Help
+	return &summaryDialogCmp{
+		message:  msg,
+		textarea: ta,
+		keyMap:   DefaultKeyMap(),
+		help:     help,
+	}
+}
+
+func (s *summaryDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (s *summaryDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.wWidth = msg.Width
+		s.wHeight = msg.Height
+		s.width = min(120, int(float64(s.wWidth)*0.8))
+		s.textarea.SetWidth(s.width - 4)
+		s.textarea.SetHeight(max(5, min(15, s.wHeight-12)))
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, s.keyMap.Close):
+			return s, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case key.Matches(msg, s.keyMap.Newline):
+			s.textarea.InsertRune('\n')
+			return s, nil
+		case key.Matches(msg, s.keyMap.Save):
+			text := strings.TrimSpace(s.textarea.Value())
+			if text == "" {
+				return s, util.ReportWarn("The summary is empty")
+			}
+			return s, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(SaveSummaryMsg{
+					SessionID: s.message.SessionID,
+					MessageID: s.message.ID,
+					Text:      text,
+				}),
+			)
+		}
+	}
+	var cmd tea.Cmd
+	s.textarea, cmd = s.textarea.Update(msg)
+	return s, cmd
+}
+
+func (s *summaryDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Edit Summary", s.width-4)),
+		t.S().Muted.PaddingLeft(1).Width(s.width-4).Render("The agent only sees this summary of the conversation before it, along with the messages after it."),
+		"",
+		t.S().Base.PaddingLeft(1).Render(s.textarea.View()),
+		"",
+		t.S().Base.Width(s.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(s.help.View(s.keyMap)),
+	)
+	return t.S().Base.
+		Width(s.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (s *summaryDialogCmp) Position() (int, int) {
+	row := s.wHeight/2 - (s.textarea.Height()+8)/2
+	col := s.wWidth/2 - s.width/2
+	return row, col
+}
+
+func (s *summaryDialogCmp) ID() dialogs.DialogID {
+	return SummaryDialogID
+}
@@ -11,6 +11,7 @@ import (
 	"charm.land/bubbles/v2/spinner"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent"
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/history"
@@ -36,6 +37,8 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/hyper"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/models"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/reasoning"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/resend"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/summary"
 	"github.com/charmbracelet/crush/internal/tui/page"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
@@ -141,8 +144,8 @@ func New(app *app.App) ChatPage {
 	return &chatPage{
 		app:         app,
 		keyMap:      DefaultKeyMap(),
-		header:      header.New(app.LSPClients),
-		sidebar:     sidebar.New(app.History, app.LSPClients, false),
+		header:      header.New(app.LSPClients, app.MainAgent),
+		sidebar:     sidebar.New(app.History, app.Sessions, app.Messages, app.LSPClients, app.MainAgent, false),
 		chat:        chat.New(app),
 		editor:      editor.New(app),
 		splash:      splash.New(),
@@ -271,6 +274,28 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		return p, p.sendMessage(msg.Text, msg.Attachments)
 	case chat.SessionSelectedMsg:
 		return p, p.setSession(msg)
+	case chat.ForkSessionMsg:
+		return p, p.forkSession(msg.SessionID, msg.MessageID)
+	case chat.EditMessageMsg:
+		if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsSessionBusy(msg.Message.SessionID) {
+			return p, util.ReportWarn("Agent is busy, please wait before editing a prompt...")
+		}
+		return p, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: resend.NewResendDialog(msg.Message),
+		})
+	case resend.ResendMsg:
+		return p, p.resendMessage(msg)
+	case chat.EditSummaryMsg:
+		if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsSessionBusy(msg.Message.SessionID) {
+			return p, util.ReportWarn("Agent is busy, please wait before editing the summary...")
+		}
+		return p, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: summary.NewSummaryDialog(msg.Message),
+		})
+	case summary.SaveSummaryMsg:
+		return p, p.saveSummary(msg)
+	case commands.ContinueMsg:
+		return p, p.continueTurn(msg.SessionID)
 	case splash.SubmitAPIKeyMsg:
 		u, cmd := p.splash.Update(msg)
 		p.splash = u.(splash.Splash)
@@ -293,6 +318,8 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		return p, p.openReasoningDialog()
 	case reasoning.ReasoningEffortSelectedMsg:
 		return p, p.handleReasoningEffortSelected(msg.Effort)
+	case commands.TogglePlanModeMsg:
+		return p, p.togglePlanMode()
 	case commands.OpenExternalEditorMsg:
 		u, cmd := p.editor.Update(msg)
 		p.editor = u.(editor.Editor)
@@ -330,6 +357,7 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 		return p, tea.Batch(cmds...)
 	case filepicker.FilePickedMsg,
+		editor.SymbolCompletionsMsg,
 		completions.CompletionsClosedMsg,
 		completions.SelectCompletionMsg:
 		u, cmd := p.editor.Update(msg)
@@ -388,7 +416,7 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		u, cmd := p.editor.Update(msg)
 		p.editor = u.(editor.Editor)
 		return p, cmd
-	case pubsub.Event[history.File], sidebar.SessionFilesMsg:
+	case pubsub.Event[history.File], sidebar.SessionFilesMsg, sidebar.SubAgentUsageMsg:
 		u, cmd := p.sidebar.Update(msg)
 		p.sidebar = u.(sidebar.Sidebar)
 		cmds = append(cmds, cmd)
@@ -482,6 +510,10 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			if p.session.ID != "" && p.pillsExpanded {
 				return p, p.switchPillSection(1)
 			}
+		case key.Matches(msg, p.keyMap.ManageQueue):
+			if p.session.ID != "" && p.promptQueue > 0 {
+				return p, util.CmdHandler(commands.ManageQueueMsg{SessionID: p.session.ID})
+			}
 		}
 
 		switch p.focusedPane {
@@ -869,10 +901,48 @@ func (p *chatPage) setSession(sess session.Session) tea.Cmd {
 	cmds = append(cmds, p.sidebar.SetSession(sess))
 	cmds = append(cmds, p.header.SetSession(sess))
 	cmds = append(cmds, p.editor.SetSession(sess))
+	if sess.MessageCount > 0 {
+		// New sessions have nothing to resume, and their first prompt may
+		// already be running.
+		cmds = append(cmds, p.resumeSession(sess.ID))
+	}
 
 	return tea.Sequence(cmds...)
 }
 
+// resumeSession finishes the turn crush stopped in the middle of, if any,
+// and runs the prompts left in the queue of the session.
+func (p *chatPage) resumeSession(sessionID string) tea.Cmd {
+	if p.app.AgentCoordinator == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		ctx := context.Background()
+		if _, err := p.app.AgentCoordinator.Recover(ctx, sessionID); err != nil {
+			return util.InfoMsg{
+				Type: util.InfoTypeError,
+				Msg:  fmt.Sprintf("Failed to recover the interrupted turn: %v", err),
+			}
+		}
+		if p.app.AgentCoordinator.QueuedPrompts(sessionID) == 0 {
+			return nil
+		}
+		_, err := p.app.AgentCoordinator.ResumeQueue(ctx, sessionID)
+		if err != nil {
+			isCancelErr := errors.Is(err, context.Canceled)
+			isPermissionErr := errors.Is(err, permission.ErrorPermissionDenied)
+			if isCancelErr || isPermissionErr {
+				return nil
+			}
+			return util.InfoMsg{
+				Type: util.InfoTypeError,
+				Msg:  err.Error(),
+			}
+		}
+		return nil
+	}
+}
+
 func (p *chatPage) changeFocus() tea.Cmd {
 	if p.session.ID == "" {
 		return nil
@@ -990,6 +1060,126 @@ func (p *chatPage) sendMessage(text string, attachments []message.Attachment) te
 	return tea.Batch(cmds...)
 }
 
+func (p *chatPage) resendMessage(msg resend.ResendMsg) tea.Cmd {
+	if p.app.AgentCoordinator == nil {
+		return util.ReportError(fmt.Errorf("coder agent is not initialized"))
+	}
+	opts := agent.ResendOptions{
+		KeepBranch:   msg.KeepBranch,
+		RestoreFiles: msg.RestoreFiles,
+	}
+	return tea.Batch(
+		p.chat.GoToBottom(),
+		func() tea.Msg {
+			_, err := p.app.AgentCoordinator.Resend(context.Background(), msg.SessionID, msg.MessageID, msg.Text, opts)
+			if err != nil {
+				isCancelErr := errors.Is(err, context.Canceled)
+				isPermissionErr := errors.Is(err, permission.ErrorPermissionDenied)
+				if isCancelErr || isPermissionErr {
+					return nil
+				}
+				return util.InfoMsg{
+					Type: util.InfoTypeError,
+					Msg:  err.Error(),
+				}
+			}
+			return nil
+		},
+	)
+}
+
+func (p *chatPage) saveSummary(msg summary.SaveSummaryMsg) tea.Cmd {
+	if p.app.AgentCoordinator == nil {
+		return util.ReportError(fmt.Errorf("coder agent is not initialized"))
+	}
+	return func() tea.Msg {
+		if err := p.app.AgentCoordinator.EditSummary(context.Background(), msg.SessionID, msg.MessageID, msg.Text); err != nil {
+			return util.InfoMsg{
+				Type: util.InfoTypeError,
+				Msg:  err.Error(),
+			}
+		}
+		return util.InfoMsg{
+			Type: util.InfoTypeInfo,
+			Msg:  "Summary updated",
+		}
+	}
+}
+
+func (p *chatPage) continueTurn(sessionID string) tea.Cmd {
+	if p.app.AgentCoordinator == nil {
+		return util.ReportError(fmt.Errorf("coder agent is not initialized"))
+	}
+	if p.app.AgentCoordinator.IsSessionBusy(sessionID) {
+		return util.ReportWarn("Agent is busy, please wait...")
+	}
+	return tea.Batch(
+		p.chat.GoToBottom(),
+		func() tea.Msg {
+			_, err := p.app.AgentCoordinator.Continue(context.Background(), sessionID)
+			if errors.Is(err, agent.ErrNotInterrupted) {
+				return util.ReportInfo("The last turn of this session was not interrupted")()
+			}
+			if err != nil {
+				isCancelErr := errors.Is(err, context.Canceled)
+				isPermissionErr := errors.Is(err, permission.ErrorPermissionDenied)
+				if isCancelErr || isPermissionErr {
+					return nil
+				}
+				return util.InfoMsg{
+					Type: util.InfoTypeError,
+					Msg:  err.Error(),
+				}
+			}
+			return nil
+		},
+	)
+}
+
+func (p *chatPage) togglePlanMode() tea.Cmd {
+	if p.app.AgentCoordinator == nil {
+		return util.ReportError(fmt.Errorf("coder agent is not initialized"))
+	}
+	if p.app.AgentCoordinator.IsSessionBusy(p.session.ID) {
+		return util.ReportWarn("Agent is busy, please wait before switching modes...")
+	}
+	session := p.session
+	var cmds []tea.Cmd
+	if session.ID == "" {
+		newSession, err := p.app.Sessions.Create(context.Background(), "New Session")
+		if err != nil {
+			return util.ReportError(err)
+		}
+		session = newSession
+		cmds = append(cmds, util.CmdHandler(chat.SessionSelectedMsg(session)))
+	}
+	planMode := !session.PlanMode
+	cmds = append(cmds, func() tea.Msg {
+		if err := p.app.AgentCoordinator.SetPlanMode(context.Background(), session.ID, planMode); err != nil {
+			return util.ReportError(err)()
+		}
+		if planMode {
+			return util.ReportInfo("Plan mode enabled, the agent will only use read-only tools")()
+		}
+		return util.ReportInfo("Plan mode disabled")()
+	})
+	return tea.Sequence(cmds...)
+}
+
+func (p *chatPage) forkSession(sessionID, messageID string) tea.Cmd {
+	if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsSessionBusy(sessionID) {
+		return util.ReportWarn("Agent is busy, please wait before forking the session...")
+	}
+	fork, err := p.app.Sessions.Fork(context.Background(), sessionID, messageID)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	return tea.Sequence(
+		util.CmdHandler(chat.SessionSelectedMsg(fork)),
+		util.ReportInfo("Forked session: "+fork.Title),
+	)
+}
+
 func (p *chatPage) Bindings() []key.Binding {
 	bindings := []key.Binding{
 		p.keyMap.NewSession,
@@ -1248,6 +1438,10 @@ func (p *chatPage) Help() help.KeyMap {
 				shortList = append(shortList, p.keyMap.PillLeft)
 				globalBindings = append(globalBindings, p.keyMap.PillLeft)
 			}
+			if hasQueue {
+				shortList = append(shortList, p.keyMap.ManageQueue)
+				globalBindings = append(globalBindings, p.keyMap.ManageQueue)
+			}
 		}
 		commandsBinding := key.NewBinding(
 			key.WithKeys("ctrl+p"),
@@ -1338,6 +1532,8 @@ func (p *chatPage) Help() help.KeyMap {
 				[]key.Binding{
 					messages.CopyKey,
 					messages.ClearSelectionKey,
+					messages.EditKey,
+					messages.ForkKey,
 				},
 			)
 		case PanelTypeEditor:
@@ -98,18 +98,23 @@ func todoList(sessionTodos []session.Todo, spinnerView string, t *styles.Theme,
 	return todos.FormatTodosList(sessionTodos, spinnerView, t, width)
 }
 
-func queueList(queueItems []string, t *styles.Theme) string {
+func queueList(queueItems []session.QueuedPrompt, t *styles.Theme) string {
 	if len(queueItems) == 0 {
 		return ""
 	}
 
 	var lines []string
 	for _, item := range queueItems {
-		text := item
+		text := item.Prompt
 		if len(text) > maxQueueDisplayLength {
 			text = text[:maxQueueDisplayLength-1] + "…"
 		}
-		prefix := t.S().Base.Foreground(t.FgMuted).Render("  •") + " "
+		bullet := "  •"
+		if item.Steer {
+			// Sent to the agent at its next step.
+			bullet = "  ↳"
+		}
+		prefix := t.S().Base.Foreground(t.FgMuted).Render(bullet) + " "
 		lines = append(lines, prefix+t.S().Base.Foreground(t.FgMuted).Render(text))
 	}
 
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"regexp"
@@ -12,10 +13,14 @@ import (
 	"charm.land/bubbles/v2/key"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/checkpoint"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/memory"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/stringext"
@@ -26,10 +31,15 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
 	"github.com/charmbracelet/crush/internal/tui/components/core/status"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/agents"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/checkpoints"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/memories"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/models"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/permissions"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/plan"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/queue"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/quit"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/sessions"
 	"github.com/charmbracelet/crush/internal/tui/page"
@@ -167,9 +177,20 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a, handleMCPToolsEvent(context.Background(), msg.Payload.Name)
 		}
 
+	case pubsub.Event[agent.FallbackEvent]:
+		cfg := config.Get()
+		modelName := func(model config.SelectedModel) string {
+			if m := cfg.GetModel(model.Provider, model.Model); m != nil {
+				return m.Name
+			}
+			return model.Model
+		}
+		return a, util.ReportWarn(fmt.Sprintf("%s is unavailable, switched to %s", modelName(msg.Payload.From), modelName(msg.Payload.To)))
+
 	// Completions messages
 	case completions.OpenCompletionsMsg, completions.FilterCompletionsMsg,
-		completions.CloseCompletionsMsg, completions.RepositionCompletionsMsg:
+		completions.ExtendCompletionsMsg, completions.CloseCompletionsMsg,
+		completions.RepositionCompletionsMsg:
 		u, completionCmd := a.completions.Update(msg)
 		if model, ok := u.(completions.Completions); ok {
 			a.completions = model
@@ -254,6 +275,84 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				Model: models.NewModelDialogCmp(),
 			},
 		)
+	case commands.SwitchAgentMsg:
+		return a, util.CmdHandler(
+			dialogs.OpenDialogMsg{
+				Model: agents.NewAgentsDialog(a.app.AgentCoordinator.MainAgent().ID),
+			},
+		)
+	case agents.AgentSelectedMsg:
+		return a, func() tea.Msg {
+			if err := a.app.AgentCoordinator.SetMainAgent(context.Background(), msg.Agent.ID); err != nil {
+				return util.ReportError(err)()
+			}
+			return util.ReportInfo(fmt.Sprintf("Agent changed to %s", msg.Agent.Name))()
+		}
+	// Plan
+	case commands.ReviewPlanMsg:
+		if a.selectedSessionID == "" {
+			return a, util.ReportWarn("No session selected")
+		}
+		return a, a.openPlanDialog(a.selectedSessionID)
+	case plan.PlanEditedMsg:
+		return a, func() tea.Msg {
+			ctx := context.Background()
+			s, err := a.app.Sessions.Get(ctx, msg.SessionID)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			s.Plan = msg.Plan
+			if _, err := a.app.Sessions.Save(ctx, s); err != nil {
+				return util.ReportError(err)()
+			}
+			return a.openPlanDialog(msg.SessionID)()
+		}
+	case plan.ExecutePlanMsg:
+		return a, func() tea.Msg {
+			if err := a.app.AgentCoordinator.SetPlanMode(context.Background(), msg.SessionID, false); err != nil {
+				return util.ReportError(err)()
+			}
+			return util.ReportInfo("Switched to execution mode, the plan will be shared with the agent")()
+		}
+	// Checkpoints
+	case commands.UndoMsg:
+		if a.app.AgentCoordinator != nil && a.app.AgentCoordinator.IsSessionBusy(msg.SessionID) {
+			return a, util.ReportWarn("Agent is busy, please wait before undoing changes...")
+		}
+		return a, func() tea.Msg {
+			result, err := a.app.Checkpoints.Undo(context.Background(), msg.SessionID)
+			if errors.Is(err, checkpoint.ErrNoChanges) {
+				return util.ReportInfo("No file changes to undo")()
+			}
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			return restoreReport(result)
+		}
+	case commands.RestoreCheckpointMsg:
+		return a, a.openCheckpointsDialog(msg.SessionID)
+	case commands.ManageQueueMsg:
+		if a.app.AgentCoordinator == nil {
+			return a, util.ReportWarn("Agent is not initialized yet")
+		}
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: queue.NewQueueDialog(a.app.AgentCoordinator, msg.SessionID),
+		})
+	case commands.OpenMemoriesMsg:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: memories.NewMemoriesDialog(memory.NewStore(config.Get().Options.DataDirectory)),
+		})
+	case checkpoints.RestoreCheckpointMsg:
+		if a.app.AgentCoordinator != nil && a.app.AgentCoordinator.IsSessionBusy(msg.Checkpoint.SessionID) {
+			return a, util.ReportWarn("Agent is busy, please wait before restoring a checkpoint...")
+		}
+		return a, func() tea.Msg {
+			result, err := a.app.Checkpoints.Restore(context.Background(), msg.Checkpoint)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			return restoreReport(result)
+		}
 	// Compact
 	case commands.CompactMsg:
 		return a, func() tea.Msg {
@@ -422,6 +521,71 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return a, tea.Batch(cmds...)
 }
 
+// openCheckpointsDialog opens the dialog to pick a checkpoint of the given
+// session to restore.
+func (a *appModel) openCheckpointsDialog(sessionID string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		cps, err := a.app.Checkpoints.List(ctx, sessionID)
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		if len(cps) == 0 {
+			return util.ReportInfo("There are no checkpoints in this session yet")()
+		}
+		items := make([]checkpoints.Item, len(cps))
+		for i, cp := range cps {
+			changes, err := a.app.Checkpoints.Changes(ctx, cp)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			items[i] = checkpoints.Item{
+				Checkpoint: cp,
+				Changes:    changes,
+			}
+			if msg, err := a.app.Messages.Get(ctx, cp.MessageID); err == nil {
+				items[i].Prompt = msg.Content().Text
+			}
+		}
+		return dialogs.OpenDialogMsg{
+			Model: checkpoints.NewCheckpointsDialog(items),
+		}
+	}
+}
+
+// restoreReport tells the user how restoring a checkpoint went.
+func restoreReport(result checkpoint.Result) tea.Msg {
+	if len(result.Reverted) == 0 && len(result.Conflicts) == 0 {
+		return util.ReportInfo(fmt.Sprintf("Restored checkpoint #%d, no files needed changes", result.Checkpoint.Number))()
+	}
+	files := "files"
+	if len(result.Reverted) == 1 {
+		files = "file"
+	}
+	report := fmt.Sprintf("Restored checkpoint #%d, reverted %d %s", result.Checkpoint.Number, len(result.Reverted), files)
+	if len(result.Conflicts) == 0 {
+		return util.ReportInfo(report)()
+	}
+	paths := make([]string, len(result.Conflicts))
+	for i, conflict := range result.Conflicts {
+		paths[i] = fsext.PrettyPath(conflict.Path)
+	}
+	return util.ReportWarn(fmt.Sprintf("%s, kept files changed outside of crush: %s", report, strings.Join(paths, ", ")))()
+}
+
+// openPlanDialog opens the dialog to review the plan of the given session.
+func (a *appModel) openPlanDialog(sessionID string) tea.Cmd {
+	return func() tea.Msg {
+		s, err := a.app.Sessions.Get(context.Background(), sessionID)
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		return dialogs.OpenDialogMsg{
+			Model: plan.NewPlanDialog(s),
+		}
+	}
+}
+
 // handleWindowResize processes window resize events and updates all components.
 func (a *appModel) handleWindowResize(width, height int) tea.Cmd {
 	var cmds []tea.Cmd
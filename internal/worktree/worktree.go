@@ -0,0 +1,285 @@
+// Package worktree manages the git worktrees sub-agents work in, so that
+// several of them can change files at the same time without stepping on each
+// other or on the user's working tree.
+package worktree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned when a worktree does not exist.
+var ErrNotFound = errors.New("worktree not found")
+
+// SessionDir returns the directory holding the worktrees of a session.
+func SessionDir(dataDir, sessionID string) string {
+	return filepath.Join(dataDir, "worktrees", sessionID)
+}
+
+// Path returns the directory of a worktree of a session.
+func Path(dataDir, sessionID, id string) string {
+	return filepath.Join(SessionDir(dataDir, sessionID), id)
+}
+
+// ValidID reports whether id can be used as the name of a worktree.
+func ValidID(id string) bool {
+	return id != "" && id != "." && id != ".." && filepath.Base(id) == id
+}
+
+// Root returns the top level directory of the repository dir belongs to.
+func Root(ctx context.Context, dir string) (string, error) {
+	out, err := git(ctx, dir, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Create adds a worktree at path, detached at the HEAD of the repository
+// repoDir belongs to. Uncommitted and untracked changes are carried over,
+// except for the ones in dataDir, where crush keeps its database and the
+// worktrees themselves. The result is committed in the worktree so that Diff
+// only reports what was changed in the worktree afterwards. It returns the
+// directory matching repoDir inside of the worktree.
+func Create(ctx context.Context, repoDir, dataDir, path string) (string, error) {
+	root, err := Root(ctx, repoDir)
+	if err != nil {
+		return "", err
+	}
+	// Git reports the root with symlinks resolved.
+	if dir, err := filepath.EvalSymlinks(repoDir); err == nil {
+		repoDir = dir
+	}
+	rel, err := filepath.Rel(root, repoDir)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if _, err := git(ctx, root, nil, "worktree", "add", "--detach", path, "HEAD"); err != nil {
+		return "", err
+	}
+	if dir, err := filepath.EvalSymlinks(dataDir); err == nil {
+		dataDir = dir
+	}
+	if err := copyChanges(ctx, root, dataDir, path); err != nil {
+		_ = Remove(ctx, root, path)
+		return "", err
+	}
+	return filepath.Join(path, rel), nil
+}
+
+// copyChanges brings the uncommitted and untracked files of the repository
+// at root over to the worktree at path and commits them there. Ignored files
+// and the ones in dataDir are left out.
+func copyChanges(ctx context.Context, root, dataDir, path string) error {
+	patch, err := git(ctx, root, nil, "diff", "--binary", "HEAD")
+	if err != nil {
+		return err
+	}
+	if patch != "" {
+		if _, err := git(ctx, path, strings.NewReader(patch), "apply", "--binary", "-"); err != nil {
+			return err
+		}
+	}
+
+	untracked, err := git(ctx, root, nil, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return err
+	}
+	for name := range strings.SplitSeq(untracked, "\x00") {
+		if name == "" || within(filepath.Join(root, name), dataDir) {
+			continue
+		}
+		// Nested repositories are listed as directories.
+		if err := copyTree(filepath.Join(root, name), filepath.Join(path, name)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := git(ctx, path, nil, "add", "-A"); err != nil {
+		return err
+	}
+	_, err = git(
+		ctx, path, nil,
+		"-c", "user.name=crush",
+		"-c", "user.email=crush@charm.land",
+		"-c", "commit.gpgsign=false",
+		"commit", "--quiet", "--no-verify", "--allow-empty",
+		"-m", "Uncommitted changes",
+	)
+	return err
+}
+
+// within reports whether path is dir or inside of it.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// copyTree copies the file or directory at src to dst.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, name)
+		if err != nil {
+			return err
+		}
+		return copyFile(name, filepath.Join(dst, rel))
+	})
+}
+
+func copyFile(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Diff returns the changes made in the worktree at path since it was
+// created, as a patch relative to the root of the repository.
+func Diff(ctx context.Context, path string) (string, error) {
+	if err := stage(ctx, path); err != nil {
+		return "", err
+	}
+	return git(ctx, path, nil, "diff", "--cached", "--binary", "HEAD")
+}
+
+// ChangedFiles returns the files changed in the worktree at path since it
+// was created, relative to the root of the repository.
+func ChangedFiles(ctx context.Context, path string) ([]string, error) {
+	if err := stage(ctx, path); err != nil {
+		return nil, err
+	}
+	out, err := git(ctx, path, nil, "diff", "--cached", "--name-only", "--no-renames", "-z", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for name := range strings.SplitSeq(out, "\x00") {
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+func stage(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	_, err := git(ctx, path, nil, "add", "-A")
+	return err
+}
+
+// Apply applies the changes made in the worktree at path to the repository
+// repoDir belongs to. Nothing is changed if any of them does not apply.
+func Apply(ctx context.Context, path, repoDir string) error {
+	patch, err := Diff(ctx, path)
+	if err != nil {
+		return err
+	}
+	if patch == "" {
+		return nil
+	}
+	root, err := Root(ctx, repoDir)
+	if err != nil {
+		return err
+	}
+	_, err = git(ctx, root, strings.NewReader(patch), "apply", "--binary", "-")
+	return err
+}
+
+// Remove deletes the worktree at path.
+func Remove(ctx context.Context, repoDir, path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	root, err := Root(ctx, repoDir)
+	if err != nil {
+		return err
+	}
+	if _, err := git(ctx, root, nil, "worktree", "remove", "--force", path); err == nil {
+		return nil
+	}
+	// The worktree may be broken, or not known to git anymore.
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	_, err = git(ctx, root, nil, "worktree", "prune")
+	return err
+}
+
+// RemoveSession deletes every worktree of a session.
+func RemoveSession(ctx context.Context, repoDir, dataDir, sessionID string) error {
+	dir := SessionDir(dataDir, sessionID)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() {
+			errs = append(errs, Remove(ctx, repoDir, filepath.Join(dir, entry.Name())))
+		}
+	}
+	errs = append(errs, os.RemoveAll(dir))
+	return errors.Join(errs...)
+}
+
+func git(ctx context.Context, dir string, stdin io.Reader, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), msg)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,87 @@
+package worktree
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorktree(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	write := func(dir, name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	read := func(dir, name string) string {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	run("init", "--quiet")
+	write(repo, ".gitignore", "*.log\n")
+	write(repo, "main.go", "package main\n")
+	write(repo, "removed.go", "package main\n")
+	run("add", "-A")
+	run("-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "initial")
+
+	// Uncommitted and untracked changes are carried over to the worktree.
+	write(repo, "main.go", "package main\n\nfunc main() {}\n")
+	write(repo, "notes/todo.md", "todo\n")
+	write(repo, "nested/lib.go", "package nested\n")
+	run("-C", "nested", "init", "--quiet")
+	// Neither ignored files nor the data directory are carried over.
+	write(repo, "debug.log", "log\n")
+	dataDir := filepath.Join(repo, ".crush")
+	write(dataDir, "crush.db", "data\n")
+
+	path := Path(dataDir, "session", "call")
+	dir, err := Create(t.Context(), repo, dataDir, path)
+	require.NoError(t, err)
+	require.Equal(t, "package main\n\nfunc main() {}\n", read(dir, "main.go"))
+	require.Equal(t, "todo\n", read(dir, "notes/todo.md"))
+	require.Equal(t, "package nested\n", read(dir, "nested/lib.go"))
+	require.NoFileExists(t, filepath.Join(dir, "debug.log"))
+	require.NoDirExists(t, filepath.Join(dir, ".crush"))
+
+	diff, err := Diff(t.Context(), path)
+	require.NoError(t, err)
+	require.Empty(t, diff)
+
+	// Changes in the worktree do not touch the repository.
+	write(dir, "main.go", "package main\n\nfunc main() { println() }\n")
+	write(dir, "added.go", "package main\n")
+	require.NoError(t, os.Remove(filepath.Join(dir, "removed.go")))
+	require.Equal(t, "package main\n\nfunc main() {}\n", read(repo, "main.go"))
+
+	files, err := ChangedFiles(t.Context(), path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"added.go", "main.go", "removed.go"}, files)
+
+	require.NoError(t, Apply(t.Context(), path, repo))
+	require.Equal(t, "package main\n\nfunc main() { println() }\n", read(repo, "main.go"))
+	require.Equal(t, "package main\n", read(repo, "added.go"))
+	require.NoFileExists(t, filepath.Join(repo, "removed.go"))
+	require.Equal(t, "todo\n", read(repo, "notes/todo.md"))
+
+	require.NoError(t, RemoveSession(t.Context(), repo, dataDir, "session"))
+	require.NoDirExists(t, SessionDir(dataDir, "session"))
+
+	_, err = Diff(t.Context(), path)
+	require.ErrorIs(t, err, ErrNotFound)
+}